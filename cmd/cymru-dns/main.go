@@ -0,0 +1,84 @@
+// Copyright 2019 Freman/Fremnet (Shannon Wynter). All rights reserved.
+
+// Command cymru-dns serves the Team Cymru IP-ASN zones from a local dataset
+// or snapshot over UDP and TCP.
+//
+// Usage:
+//
+//	cymru-dns -listen 127.0.0.1:5353 [-snapshot ipasn.db | [-mrt rib.bz2]... [-pfx2as file]... [-iptoasn file]... [-asnames file]...]
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/freman/cymru/ipasn"
+	"github.com/freman/cymru/ipasn/dnsserver"
+	"github.com/freman/cymru/ipasn/localdb"
+)
+
+func main() {
+	var (
+		listen, zone, snapshot string
+		ttl                    time.Duration
+		datasets               localdb.Datasets
+	)
+
+	flag.StringVar(&listen, "listen", "127.0.0.1:5353", "UDP and TCP address to listen on")
+	flag.StringVar(&zone, "zone", dnsserver.DefaultZone, "Zone to serve")
+	flag.DurationVar(&ttl, "ttl", time.Hour, "TTL of the answers")
+	flag.StringVar(&snapshot, "snapshot", "", "localdb snapshot to serve")
+	datasets.RegisterFlags(flag.CommandLine)
+	flag.Parse()
+
+	if snapshot != "" && len(datasets.MRT)+len(datasets.Pfx2AS)+len(datasets.IPToASN)+len(datasets.ASNames) > 0 {
+		fmt.Println("Please pass either -snapshot or dataset files, not both")
+		os.Exit(1)
+	}
+
+	var resolver ipasn.Resolver
+
+	if snapshot != "" {
+		s, err := localdb.OpenSnapshot(snapshot)
+		if err != nil {
+			fmt.Println("Error opening snapshot:", err)
+			os.Exit(1)
+		}
+		defer s.Close()
+
+		resolver = s
+	} else {
+		db := localdb.New()
+
+		if err := db.LoadDatasets(datasets); err != nil {
+			fmt.Println("Error loading dataset:", err)
+			os.Exit(1)
+		}
+
+		resolver = db
+	}
+
+	server := &dnsserver.Server{
+		Addr:     listen,
+		Zone:     zone,
+		Resolver: resolver,
+		TTL:      ttl,
+	}
+
+	if err := server.Start(); err != nil {
+		fmt.Println("Error starting server:", err)
+		os.Exit(1)
+	}
+
+	fmt.Println("Serving", zone, "on", server.LocalAddr())
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	<-signals
+
+	_ = server.Close()
+}
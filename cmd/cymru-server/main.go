@@ -0,0 +1,91 @@
+// Copyright 2019 Freman/Fremnet (Shannon Wynter). All rights reserved.
+
+// Command cymru-server serves ipasn lookups over an HTTP/JSON API, see the
+// httpapi package for the endpoints.
+//
+// Usage:
+//
+//	cymru-server -listen 127.0.0.1:8080 [-resolver host:port] [-zone asn.cymru.com.] [-provider cymru,routeviews] [-timeout 5s] [-ttl 4h] [-error-ttl 1m] [-cache-size 100000] [-max-batch 1000]
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/freman/cymru/ipasn"
+	"github.com/freman/cymru/ipasn/httpapi"
+)
+
+func main() {
+	var (
+		cfg      ipasn.Config
+		listen   string
+		timeout  time.Duration
+		errorTTL time.Duration
+		maxBatch int
+	)
+
+	flag.StringVar(&listen, "listen", "127.0.0.1:8080", "HTTP address to listen on")
+	cfg.RegisterFlags(flag.CommandLine)
+	flag.DurationVar(&timeout, "timeout", 5*time.Second, "Timeout for each lookup")
+	flag.DurationVar(&cfg.CacheTTL, "ttl", 4*time.Hour, "How long answers are cached locally, clients are given the time left in the cache, not the DNS TTL, as max-age so this should match the TTL of the zone")
+	flag.DurationVar(&errorTTL, "error-ttl", time.Minute, "The max-age of errors such as not found")
+	flag.IntVar(&cfg.CacheSize, "cache-size", 100000, "Number of answers to cache")
+	flag.IntVar(&maxBatch, "max-batch", 1000, "Maximum number of queries in a batch")
+	flag.Parse()
+
+	client, err := cfg.NewClient()
+	if err != nil {
+		fmt.Println("Error:", err)
+		os.Exit(2)
+	}
+
+	srv := &http.Server{
+		Addr: listen,
+		Handler: &httpapi.Handler{
+			Client:      client,
+			MaxAge:      cfg.CacheTTL,
+			ErrorMaxAge: errorTTL,
+			Timeout:     timeout,
+			MaxBatch:    maxBatch,
+		},
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	l, err := net.Listen("tcp", listen)
+	if err != nil {
+		fmt.Println("Error starting server:", err)
+		os.Exit(1)
+	}
+
+	fmt.Println("Serving on", l.Addr())
+
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+
+		signals := make(chan os.Signal, 1)
+		signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+		<-signals
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		_ = srv.Shutdown(ctx)
+	}()
+
+	if err := srv.Serve(l); err != http.ErrServerClosed {
+		fmt.Println("Error serving:", err)
+		os.Exit(1)
+	}
+
+	<-done
+}
@@ -0,0 +1,87 @@
+// Copyright 2019 Freman/Fremnet (Shannon Wynter). All rights reserved.
+
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/freman/cymru/ipasn"
+	"github.com/freman/cymru/ipasn/enrich"
+)
+
+// fields is a repeatable flag that also accepts comma separated lists
+type fields []string
+
+func (f *fields) String() string {
+	return strings.Join(*f, ",")
+}
+
+func (f *fields) Set(s string) error {
+	*f = append(*f, strings.Split(s, ",")...)
+	return nil
+}
+
+// enrichMain copies stdin to stdout annotating the addresses in each line, or
+// the given fields of each JSON record
+func enrichMain(args []string) int {
+	var (
+		cf         clientFlags
+		substitute bool
+		jsonFields fields
+	)
+
+	fs := flag.NewFlagSet("cymru enrich", flag.ExitOnError)
+	cf.register(fs, 16)
+	fs.BoolVar(&substitute, "substitute", false, "Replace addresses with their annotation instead of appending it")
+	fs.Var(&jsonFields, "field", "Treat stdin as JSON Lines and annotate the address in this field, eg: client.ip (repeatable)")
+	_ = fs.Parse(args)
+
+	client, err := cf.client(ipasn.WithCache(ipasn.NewMemoryCache(time.Hour, 100000)))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+
+	e := &enrich.Enricher{
+		Client:      client,
+		Concurrency: cf.concurrency,
+		Timeout:     cf.timeout,
+	}
+
+	if substitute {
+		e.Mode = enrich.Substitute
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+
+	go func() {
+		<-signals
+		cancel()
+	}()
+
+	enrichFn := e.Enrich
+	if len(jsonFields) > 0 {
+		enrichFn = func(ctx context.Context, r io.Reader, w io.Writer) error {
+			return e.EnrichJSON(ctx, r, w, jsonFields...)
+		}
+	}
+
+	if err := enrichFn(ctx, os.Stdin, os.Stdout); err != nil && ctx.Err() == nil {
+		fmt.Fprintln(os.Stderr, "Error enriching:", err)
+		return 1
+	}
+
+	return 0
+}
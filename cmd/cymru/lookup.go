@@ -0,0 +1,213 @@
+// Copyright 2019 Freman/Fremnet (Shannon Wynter). All rights reserved.
+
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/freman/cymru/ipasn"
+)
+
+// query is a parsed argument, either an address (a prefix is looked up by its
+// network address) or an ASN
+type query struct {
+	input string
+	ip    net.IP
+	asn   int
+	err   error
+}
+
+func parseQuery(input string) query {
+	q := query{input: input}
+
+	if ip := net.ParseIP(input); ip != nil {
+		q.ip = ip
+		return q
+	}
+
+	if _, network, err := net.ParseCIDR(input); err == nil {
+		q.ip = network.IP
+		return q
+	}
+
+	digits := input
+	if len(digits) > 2 && strings.EqualFold(digits[:2], "AS") {
+		digits = digits[2:]
+	}
+
+	if asn, err := strconv.Atoi(digits); err == nil && asn > 0 {
+		q.asn = asn
+		return q
+	}
+
+	q.err = errors.New("not an IP address, prefix or ASN")
+
+	return q
+}
+
+func readArgs(args []string, queries chan<- query) {
+	for _, arg := range args {
+		queries <- parseQuery(arg)
+	}
+}
+
+// readQueries reads whitespace separated queries, ignoring # comments
+func readQueries(r io.Reader, queries chan<- query) error {
+	scanner := bufio.NewScanner(r)
+
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.IndexByte(line, '#'); i >= 0 {
+			line = line[:i]
+		}
+
+		for _, field := range strings.Fields(line) {
+			queries <- parseQuery(field)
+		}
+	}
+
+	return scanner.Err()
+}
+
+type result struct {
+	query query
+	row   []interface{}
+	err   error
+}
+
+// lookupAll runs the command over the queries with the given concurrency,
+// returning the results in the same order as the queries
+func lookupAll(client *ipasn.Client, cmd command, queries <-chan query, concurrency int, timeout time.Duration) <-chan result {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	// Each query gets a channel for its result, queued in order
+	pending := make(chan chan result, concurrency)
+	results := make(chan result)
+	sem := make(chan struct{}, concurrency)
+
+	go func() {
+		defer close(pending)
+
+		var wg sync.WaitGroup
+
+		for q := range queries {
+			ch := make(chan result, 1)
+			pending <- ch
+			sem <- struct{}{}
+
+			wg.Add(1)
+
+			go func(q query) {
+				defer func() {
+					<-sem
+					wg.Done()
+				}()
+
+				res := result{query: q, err: q.err}
+
+				if res.err == nil {
+					ctx, cancel := context.WithTimeout(context.Background(), timeout)
+					res.row, res.err = cmd.run(ctx, client, q)
+					cancel()
+				}
+
+				ch <- res
+			}(q)
+		}
+
+		wg.Wait()
+	}()
+
+	go func() {
+		defer close(results)
+
+		for ch := range pending {
+			results <- <-ch
+		}
+	}()
+
+	return results
+}
+
+func runOrigin(ctx context.Context, c *ipasn.Client, q query) ([]interface{}, error) {
+	if q.ip == nil {
+		return nil, errors.New("not an IP address or prefix")
+	}
+
+	o, err := c.Origin(ctx, q.ip)
+	if err != nil {
+		return nil, err
+	}
+
+	return []interface{}{q.input, o.ASN, o.Network, o.Country, o.Authority, date(o.Updated)}, nil
+}
+
+func runPeer(ctx context.Context, c *ipasn.Client, q query) ([]interface{}, error) {
+	if q.ip == nil {
+		return nil, errors.New("not an IP address or prefix")
+	}
+
+	p, err := c.Peer(ctx, q.ip)
+	if err != nil {
+		return nil, err
+	}
+
+	return []interface{}{q.input, p.ASNs, p.Network, p.Country, p.Authority, date(p.Updated)}, nil
+}
+
+func runASN(ctx context.Context, c *ipasn.Client, q query) ([]interface{}, error) {
+	if q.ip != nil {
+		return nil, errors.New("not an ASN")
+	}
+
+	a, err := c.ASN(ctx, q.asn)
+	if err != nil {
+		return nil, err
+	}
+
+	return []interface{}{q.input, a.ASN, a.Country, a.Authority, date(a.Updated), a.Description}, nil
+}
+
+// runLookup combines the origin of an address with the description of the
+// ASN, ASN queries get the description alone
+func runLookup(ctx context.Context, c *ipasn.Client, q query) ([]interface{}, error) {
+	if q.ip == nil {
+		a, err := c.ASN(ctx, q.asn)
+		if err != nil {
+			return nil, err
+		}
+
+		return []interface{}{q.input, a.ASN, "", a.Country, a.Authority, date(a.Updated), a.Description}, nil
+	}
+
+	o, err := c.Origin(ctx, q.ip)
+	if err != nil {
+		return nil, err
+	}
+
+	a, err := c.ASN(ctx, o.ASN)
+	if err != nil && !errors.Is(err, ipasn.ErrNotFound) {
+		return nil, fmt.Errorf("describing AS%d: %w", o.ASN, err)
+	}
+
+	return []interface{}{q.input, o.ASN, o.Network, o.Country, o.Authority, date(o.Updated), a.Description}, nil
+}
+
+func date(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+
+	return t.Format("2006-01-02")
+}
@@ -0,0 +1,153 @@
+// Copyright 2019 Freman/Fremnet (Shannon Wynter). All rights reserved.
+
+package main
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/freman/cymru/ipasn"
+	"github.com/freman/cymru/ipasn/ipasntest"
+)
+
+func TestParseQuery(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		input string
+		ip    string
+		asn   int
+		err   bool
+	}{
+		{"216.90.108.31", "216.90.108.31", 0, false},
+		{"2001:4860::1", "2001:4860::1", 0, false},
+		{"216.90.108.31/24", "216.90.108.0", 0, false},
+		{"2001:4860::/32", "2001:4860::", 0, false},
+		{"AS23028", "", 23028, false},
+		{"as23028", "", 23028, false},
+		{"23028", "", 23028, false},
+		{"AS", "", 0, true},
+		{"AS0", "", 0, true},
+		{"-1", "", 0, true},
+		{"216.90.108", "", 0, true},
+		{"1.2.3.4/33", "", 0, true},
+		{"banana", "", 0, true},
+	}
+
+	for _, test := range tests {
+		q := parseQuery(test.input)
+		require.Equal(t, test.input, q.input)
+
+		if test.err {
+			require.EqualError(t, q.err, "not an IP address, prefix or ASN", test.input)
+			continue
+		}
+
+		require.NoError(t, q.err, test.input)
+		require.Equal(t, test.asn, q.asn, test.input)
+
+		if test.ip == "" {
+			require.Nil(t, q.ip, test.input)
+		} else {
+			require.Equal(t, test.ip, q.ip.String(), test.input)
+		}
+	}
+}
+
+func TestReadQueries(t *testing.T) {
+	t.Parallel()
+
+	queries := make(chan query, 10)
+	require.NoError(t, readQueries(strings.NewReader("1.1.1.1 AS13335 # cloudflare\n\n# nothing\n  2001:4860::/32\n"), queries))
+	close(queries)
+
+	var inputs []string
+	for q := range queries {
+		inputs = append(inputs, q.input)
+	}
+
+	require.Equal(t, []string{"1.1.1.1", "AS13335", "2001:4860::/32"}, inputs)
+}
+
+func TestLookupAllOrder(t *testing.T) {
+	t.Parallel()
+
+	// Earlier queries take longer, so finish last
+	cmd := command{run: func(ctx context.Context, c *ipasn.Client, q query) ([]interface{}, error) {
+		time.Sleep(time.Duration(20-q.asn) * time.Millisecond)
+		return []interface{}{q.asn}, nil
+	}}
+
+	queries := make(chan query)
+
+	go func() {
+		defer close(queries)
+
+		for i := 1; i <= 20; i++ {
+			queries <- query{asn: i}
+		}
+
+		queries <- query{input: "bad", err: errors.New("bad")}
+	}()
+
+	var got []interface{}
+
+	for res := range lookupAll(nil, cmd, queries, 8, time.Second) {
+		if res.err != nil {
+			require.Equal(t, "bad", res.query.input)
+			continue
+		}
+
+		got = append(got, res.row[0])
+	}
+
+	require.Len(t, got, 20)
+
+	for i, v := range got {
+		require.Equal(t, i+1, v)
+	}
+}
+
+func TestCommands(t *testing.T) {
+	t.Parallel()
+
+	client := ipasn.NewClient(ipasn.WithResolver(ipasntest.NewResolver(ipasntest.Golden())))
+
+	tests := []struct {
+		run   func(ctx context.Context, c *ipasn.Client, q query) ([]interface{}, error)
+		input string
+		row   string
+		err   string
+	}{
+		{runOrigin, "216.90.108.31", "216.90.108.31 | 23028 | 216.90.108.0/24 | US | arin | 1998-09-25", ""},
+		{runOrigin, "AS23028", "", "not an IP address or prefix"},
+		{runPeer, "216.90.108.0/24", "216.90.108.0/24 | 701 1239 3549 3561 7132 | 216.90.108.0/24 | US | arin | 1998-09-25", ""},
+		{runASN, "AS1234", "AS1234 | 1234 | EU | ripencc | 1993-09-01 | FORTUM-AS | Fortum, FI", ""},
+		{runASN, "1.1.1.1", "", "not an ASN"},
+		{runLookup, "2001:4860:b002::68", "2001:4860:b002::68 | 15169 | 2001:4860::/32 | US | arin | 2005-03-14 | GOOGLE - Google LLC, US", ""},
+		{runLookup, "AS23028", "AS23028 | 23028 |  | US | arin | 2002-01-04 | TEAM-CYMRU - Team Cymru Inc., US", ""},
+	}
+
+	for _, test := range tests {
+		row, err := test.run(context.TODO(), client, parseQuery(test.input))
+		if test.err != "" {
+			require.EqualError(t, err, test.err, test.input)
+			continue
+		}
+
+		require.NoError(t, err, test.input)
+		require.Equal(t, test.row, strings.Join(toStrings(row), " | "), test.input)
+	}
+
+	_, err := runOrigin(context.TODO(), client, parseQuery("1.1.1.1"))
+	require.Equal(t, ipasn.ErrNotFound, err)
+
+	_, err = runOrigin(context.TODO(), client, query{input: "10.0.0.1", ip: net.ParseIP("10.0.0.1")})
+	require.Equal(t, ipasn.ErrIPIsPrivate, err)
+}
@@ -0,0 +1,187 @@
+// Copyright 2019 Freman/Fremnet (Shannon Wynter). All rights reserved.
+
+// Command cymru looks up IP addresses, prefixes and ASNs using the Team Cymru
+// IP-ASN mapping service.
+//
+// Usage:
+//
+//	cymru origin|peer|asn|lookup [-resolver host:port] [-zone asn.cymru.com.] [-provider cymru,routeviews] [-timeout 5s] [-concurrency 8] [-format text|json|csv] [query]...
+//	cymru enrich [-resolver host:port] [-timeout 5s] [-concurrency 16] [-substitute] < access.log
+//	cymru enrich [-resolver host:port] [-timeout 5s] [-concurrency 16] -field client.ip [-field dst]... < access.jsonl
+//
+// Queries are IP addresses, CIDR prefixes and ASNs (eg: AS23028), if none are
+// given they're read from stdin, one or more per line.
+//
+// The enrich command copies stdin to stdout annotating every address it finds
+// with its origin ASN, eg: 1.1.1.1 [AS13335 CLOUDFLARENET AU], or with -field
+// adds an object describing the address in each field of JSON records.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/freman/cymru/ipasn"
+)
+
+// command is a subcommand, run does the lookup for a single query unless the
+// command has its own main
+type command struct {
+	name    string
+	usage   string
+	columns []string
+	run     func(ctx context.Context, c *ipasn.Client, q query) ([]interface{}, error)
+	main    func(args []string) int
+}
+
+//nolint:gochecknoglobals
+var commands = []command{
+	{
+		name:    "origin",
+		usage:   "Look up the origin ASN of IP addresses and prefixes",
+		columns: []string{"query", "asn", "prefix", "cc", "registry", "allocated"},
+		run:     runOrigin,
+	},
+	{
+		name:    "peer",
+		usage:   "Look up the peer ASNs of IP addresses and prefixes",
+		columns: []string{"query", "peers", "prefix", "cc", "registry", "allocated"},
+		run:     runPeer,
+	},
+	{
+		name:    "asn",
+		usage:   "Look up the description of ASNs",
+		columns: []string{"query", "asn", "cc", "registry", "allocated", "as_name"},
+		run:     runASN,
+	},
+	{
+		name:    "lookup",
+		usage:   "Look up the origin ASN and its description of IP addresses, prefixes and ASNs",
+		columns: []string{"query", "asn", "prefix", "cc", "registry", "allocated", "as_name"},
+		run:     runLookup,
+	},
+	{
+		name:  "enrich",
+		usage: "Annotate the addresses found in each line of stdin with their origin ASN",
+		main:  enrichMain,
+	},
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+
+	for _, cmd := range commands {
+		if cmd.name != os.Args[1] {
+			continue
+		}
+
+		if cmd.main != nil {
+			os.Exit(cmd.main(os.Args[2:]))
+		}
+
+		os.Exit(cmd.lookupMain(os.Args[2:]))
+	}
+
+	usage()
+	os.Exit(2)
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "Usage: cymru <command> [flags] [query]...")
+	fmt.Fprintln(os.Stderr, "\nCommands:")
+
+	for _, cmd := range commands {
+		fmt.Fprintf(os.Stderr, "  %-8s %s\n", cmd.name, cmd.usage)
+	}
+
+	fmt.Fprintln(os.Stderr, "\nQueries are read from stdin if none are given, run cymru <command> -h for the flags.")
+}
+
+// clientFlags are the flags common to every command
+type clientFlags struct {
+	ipasn.Config
+	timeout     time.Duration
+	concurrency int
+}
+
+func (f *clientFlags) register(fs *flag.FlagSet, concurrency int) {
+	f.RegisterFlags(fs)
+	fs.DurationVar(&f.timeout, "timeout", 5*time.Second, "Timeout for each lookup")
+	fs.IntVar(&f.concurrency, "concurrency", concurrency, "Number of lookups to run at once")
+}
+
+// client returns a client using the chosen resolver, zone and providers
+func (f *clientFlags) client(opts ...ipasn.Option) (*ipasn.Client, error) {
+	return f.NewClient(opts...)
+}
+
+// lookupMain parses the flags and runs the command over every query returning
+// the exit status
+func (cmd command) lookupMain(args []string) int {
+	var (
+		cf     clientFlags
+		format string
+	)
+
+	fs := flag.NewFlagSet("cymru "+cmd.name, flag.ExitOnError)
+	cf.register(fs, 8)
+	fs.StringVar(&format, "format", "text", "Output format, one of text, json or csv")
+	_ = fs.Parse(args)
+
+	out, err := newWriter(os.Stdout, format, cmd.columns)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+
+	client, err := cf.client()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+
+	queries := make(chan query)
+
+	go func() {
+		defer close(queries)
+
+		if fs.NArg() > 0 {
+			readArgs(fs.Args(), queries)
+			return
+		}
+
+		if err := readQueries(os.Stdin, queries); err != nil {
+			fmt.Fprintln(os.Stderr, "Error reading stdin:", err)
+		}
+	}()
+
+	status := 0
+
+	for res := range lookupAll(client, cmd, queries, cf.concurrency, cf.timeout) {
+		if res.err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", res.query.input, res.err)
+
+			status = 1
+
+			continue
+		}
+
+		if err := out.write(res.row); err != nil {
+			fmt.Fprintln(os.Stderr, "Error writing output:", err)
+			return 1
+		}
+	}
+
+	if err := out.flush(); err != nil {
+		fmt.Fprintln(os.Stderr, "Error writing output:", err)
+		return 1
+	}
+
+	return status
+}
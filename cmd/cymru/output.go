@@ -0,0 +1,109 @@
+// Copyright 2019 Freman/Fremnet (Shannon Wynter). All rights reserved.
+
+package main
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+)
+
+// writer writes rows of values in one of the output formats
+type writer struct {
+	format  string
+	columns []string
+	buf     *bufio.Writer
+	csv     *csv.Writer
+	json    *json.Encoder
+}
+
+func newWriter(w io.Writer, format string, columns []string) (*writer, error) {
+	out := &writer{format: format, columns: columns, buf: bufio.NewWriter(w)}
+
+	switch format {
+	case "text":
+	case "json":
+		out.json = json.NewEncoder(out.buf)
+	case "csv":
+		out.csv = csv.NewWriter(out.buf)
+		if err := out.csv.Write(columns); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unknown format %q, expected text, json or csv", format)
+	}
+
+	return out, nil
+}
+
+// write outputs the row, text is pipe delimited like Team Cymru's own
+// answers and json is an object per line
+func (w *writer) write(row []interface{}) error {
+	switch w.format {
+	case "json":
+		obj := make(map[string]interface{}, len(row))
+
+		for i, v := range row {
+			switch v := v.(type) {
+			case *net.IPNet:
+				obj[w.columns[i]] = toString(v)
+			default:
+				obj[w.columns[i]] = v
+			}
+		}
+
+		return w.json.Encode(obj)
+	case "csv":
+		return w.csv.Write(toStrings(row))
+	}
+
+	_, err := w.buf.WriteString(strings.Join(toStrings(row), " | ") + "\n")
+
+	return err
+}
+
+func (w *writer) flush() error {
+	if w.csv != nil {
+		w.csv.Flush()
+
+		if err := w.csv.Error(); err != nil {
+			return err
+		}
+	}
+
+	return w.buf.Flush()
+}
+
+func toStrings(row []interface{}) []string {
+	strs := make([]string, len(row))
+	for i, v := range row {
+		strs[i] = toString(v)
+	}
+
+	return strs
+}
+
+func toString(v interface{}) string {
+	switch v := v.(type) {
+	case *net.IPNet:
+		if v == nil {
+			return ""
+		}
+
+		return v.String()
+	case []int:
+		strs := make([]string, len(v))
+		for i, asn := range v {
+			strs[i] = strconv.Itoa(asn)
+		}
+
+		return strings.Join(strs, " ")
+	}
+
+	return fmt.Sprint(v)
+}
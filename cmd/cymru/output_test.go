@@ -0,0 +1,60 @@
+// Copyright 2019 Freman/Fremnet (Shannon Wynter). All rights reserved.
+
+package main
+
+import (
+	"bytes"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriter(t *testing.T) {
+	t.Parallel()
+
+	_, network, _ := net.ParseCIDR("216.90.108.0/24")
+	columns := []string{"query", "asn", "prefix", "peers", "note"}
+	rows := [][]interface{}{
+		{"216.90.108.31", 23028, network, []int{701, 1239}, "a, b"},
+		{"AS23028", 23028, (*net.IPNet)(nil), []int(nil), ""},
+	}
+
+	tests := []struct {
+		format string
+		want   string
+	}{
+		{"text", "" +
+			"216.90.108.31 | 23028 | 216.90.108.0/24 | 701 1239 | a, b\n" +
+			"AS23028 | 23028 |  |  | \n"},
+		{"csv", "" +
+			"query,asn,prefix,peers,note\n" +
+			"216.90.108.31,23028,216.90.108.0/24,701 1239,\"a, b\"\n" +
+			"AS23028,23028,,,\n"},
+		{"json", "" +
+			`{"asn":23028,"note":"a, b","peers":[701,1239],"prefix":"216.90.108.0/24","query":"216.90.108.31"}` + "\n" +
+			`{"asn":23028,"note":"","peers":null,"prefix":"","query":"AS23028"}` + "\n"},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.format, func(t *testing.T) {
+			t.Parallel()
+
+			var buf bytes.Buffer
+
+			w, err := newWriter(&buf, test.format, columns)
+			require.NoError(t, err)
+
+			for _, row := range rows {
+				require.NoError(t, w.write(row))
+			}
+
+			require.NoError(t, w.flush())
+			require.Equal(t, test.want, buf.String())
+		})
+	}
+
+	_, err := newWriter(&bytes.Buffer{}, "xml", columns)
+	require.EqualError(t, err, `unknown format "xml", expected text, json or csv`)
+}
@@ -0,0 +1,164 @@
+// Copyright 2019 Freman/Fremnet (Shannon Wynter). All rights reserved.
+
+// Command cymrudb compiles datasets, or live Team Cymru lookups, into a
+// localdb snapshot.
+//
+// Usage:
+//
+//	cymrudb -o ipasn.db [-mrt rib.bz2]... [-pfx2as file]... [-iptoasn file]... [-asnames file]... [-live addresses.txt]
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/freman/cymru/ipasn"
+	"github.com/freman/cymru/ipasn/localdb"
+)
+
+func main() {
+	var (
+		output   string
+		live     string
+		timeout  time.Duration
+		datasets localdb.Datasets
+	)
+
+	flag.StringVar(&output, "o", "", "Snapshot file to write")
+	datasets.RegisterFlags(flag.CommandLine)
+	flag.StringVar(&live, "live", "", "File of addresses, one per line, to look up live (- for stdin)")
+	flag.DurationVar(&timeout, "timeout", 5*time.Second, "Timeout for each live lookup")
+	flag.Parse()
+
+	if output == "" {
+		fmt.Println("Please pass an output file with -o")
+		os.Exit(1)
+	}
+
+	db := localdb.New()
+
+	if err := db.LoadDatasets(datasets); err != nil {
+		fmt.Println("Error loading dataset:", err)
+		os.Exit(1)
+	}
+
+	if live != "" {
+		if err := localdb.LoadFile(live, func(r io.Reader) error {
+			return loadLive(db, r, timeout)
+		}); err != nil {
+			fmt.Println("Error looking up addresses:", err)
+			os.Exit(1)
+		}
+	}
+
+	if err := writeSnapshot(db, output); err != nil {
+		fmt.Println("Error writing snapshot:", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("Wrote %d prefixes and %d ASNs to %s\n", db.Len(), len(db.ASNs()), output)
+}
+
+// loadLive looks up each address with the default client, adding the origin,
+// peers and AS description to the database. Addresses that can't be looked up
+// are reported and skipped.
+func loadLive(db *localdb.DB, r io.Reader, timeout time.Duration) error {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		ip := net.ParseIP(text)
+		if ip == nil {
+			fmt.Fprintf(os.Stderr, "Skipping %q: not an IP address\n", text)
+			continue
+		}
+
+		if rec, found := db.Lookup(ip); found && rec.Country != "" {
+			continue
+		}
+
+		if err := lookupLive(db, ip, timeout); err != nil {
+			fmt.Fprintf(os.Stderr, "Skipping %s: %v\n", ip, err)
+		}
+	}
+
+	return scanner.Err()
+}
+
+func lookupLive(db *localdb.DB, ip net.IP, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	origin, err := ipasn.Origin(ctx, ip)
+	if err != nil {
+		return err
+	}
+
+	if origin.Network == nil {
+		return ipasn.ErrMalformed
+	}
+
+	rec := localdb.Record{
+		Network:   origin.Network,
+		ASN:       origin.ASN,
+		Country:   origin.Country,
+		Authority: origin.Authority,
+		Updated:   origin.Updated,
+		Seen:      time.Now(),
+	}
+
+	if peer, err := ipasn.Peer(ctx, ip); err == nil {
+		rec.Peers = peer.ASNs
+	} else if !errors.Is(err, ipasn.ErrNotFound) {
+		return err
+	}
+
+	db.Insert(rec)
+
+	if _, known := db.LookupASN(origin.ASN); known {
+		return nil
+	}
+
+	asn, err := ipasn.ASN(ctx, origin.ASN)
+	if err != nil {
+		return err
+	}
+
+	db.InsertASN(asn)
+
+	return nil
+}
+
+// writeSnapshot writes to a temporary file first so that processes with the
+// snapshot mapped never see it half written
+func writeSnapshot(db *localdb.DB, output string) error {
+	fh, err := os.Create(output + ".tmp")
+	if err != nil {
+		return err
+	}
+
+	if _, err := db.WriteTo(fh); err != nil {
+		fh.Close()
+		os.Remove(fh.Name())
+
+		return err
+	}
+
+	if err := fh.Close(); err != nil {
+		os.Remove(fh.Name())
+		return err
+	}
+
+	return os.Rename(fh.Name(), output)
+}
@@ -0,0 +1,113 @@
+// Copyright 2019 Freman/Fremnet (Shannon Wynter). All rights reserved.
+
+package ipasn
+
+import (
+	"sync"
+	"time"
+)
+
+// Cache stores the TXT records returned by the Resolver keyed by the query name.
+//
+// Implementations must be safe for concurrent use.
+type Cache interface {
+	Get(name string) ([]string, bool)
+	Set(name string, vals []string)
+}
+
+// TTLCache is a Cache that knows how long its entries have left, which the
+// Client reports to callers that ask for it with ReportTTL.
+type TTLCache interface {
+	Cache
+	GetTTL(name string) ([]string, time.Duration, bool)
+}
+
+// MemoryCache is a simple in memory Cache that expires entries after a fixed TTL.
+type MemoryCache struct {
+	ttl        time.Duration
+	maxEntries int
+
+	mu      sync.Mutex
+	entries map[string]memoryCacheEntry
+}
+
+type memoryCacheEntry struct {
+	vals    []string
+	expires time.Time
+}
+
+// NewMemoryCache returns a MemoryCache that holds entries for ttl, if maxEntries
+// is greater than 0 the cache will be kept to that size by discarding entries.
+func NewMemoryCache(ttl time.Duration, maxEntries int) *MemoryCache {
+	return &MemoryCache{
+		ttl:        ttl,
+		maxEntries: maxEntries,
+		entries:    make(map[string]memoryCacheEntry),
+	}
+}
+
+// Get implements Cache
+func (m *MemoryCache) Get(name string) ([]string, bool) {
+	vals, _, ok := m.GetTTL(name)
+	return vals, ok
+}
+
+// GetTTL implements TTLCache
+func (m *MemoryCache) GetTTL(name string) ([]string, time.Duration, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	e, ok := m.entries[name]
+	if !ok {
+		return nil, 0, false
+	}
+
+	ttl := time.Until(e.expires)
+	if ttl < 0 {
+		delete(m.entries, name)
+		return nil, 0, false
+	}
+
+	return e.vals, ttl, true
+}
+
+// Set implements Cache
+func (m *MemoryCache) Set(name string, vals []string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+
+	if _, exists := m.entries[name]; !exists && m.maxEntries > 0 && len(m.entries) >= m.maxEntries {
+		m.evict(now)
+	}
+
+	m.entries[name] = memoryCacheEntry{vals: vals, expires: now.Add(m.ttl)}
+}
+
+// Len returns the number of entries in the cache, including any that have
+// expired but not yet been discarded.
+func (m *MemoryCache) Len() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return len(m.entries)
+}
+
+// evict discards expired entries, if there are none it discards whichever
+// entry the map gives up first.
+func (m *MemoryCache) evict(now time.Time) {
+	for name, e := range m.entries {
+		if now.After(e.expires) {
+			delete(m.entries, name)
+		}
+	}
+
+	for name := range m.entries {
+		if len(m.entries) < m.maxEntries {
+			return
+		}
+
+		delete(m.entries, name)
+	}
+}
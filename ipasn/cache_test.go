@@ -0,0 +1,58 @@
+// Copyright 2019 Freman/Fremnet (Shannon Wynter). All rights reserved.
+
+package ipasn_test
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/freman/cymru/ipasn"
+)
+
+func TestMemoryCache(t *testing.T) {
+	t.Parallel()
+
+	c := ipasn.NewMemoryCache(time.Minute, 0)
+
+	_, ok := c.Get("a")
+	require.False(t, ok)
+
+	c.Set("a", []string{"1"})
+
+	v, ok := c.Get("a")
+	require.True(t, ok)
+	require.Equal(t, []string{"1"}, v)
+
+	_, ttl, ok := c.GetTTL("a")
+	require.True(t, ok)
+	require.True(t, ttl > 0 && ttl <= time.Minute)
+
+	expired := ipasn.NewMemoryCache(-time.Second, 0)
+	expired.Set("a", []string{"1"})
+
+	_, ok = expired.Get("a")
+	require.False(t, ok)
+	require.Equal(t, 0, expired.Len())
+}
+
+func TestMemoryCacheMaxEntries(t *testing.T) {
+	t.Parallel()
+
+	c := ipasn.NewMemoryCache(time.Minute, 3)
+
+	for i := 0; i < 10; i++ {
+		c.Set(strconv.Itoa(i), []string{strconv.Itoa(i)})
+		require.True(t, c.Len() <= 3)
+	}
+
+	v, ok := c.Get("9")
+	require.True(t, ok)
+	require.Equal(t, []string{"9"}, v)
+
+	// Replacing an existing entry doesn't evict anything
+	c.Set("9", []string{"nine"})
+	require.Equal(t, 3, c.Len())
+}
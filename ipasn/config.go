@@ -0,0 +1,85 @@
+// Copyright 2019 Freman/Fremnet (Shannon Wynter). All rights reserved.
+
+package ipasn
+
+import (
+	"context"
+	"flag"
+	"net"
+	"strings"
+	"time"
+)
+
+// Config describes a Client in the terms of command line flags, eg: for
+// commands built on this package. The zero value is a Client of the system
+// resolver, the Team Cymru zones and no cache.
+type Config struct {
+	// Server is the DNS server to query, host or host:port, instead of the
+	// system resolver, see ServerResolver
+	Server string
+
+	// Zone is the parent of the Team Cymru zones, see ZonesUnder, it
+	// defaults to DefaultZone
+	Zone string
+
+	// Providers is a list for ParseProviders, the first is the Client's
+	// Provider and the rest its Fallbacks, it defaults to cymru
+	Providers string
+
+	// CacheTTL and CacheSize configure a MemoryCache if CacheTTL is set
+	CacheTTL  time.Duration
+	CacheSize int
+}
+
+// RegisterFlags registers the -resolver, -zone and -provider flags in fs
+func (cfg *Config) RegisterFlags(fs *flag.FlagSet) {
+	fs.StringVar(&cfg.Server, "resolver", "", "DNS server to query, as host:port, instead of the system resolver")
+	fs.StringVar(&cfg.Zone, "zone", DefaultZone, "Zone to query, eg: a mirror of the Team Cymru zones")
+	fs.StringVar(&cfg.Providers, "provider", "cymru", "Providers to query, in order, when the previous one fails, any of cymru, routeviews or rspamd")
+}
+
+// NewClient returns a Client as configured, with opts applied on top, or why
+// it can't.
+func (cfg Config) NewClient(opts ...Option) (*Client, error) {
+	zone, list := cfg.Zone, cfg.Providers
+	if zone == "" {
+		zone = DefaultZone
+	}
+
+	if list == "" {
+		list = "cymru"
+	}
+
+	providers, err := ParseProviders(list, ZonesUnder(zone))
+	if err != nil {
+		return nil, err
+	}
+
+	base := []Option{WithProvider(providers[0]), WithFallbacks(providers[1:]...)}
+
+	if cfg.Server != "" {
+		base = append(base, WithResolver(ServerResolver(cfg.Server)))
+	}
+
+	if cfg.CacheTTL > 0 {
+		base = append(base, WithCache(NewMemoryCache(cfg.CacheTTL, cfg.CacheSize)))
+	}
+
+	return NewClient(append(base, opts...)...), nil
+}
+
+// ServerResolver returns a Resolver that sends every query to server, host
+// or host:port, the port defaults to 53.
+func ServerResolver(server string) *net.Resolver {
+	if _, _, err := net.SplitHostPort(server); err != nil {
+		server = net.JoinHostPort(strings.Trim(server, "[]"), "53")
+	}
+
+	return &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, network, server)
+		},
+	}
+}
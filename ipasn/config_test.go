@@ -0,0 +1,44 @@
+// Copyright 2019 Freman/Fremnet (Shannon Wynter). All rights reserved.
+
+package ipasn_test
+
+import (
+	"flag"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/freman/cymru/ipasn"
+)
+
+func TestConfig(t *testing.T) {
+	t.Parallel()
+
+	c, err := ipasn.Config{}.NewClient()
+	require.NoError(t, err)
+	require.Equal(t, ipasn.Cymru{Zones: ipasn.DefaultZones()}, c.Provider)
+	require.Empty(t, c.Fallbacks)
+	require.Nil(t, c.Resolver)
+	require.Nil(t, c.Cache)
+
+	var cfg ipasn.Config
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	cfg.RegisterFlags(fs)
+	require.NoError(t, fs.Parse([]string{"-resolver", "127.0.0.1", "-zone", "asn.example.internal", "-provider", "cymru,routeviews"}))
+
+	cfg.CacheTTL = time.Minute
+
+	c, err = cfg.NewClient(ipasn.WithStrictParsing())
+	require.NoError(t, err)
+	require.Equal(t, ipasn.Cymru{Zones: ipasn.ZonesUnder("asn.example.internal")}, c.Provider)
+	require.Equal(t, []ipasn.Provider{ipasn.RouteViews{}}, c.Fallbacks)
+	require.IsType(t, &net.Resolver{}, c.Resolver)
+	require.IsType(t, &ipasn.MemoryCache{}, c.Cache)
+	require.True(t, c.Strict)
+
+	_, err = ipasn.Config{Providers: "bogus"}.NewClient()
+	require.EqualError(t, err, `unknown provider "bogus"`)
+}
@@ -0,0 +1,5 @@
+// Copyright 2019 Freman/Fremnet (Shannon Wynter). All rights reserved.
+
+// Package dnsserver implements an authoritative DNS server for the Team Cymru IP-ASN zones so that
+// unmodified resolvers, and ipasn.Client, can be pointed at a local dataset.
+package dnsserver
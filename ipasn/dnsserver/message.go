@@ -0,0 +1,211 @@
+// Copyright 2019 Freman/Fremnet (Shannon Wynter). All rights reserved.
+
+package dnsserver
+
+import (
+	"encoding/binary"
+	"errors"
+	"strings"
+)
+
+// Just enough of RFC 1035 and RFC 6891 to answer TXT questions
+const (
+	headerSize = 12
+
+	flagQR = 1 << 15
+	flagAA = 1 << 10
+	flagTC = 1 << 9
+	flagRD = 1 << 8
+
+	opcodeMask = 0xf << 11
+
+	rcodeSuccess        = 0
+	rcodeFormatError    = 1
+	rcodeServerFailure  = 2
+	rcodeNameError      = 3
+	rcodeNotImplemented = 4
+	rcodeRefused        = 5
+
+	typeTXT = 16
+	typeOPT = 41
+	typeANY = 255
+
+	classINET = 1
+
+	maxUDPSize    = 512
+	maxStringSize = 255
+
+	// ednsUDPSize is the UDP payload size advertised in responses to EDNS
+	// queries
+	ednsUDPSize = 4096
+)
+
+var errMalformed = errors.New("dnsserver: malformed message")
+
+// request is the interesting parts of a query
+type request struct {
+	id       uint16
+	flags    uint16
+	question []byte // the raw question, echoed in the response
+	name     string
+	qtype    uint16
+	qclass   uint16
+	udpSize  int
+	edns     bool // the query had an OPT record, so the response needs one
+}
+
+// parseRequest reads the header, the single question and looks for an OPT
+// record advertising a larger UDP payload size
+func parseRequest(msg []byte) (req request, err error) {
+	if len(msg) < headerSize {
+		return req, errMalformed
+	}
+
+	req.id = binary.BigEndian.Uint16(msg[0:])
+	req.flags = binary.BigEndian.Uint16(msg[2:])
+	req.udpSize = maxUDPSize
+
+	if req.flags&flagQR != 0 || binary.BigEndian.Uint16(msg[4:]) != 1 {
+		return req, errMalformed
+	}
+
+	offset := headerSize
+
+	var labels []string
+
+	for {
+		if offset >= len(msg) {
+			return req, errMalformed
+		}
+
+		length := int(msg[offset])
+		offset++
+
+		if length == 0 {
+			break
+		}
+
+		// Compression isn't valid in a question that comes first
+		if length > 63 || offset+length > len(msg) {
+			return req, errMalformed
+		}
+
+		labels = append(labels, string(msg[offset:offset+length]))
+		offset += length
+	}
+
+	if offset+4 > len(msg) {
+		return req, errMalformed
+	}
+
+	req.name = strings.Join(labels, ".") + "."
+	req.qtype = binary.BigEndian.Uint16(msg[offset:])
+	req.qclass = binary.BigEndian.Uint16(msg[offset+2:])
+	offset += 4
+	req.question = msg[headerSize:offset]
+
+	// Skip straight to the additional section, answers and authorities
+	// shouldn't be in a query
+	if binary.BigEndian.Uint16(msg[6:]) != 0 || binary.BigEndian.Uint16(msg[8:]) != 0 {
+		return req, nil
+	}
+
+	for additional := binary.BigEndian.Uint16(msg[10:]); additional > 0; additional-- {
+		// Only the root name is expected here, for OPT
+		if offset+11 > len(msg) || msg[offset] != 0 {
+			break
+		}
+
+		typ := binary.BigEndian.Uint16(msg[offset+1:])
+		class := int(binary.BigEndian.Uint16(msg[offset+3:]))
+		rdlength := int(binary.BigEndian.Uint16(msg[offset+9:]))
+
+		if typ == typeOPT {
+			req.edns = true
+
+			if class > maxUDPSize {
+				req.udpSize = class
+			}
+		}
+
+		offset += 11 + rdlength
+	}
+
+	return req, nil
+}
+
+// buildResponse answers the request with the given TXT records, each of which
+// is split into 255 byte strings as needed, and an OPT record if the request
+// had one
+func buildResponse(req request, rcode uint16, ttl uint32, txts []string) []byte {
+	msg := make([]byte, headerSize, headerSize+len(req.question)+64*len(txts))
+
+	binary.BigEndian.PutUint16(msg[0:], req.id)
+	binary.BigEndian.PutUint16(msg[2:], flagQR|flagAA|req.flags&(opcodeMask|flagRD)|rcode)
+
+	if req.question == nil {
+		return msg
+	}
+
+	binary.BigEndian.PutUint16(msg[4:], 1)
+	binary.BigEndian.PutUint16(msg[6:], uint16(len(txts)))
+	msg = append(msg, req.question...)
+
+	for _, txt := range txts {
+		var rdata []byte
+
+		for len(txt) > maxStringSize {
+			rdata = append(rdata, maxStringSize)
+			rdata = append(rdata, txt[:maxStringSize]...)
+			txt = txt[maxStringSize:]
+		}
+
+		rdata = append(rdata, byte(len(txt)))
+		rdata = append(rdata, txt...)
+
+		var rr [12]byte
+
+		// The name is a pointer to the question
+		binary.BigEndian.PutUint16(rr[0:], 0xc000|headerSize)
+		binary.BigEndian.PutUint16(rr[2:], typeTXT)
+		binary.BigEndian.PutUint16(rr[4:], classINET)
+		binary.BigEndian.PutUint32(rr[6:], ttl)
+		binary.BigEndian.PutUint16(rr[10:], uint16(len(rdata)))
+
+		msg = append(msg, rr[:]...)
+		msg = append(msg, rdata...)
+	}
+
+	if req.edns {
+		binary.BigEndian.PutUint16(msg[10:], 1)
+		msg = append(msg, optRecord()...)
+	}
+
+	return msg
+}
+
+// optRecord is an EDNS version 0 OPT record without any options
+func optRecord() []byte {
+	return []byte{0, 0, typeOPT, ednsUDPSize >> 8, ednsUDPSize & 0xff, 0, 0, 0, 0, 0, 0}
+}
+
+// truncate drops the answers and sets the TC flag so the client retries
+// over TCP, the OPT record, if any, is kept
+func truncate(msg []byte) []byte {
+	binary.BigEndian.PutUint16(msg[2:], binary.BigEndian.Uint16(msg[2:])|flagTC)
+	binary.BigEndian.PutUint16(msg[6:], 0)
+
+	// The header and question are all that's left
+	offset := headerSize
+	for offset < len(msg) && msg[offset] != 0 {
+		offset += 1 + int(msg[offset])
+	}
+
+	msg = msg[:offset+5]
+
+	if binary.BigEndian.Uint16(msg[10:]) != 0 {
+		msg = append(msg, optRecord()...)
+	}
+
+	return msg
+}
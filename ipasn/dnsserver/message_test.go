@@ -0,0 +1,126 @@
+// Copyright 2019 Freman/Fremnet (Shannon Wynter). All rights reserved.
+
+package dnsserver
+
+import (
+	"encoding/binary"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// query builds a question for name with an optional OPT record
+func query(name string, qtype uint16, udpSize uint16) []byte {
+	msg := []byte{0x12, 0x34, 0x01, 0x00, 0, 1, 0, 0, 0, 0, 0, 0}
+
+	for _, label := range []string{"AS23028", "asn", "cymru", "com"} {
+		if name != "" {
+			msg = append(msg, byte(len(label)))
+			msg = append(msg, label...)
+		}
+	}
+
+	msg = append(msg, 0, byte(qtype>>8), byte(qtype), 0, classINET)
+
+	if udpSize > 0 {
+		msg[11] = 1
+		msg = append(msg, 0, 0, typeOPT, byte(udpSize>>8), byte(udpSize), 0, 0, 0, 0, 0, 0)
+	}
+
+	return msg
+}
+
+func TestParseRequest(t *testing.T) {
+	t.Parallel()
+
+	req, err := parseRequest(query("AS23028.asn.cymru.com", typeTXT, 0))
+	require.NoError(t, err)
+	require.Equal(t, uint16(0x1234), req.id)
+	require.Equal(t, "AS23028.asn.cymru.com.", req.name)
+	require.Equal(t, uint16(typeTXT), req.qtype)
+	require.Equal(t, maxUDPSize, req.udpSize)
+	require.False(t, req.edns)
+
+	req, err = parseRequest(query("AS23028.asn.cymru.com", typeTXT, 4096))
+	require.NoError(t, err)
+	require.Equal(t, 4096, req.udpSize)
+	require.True(t, req.edns)
+
+	// Sizes smaller than 512 are treated as 512
+	req, err = parseRequest(query("AS23028.asn.cymru.com", typeTXT, 256))
+	require.NoError(t, err)
+	require.Equal(t, maxUDPSize, req.udpSize)
+	require.True(t, req.edns)
+
+	for _, bad := range [][]byte{
+		nil,
+		query("x", typeTXT, 0)[:20],
+		query("x", typeTXT, 0)[:14],
+		{0, 0, 0x81, 0, 0, 1, 0, 0, 0, 0, 0, 0, 0, 0, 16, 0, 1},
+		{0, 0, 0x01, 0, 0, 2, 0, 0, 0, 0, 0, 0, 0, 0, 16, 0, 1},
+		{0, 0, 0x01, 0, 0, 1, 0, 0, 0, 0, 0, 0, 0xc0, 0x0c, 0, 16, 0, 1},
+	} {
+		_, err := parseRequest(bad)
+		require.Equal(t, errMalformed, err)
+	}
+}
+
+func TestBuildResponse(t *testing.T) {
+	t.Parallel()
+
+	req, err := parseRequest(query("AS23028.asn.cymru.com", typeTXT, 0))
+	require.NoError(t, err)
+
+	long := make([]byte, 300)
+	for i := range long {
+		long[i] = 'a'
+	}
+
+	resp := buildResponse(req, rcodeSuccess, 3600, []string{"short", string(long)})
+
+	require.Equal(t, uint16(0x1234), binary.BigEndian.Uint16(resp))
+	require.Equal(t, uint16(flagQR|flagAA|flagRD), binary.BigEndian.Uint16(resp[2:]))
+	require.Equal(t, uint16(2), binary.BigEndian.Uint16(resp[6:]))
+
+	offset := headerSize + len(req.question)
+	require.Equal(t, []byte{0xc0, 0x0c, 0, typeTXT, 0, classINET, 0, 0, 0x0e, 0x10, 0, 6, 5}, resp[offset:offset+13])
+	require.Equal(t, "short", string(resp[offset+13:offset+18]))
+
+	offset += 18
+	require.Equal(t, uint16(302), binary.BigEndian.Uint16(resp[offset+10:]))
+	require.Equal(t, byte(255), resp[offset+12])
+	require.Equal(t, byte(45), resp[offset+12+256])
+	require.Len(t, resp, offset+12+302)
+
+	truncated := truncate(resp)
+	require.Equal(t, uint16(flagQR|flagAA|flagTC|flagRD), binary.BigEndian.Uint16(truncated[2:]))
+	require.Equal(t, uint16(0), binary.BigEndian.Uint16(truncated[6:]))
+	require.Len(t, truncated, headerSize+len(req.question))
+}
+
+func TestBuildResponseEDNS(t *testing.T) {
+	t.Parallel()
+
+	req, err := parseRequest(query("AS23028.asn.cymru.com", typeTXT, 1232))
+	require.NoError(t, err)
+
+	opt := []byte{0, 0, typeOPT, 0x10, 0, 0, 0, 0, 0, 0, 0}
+
+	resp := buildResponse(req, rcodeSuccess, 3600, []string{"short"})
+	require.Equal(t, uint16(1), binary.BigEndian.Uint16(resp[6:]))
+	require.Equal(t, uint16(1), binary.BigEndian.Uint16(resp[10:]))
+	require.Equal(t, opt, resp[len(resp)-len(opt):])
+
+	// Errors too
+	resp = buildResponse(req, rcodeNameError, 0, nil)
+	require.Equal(t, uint16(1), binary.BigEndian.Uint16(resp[10:]))
+	require.Len(t, resp, headerSize+len(req.question)+len(opt))
+	require.Equal(t, opt, resp[headerSize+len(req.question):])
+
+	// Truncating keeps the OPT record
+	truncated := truncate(buildResponse(req, rcodeSuccess, 3600, []string{"short"}))
+	require.Equal(t, uint16(0), binary.BigEndian.Uint16(truncated[6:]))
+	require.Equal(t, uint16(1), binary.BigEndian.Uint16(truncated[10:]))
+	require.Len(t, truncated, headerSize+len(req.question)+len(opt))
+	require.Equal(t, opt, truncated[headerSize+len(req.question):])
+}
@@ -0,0 +1,338 @@
+// Copyright 2019 Freman/Fremnet (Shannon Wynter). All rights reserved.
+
+package dnsserver
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/freman/cymru/ipasn"
+)
+
+// DefaultZone is the zone served by the Team Cymru IP-ASN service
+const DefaultZone = ipasn.DefaultZone
+
+// Server is an authoritative DNS server for the Team Cymru zones, answering
+// TXT questions with whatever its Resolver returns.
+//
+// Questions outside of the Zone are refused, questions the Resolver has no
+// records for, or reports as not found, unknown or unsupported, get NXDOMAIN,
+// and other Resolver errors get SERVFAIL. Questions in a
+// Zone other than DefaultZone are renamed into the Team Cymru zones before
+// being passed to the Resolver, so that eg: a *localdb.DB can serve any zone.
+type Server struct {
+	// Addr is the UDP and TCP address to listen on, it defaults to
+	// 127.0.0.1:53
+	Addr string
+
+	// Zone is the zone being served, it defaults to DefaultZone
+	Zone string
+
+	// Resolver answers the questions, eg: a *localdb.DB
+	Resolver ipasn.Resolver
+
+	// TTL is the time to live of every answer, it defaults to an hour
+	TTL time.Duration
+
+	// Timeout limits how long the Resolver has to answer, and how long a TCP
+	// connection can be idle, it defaults to 5 seconds
+	Timeout time.Duration
+
+	mu     sync.Mutex
+	udp    net.PacketConn
+	tcp    net.Listener
+	conns  map[net.Conn]struct{}
+	wg     sync.WaitGroup
+	closed bool
+}
+
+// ErrServerClosed is returned by ListenAndServe after Close
+var ErrServerClosed = errors.New("dnsserver: server closed")
+
+// ListenAndServe listens on Addr and serves until Close is called or it fails
+func (s *Server) ListenAndServe() error {
+	if err := s.Start(); err != nil {
+		return err
+	}
+
+	s.wg.Wait()
+
+	return ErrServerClosed
+}
+
+// Start listens on Addr for both UDP and TCP and serves in the background
+// until Close is called. If the port is 0 the same, randomly chosen, port is
+// used for both, see LocalAddr.
+func (s *Server) Start() error {
+	addr := s.Addr
+	if addr == "" {
+		addr = "127.0.0.1:53"
+	}
+
+	udp, tcp, err := listen(addr)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	s.udp, s.tcp = udp, tcp
+	s.conns = make(map[net.Conn]struct{})
+	s.mu.Unlock()
+
+	s.wg.Add(2)
+
+	go s.serveUDP()
+	go s.serveTCP()
+
+	return nil
+}
+
+// LocalAddr returns the address the server is listening on, which is the same
+// for UDP and TCP
+func (s *Server) LocalAddr() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.udp == nil {
+		return ""
+	}
+
+	return s.udp.LocalAddr().String()
+}
+
+// Close stops the server and waits for in flight requests to finish
+func (s *Server) Close() error {
+	s.mu.Lock()
+
+	if s.closed || s.udp == nil {
+		s.mu.Unlock()
+		return nil
+	}
+
+	s.closed = true
+	err := s.udp.Close()
+
+	if terr := s.tcp.Close(); err == nil {
+		err = terr
+	}
+
+	for conn := range s.conns {
+		conn.Close()
+	}
+
+	s.mu.Unlock()
+
+	s.wg.Wait()
+
+	return err
+}
+
+// listen binds UDP and TCP to the same port, retrying a few times if a
+// random port is taken for one and not the other
+func listen(addr string) (udp net.PacketConn, tcp net.Listener, err error) {
+	for attempt := 0; attempt < 10; attempt++ {
+		udp, err = net.ListenPacket("udp", addr)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		tcp, err = net.Listen("tcp", udp.LocalAddr().String())
+		if err == nil {
+			return udp, tcp, nil
+		}
+
+		udp.Close()
+
+		if _, port, _ := net.SplitHostPort(addr); port != "0" {
+			break
+		}
+	}
+
+	return nil, nil, err
+}
+
+func (s *Server) serveUDP() {
+	defer s.wg.Done()
+
+	buf := make([]byte, 65535)
+
+	for {
+		n, addr, err := s.udp.ReadFrom(buf)
+		if err != nil {
+			if ne, ok := err.(net.Error); ok && ne.Temporary() {
+				continue
+			}
+
+			return
+		}
+
+		msg := append([]byte(nil), buf[:n]...)
+
+		s.wg.Add(1)
+
+		go func() {
+			defer s.wg.Done()
+
+			resp, udpSize := s.handle(msg)
+			if len(resp) > udpSize {
+				resp = truncate(resp)
+			}
+
+			_, _ = s.udp.WriteTo(resp, addr)
+		}()
+	}
+}
+
+func (s *Server) serveTCP() {
+	defer s.wg.Done()
+
+	for {
+		conn, err := s.tcp.Accept()
+		if err != nil {
+			if ne, ok := err.(net.Error); ok && ne.Temporary() {
+				continue
+			}
+
+			return
+		}
+
+		s.mu.Lock()
+		if s.closed {
+			s.mu.Unlock()
+			conn.Close()
+
+			return
+		}
+
+		s.conns[conn] = struct{}{}
+		s.mu.Unlock()
+
+		s.wg.Add(1)
+
+		go s.serveConn(conn)
+	}
+}
+
+// serveConn answers length prefixed messages until the client goes away or
+// stays idle for too long
+func (s *Server) serveConn(conn net.Conn) {
+	defer func() {
+		s.mu.Lock()
+		delete(s.conns, conn)
+		s.mu.Unlock()
+
+		conn.Close()
+		s.wg.Done()
+	}()
+
+	var length [2]byte
+
+	for {
+		_ = conn.SetDeadline(time.Now().Add(s.timeout()))
+
+		if _, err := io.ReadFull(conn, length[:]); err != nil {
+			return
+		}
+
+		msg := make([]byte, binary.BigEndian.Uint16(length[:]))
+		if _, err := io.ReadFull(conn, msg); err != nil {
+			return
+		}
+
+		resp, _ := s.handle(msg)
+
+		binary.BigEndian.PutUint16(length[:], uint16(len(resp)))
+
+		if _, err := conn.Write(append(length[:], resp...)); err != nil {
+			return
+		}
+	}
+}
+
+// handle answers a single message, also returning the UDP size the client can
+// accept
+func (s *Server) handle(msg []byte) ([]byte, int) {
+	req, err := parseRequest(msg)
+	if err != nil {
+		// Without a question there's nothing to echo
+		req.question = nil
+		return buildResponse(req, rcodeFormatError, 0, nil), maxUDPSize
+	}
+
+	zone := s.Zone
+	if zone == "" {
+		zone = DefaultZone
+	}
+
+	name := strings.ToLower(req.name)
+	zone = strings.ToLower(strings.TrimSuffix(zone, ".") + ".")
+
+	switch {
+	case req.flags&opcodeMask != 0:
+		return buildResponse(req, rcodeNotImplemented, 0, nil), req.udpSize
+	case name != zone && !strings.HasSuffix(name, "."+zone):
+		return buildResponse(req, rcodeRefused, 0, nil), req.udpSize
+	case req.qclass != classINET || req.qtype != typeTXT && req.qtype != typeANY:
+		// No data of that type, but the name may well exist
+		return buildResponse(req, rcodeSuccess, 0, nil), req.udpSize
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout())
+	defer cancel()
+
+	txts, err := s.Resolver.LookupTXT(ctx, rename(req.name, zone))
+
+	switch {
+	case permanent(err), err == nil && len(txts) == 0:
+		return buildResponse(req, rcodeNameError, 0, nil), req.udpSize
+	case err != nil:
+		return buildResponse(req, rcodeServerFailure, 0, nil), req.udpSize
+	}
+
+	ttl := s.TTL
+	if ttl <= 0 {
+		ttl = time.Hour
+	}
+
+	return buildResponse(req, rcodeSuccess, uint32(ttl/time.Second), txts), req.udpSize
+}
+
+// permanent reports whether err means the name will never be answered, so
+// NXDOMAIN rather than SERVFAIL, which downstream resolvers retry
+func permanent(err error) bool {
+	var dnsErr *net.DNSError
+
+	return errors.Is(err, ipasn.ErrNotFound) ||
+		errors.Is(err, ipasn.ErrUnknownZone) ||
+		errors.Is(err, ipasn.ErrUnsupported) ||
+		errors.As(err, &dnsErr) && dnsErr.IsNotFound
+}
+
+func (s *Server) timeout() time.Duration {
+	if s.Timeout <= 0 {
+		return 5 * time.Second
+	}
+
+	return s.Timeout
+}
+
+// rename translates a name in zone to the equivalent name in the Team Cymru
+// zones, names that can't be parsed are left alone
+func rename(name, zone string) string {
+	if zone == DefaultZone {
+		return name
+	}
+
+	q, err := ipasn.ZonesUnder(zone).ParseName(name)
+	if err != nil {
+		return name
+	}
+
+	return ipasn.DefaultZones().Name(q)
+}
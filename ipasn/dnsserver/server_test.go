@@ -0,0 +1,208 @@
+// Copyright 2019 Freman/Fremnet (Shannon Wynter). All rights reserved.
+
+package dnsserver_test
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/freman/cymru/ipasn"
+	"github.com/freman/cymru/ipasn/dnsserver"
+	"github.com/freman/cymru/ipasn/localdb"
+)
+
+func testServer(t *testing.T) *dnsserver.Server {
+	db := localdb.New()
+
+	require.NoError(t, db.LoadPfx2AS(strings.NewReader("216.90.108.0\t24\t23028\n2001:4860::\t32\t15169\n")))
+	require.NoError(t, db.LoadASNames(strings.NewReader("23028 TEAM-CYMRU - Team Cymru Inc., US\n"+
+		"1 "+strings.Repeat("long description ", 30)+"\n")))
+
+	failing := mockResolver(func(ctx context.Context, name string) ([]string, error) {
+		switch {
+		case strings.HasPrefix(name, "AS666."):
+			return nil, errors.New("broken")
+		case strings.HasPrefix(name, "AS667."):
+			return nil, ipasn.ErrNotFound
+		case strings.HasPrefix(name, "AS668."):
+			return nil, ipasn.ErrUnsupported
+		}
+
+		return db.LookupTXT(ctx, name)
+	})
+
+	s := &dnsserver.Server{Addr: "127.0.0.1:0", Resolver: failing}
+	require.NoError(t, s.Start())
+
+	return s
+}
+
+type mockResolver func(ctx context.Context, name string) ([]string, error)
+
+func (m mockResolver) LookupTXT(ctx context.Context, name string) ([]string, error) {
+	return m(ctx, name)
+}
+
+// resolverFor returns a pure Go resolver that only talks to the server, over
+// TCP if that's the network, otherwise whichever the resolver asks for
+func resolverFor(s *dnsserver.Server, network string) *net.Resolver {
+	return &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, asked, _ string) (net.Conn, error) {
+			if network == "tcp" {
+				asked = network
+			}
+
+			var d net.Dialer
+			return d.DialContext(ctx, asked, s.LocalAddr())
+		},
+	}
+}
+
+func TestServerWithClient(t *testing.T) {
+	t.Parallel()
+
+	s := testServer(t)
+	defer s.Close()
+
+	for _, network := range []string{"udp", "tcp"} {
+		network := network
+		t.Run(network, func(t *testing.T) {
+			c := &ipasn.Client{Resolver: resolverFor(s, network)}
+
+			origin, err := c.Origin(context.TODO(), net.ParseIP("216.90.108.31"))
+			require.NoError(t, err)
+			require.Equal(t, 23028, origin.ASN)
+			require.Equal(t, "216.90.108.0/24", origin.Network.String())
+
+			origin, err = c.Origin(context.TODO(), net.ParseIP("2001:4860:b002::68"))
+			require.NoError(t, err)
+			require.Equal(t, 15169, origin.ASN)
+
+			asn, err := c.ASN(context.TODO(), 23028)
+			require.NoError(t, err)
+			require.Equal(t, "TEAM-CYMRU - Team Cymru Inc., US", asn.Description)
+
+			// Too big for a plain UDP response, so retried over TCP
+			asn, err = c.ASN(context.TODO(), 1)
+			require.NoError(t, err)
+			require.Equal(t, strings.TrimSpace(strings.Repeat("long description ", 30)), asn.Description)
+
+			_, err = c.Origin(context.TODO(), net.ParseIP("1.1.1.1"))
+			var dnsErr *net.DNSError
+			require.True(t, errors.As(err, &dnsErr))
+			require.True(t, dnsErr.IsNotFound)
+
+			_, err = c.ASN(context.TODO(), 666)
+			require.True(t, errors.As(err, &dnsErr))
+			require.False(t, dnsErr.IsNotFound)
+		})
+	}
+}
+
+func TestServerNameErrors(t *testing.T) {
+	t.Parallel()
+
+	s := testServer(t)
+	defer s.Close()
+
+	tests := []struct {
+		name     string
+		notFound bool
+	}{
+		{"1.1.1.1.origin.asn.cymru.com.", true},
+		{"1.2.3.4.5.origin.asn.cymru.com.", true},
+		{"x.origin6.asn.cymru.com.", true},
+		{"foo.asn.cymru.com.", true},
+		{"AS667.asn.cymru.com.", true},
+		{"AS668.asn.cymru.com.", true},
+		{"AS666.asn.cymru.com.", false},
+	}
+
+	for _, test := range tests {
+		_, err := resolverFor(s, "udp").LookupTXT(context.TODO(), test.name)
+
+		var dnsErr *net.DNSError
+		require.True(t, errors.As(err, &dnsErr), test.name)
+		require.Equal(t, test.notFound, dnsErr.IsNotFound, test.name)
+	}
+}
+
+func TestServerRefusesOtherZones(t *testing.T) {
+	t.Parallel()
+
+	s := testServer(t)
+	defer s.Close()
+
+	_, err := resolverFor(s, "udp").LookupTXT(context.TODO(), "example.com.")
+	require.Error(t, err)
+
+	// Other types are answered with no data
+	addrs, err := resolverFor(s, "udp").LookupHost(context.TODO(), "AS23028.asn.cymru.com.")
+	require.Error(t, err)
+	require.Empty(t, addrs)
+}
+
+func TestServerOtherZone(t *testing.T) {
+	t.Parallel()
+
+	db := localdb.New()
+	require.NoError(t, db.LoadPfx2AS(strings.NewReader("216.90.108.0\t24\t23028\n2001:4860::\t32\t15169\n")))
+	require.NoError(t, db.LoadASNames(strings.NewReader("23028 TEAM-CYMRU - Team Cymru Inc., US\n")))
+
+	s := &dnsserver.Server{Addr: "127.0.0.1:0", Zone: "asn.example.internal", Resolver: db}
+	require.NoError(t, s.Start())
+
+	defer s.Close()
+
+	c := ipasn.NewClient(
+		ipasn.WithResolver(resolverFor(s, "udp")),
+		ipasn.WithZones(ipasn.ZonesUnder("asn.example.internal")),
+	)
+
+	origin, err := c.Origin(context.TODO(), net.ParseIP("216.90.108.31"))
+	require.NoError(t, err)
+	require.Equal(t, 23028, origin.ASN)
+
+	origin, err = c.Origin(context.TODO(), net.ParseIP("2001:4860::1"))
+	require.NoError(t, err)
+	require.Equal(t, 15169, origin.ASN)
+
+	asn, err := c.ASN(context.TODO(), 23028)
+	require.NoError(t, err)
+	require.Equal(t, "TEAM-CYMRU - Team Cymru Inc., US", asn.Description)
+
+	// The Team Cymru zones aren't served
+	_, err = ipasn.NewClient(ipasn.WithResolver(resolverFor(s, "udp"))).ASN(context.TODO(), 23028)
+	require.Error(t, err)
+}
+
+func TestServerClose(t *testing.T) {
+	t.Parallel()
+
+	s := &dnsserver.Server{Addr: "127.0.0.1:0", Resolver: localdb.New()}
+
+	done := make(chan error)
+	go func() {
+		done <- s.ListenAndServe()
+	}()
+
+	for s.LocalAddr() == "" {
+		time.Sleep(time.Millisecond)
+	}
+
+	conn, err := net.Dial("tcp", s.LocalAddr())
+	require.NoError(t, err)
+
+	defer conn.Close()
+
+	require.NoError(t, s.Close())
+	require.Equal(t, dnsserver.ErrServerClosed, <-done)
+	require.NoError(t, s.Close())
+}
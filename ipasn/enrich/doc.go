@@ -0,0 +1,5 @@
+// Copyright 2019 Freman/Fremnet (Shannon Wynter). All rights reserved.
+
+// Package enrich annotates the IP addresses found in streams of text, such as logs, with their origin
+// ASN, prefix and description from the Team Cymru IP-ASN service.
+package enrich
@@ -0,0 +1,276 @@
+// Copyright 2019 Freman/Fremnet (Shannon Wynter). All rights reserved.
+
+package enrich
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/freman/cymru/ipasn"
+)
+
+// Mode is how annotations are added to lines
+type Mode int
+
+// Annotation modes
+const (
+	// Append adds the annotation after the address, eg: 1.1.1.1 [AS13335 CLOUDFLARENET AU]
+	Append Mode = iota
+	// Substitute replaces the address with the annotation
+	Substitute
+)
+
+// Annotation is what's known about an address, Err is set if the lookup
+// failed, including when the address is filtered by the Client
+//
+// Peer is only looked up for structured records
+type Annotation struct {
+	IP     net.IP
+	Origin ipasn.OriginInfo
+	ASN    ipasn.ASNInfo
+	Peer   ipasn.PeerInfo
+	Err    error
+}
+
+// Handle returns the short name of the AS, which is the description up to the
+// first " - " or space, eg: CLOUDFLARENET
+func (a Annotation) Handle() string {
+	desc := a.ASN.Description
+	if i := strings.Index(desc, " - "); i >= 0 {
+		desc = desc[:i]
+	}
+
+	if i := strings.IndexAny(desc, " ,"); i >= 0 {
+		desc = desc[:i]
+	}
+
+	return desc
+}
+
+// String returns the annotation in the form AS13335 CLOUDFLARENET AU, or an
+// empty string if the lookup failed
+func (a Annotation) String() string {
+	if a.Err != nil || a.Origin.ASN == 0 {
+		return ""
+	}
+
+	parts := []string{"AS" + strconv.Itoa(a.Origin.ASN)}
+
+	if handle := a.Handle(); handle != "" {
+		parts = append(parts, handle)
+	}
+
+	if a.Origin.Country != "" {
+		parts = append(parts, a.Origin.Country)
+	}
+
+	return strings.Join(parts, " ")
+}
+
+// Enricher annotates the addresses found in each line of a stream, looking
+// them up concurrently while preserving the order of the lines.
+//
+// The zero value is ready to use, with a Client that caches answers for an
+// hour.
+type Enricher struct {
+	// Client does the lookups, it should have a Cache as the same addresses
+	// tend to appear over and over
+	Client *ipasn.Client
+
+	// Mode is Append by default
+	Mode Mode
+
+	// Format returns the text for an annotation, or an empty string to leave
+	// the address alone, it defaults to Annotation.String
+	Format func(Annotation) string
+
+	// Concurrency is the number of lines being looked up at once, it
+	// defaults to 16
+	Concurrency int
+
+	// Timeout limits each lookup, it defaults to 5 seconds
+	Timeout time.Duration
+
+	once   sync.Once
+	client *ipasn.Client
+}
+
+// Enrich copies r to w annotating every address in every line, it returns
+// when r is exhausted, ctx is done or writing fails.
+func (e *Enricher) Enrich(ctx context.Context, r io.Reader, w io.Writer) error {
+	return e.process(ctx, r, w, e.EnrichLine)
+}
+
+// EnrichLine annotates the addresses in a single line
+func (e *Enricher) EnrichLine(ctx context.Context, line []byte) []byte {
+	matches := Scan(line)
+	if len(matches) == 0 {
+		return line
+	}
+
+	format := e.Format
+	if format == nil {
+		format = Annotation.String
+	}
+
+	var (
+		buf  bytes.Buffer
+		last int
+	)
+
+	for _, m := range matches {
+		text := format(e.Annotate(ctx, m.IP))
+		if text == "" {
+			continue
+		}
+
+		if e.Mode == Substitute {
+			buf.Write(line[last:m.Start])
+			buf.WriteString(text)
+		} else {
+			buf.Write(line[last:m.End])
+			buf.WriteString(" [" + text + "]")
+		}
+
+		last = m.End
+	}
+
+	buf.Write(line[last:])
+
+	return buf.Bytes()
+}
+
+// Annotate looks up the origin of ip and the description of its ASN
+func (e *Enricher) Annotate(ctx context.Context, ip net.IP) Annotation {
+	return e.annotate(ctx, ip, false)
+}
+
+func (e *Enricher) annotate(ctx context.Context, ip net.IP, peers bool) Annotation {
+	ctx, cancel := context.WithTimeout(ctx, e.timeout())
+	defer cancel()
+
+	a := Annotation{IP: ip}
+	client := e.getClient()
+
+	a.Origin, a.Err = client.Origin(ctx, ip)
+	if a.Err != nil {
+		return a
+	}
+
+	// The origin is still useful without a description
+	a.ASN, _ = client.ASN(ctx, a.Origin.ASN)
+
+	if peers {
+		a.Peer, _ = client.Peer(ctx, ip)
+	}
+
+	return a
+}
+
+// process transforms each line of r with fn, running up to Concurrency at
+// once, and writes them to w in their original order
+func (e *Enricher) process(ctx context.Context, r io.Reader, w io.Writer, fn func(context.Context, []byte) []byte) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	concurrency := e.Concurrency
+	if concurrency <= 0 {
+		concurrency = 16
+	}
+
+	// Each line gets a channel for its result, queued in order
+	pending := make(chan chan []byte, concurrency)
+	readErr := make(chan error, 1)
+
+	go func() {
+		defer close(pending)
+
+		br := bufio.NewReader(r)
+
+		for {
+			line, err := br.ReadBytes('\n')
+
+			if len(line) > 0 {
+				ch := make(chan []byte, 1)
+
+				select {
+				case pending <- ch:
+				case <-ctx.Done():
+					readErr <- ctx.Err()
+					return
+				}
+
+				go func(line []byte) {
+					// Keep the line ending out of reach of the scanner
+					body := bytes.TrimRight(line, "\r\n")
+					ch <- append(fn(ctx, body), line[len(body):]...)
+				}(line)
+			}
+
+			if err != nil {
+				if err == io.EOF {
+					err = nil
+				}
+
+				readErr <- err
+
+				return
+			}
+		}
+	}()
+
+	bw := bufio.NewWriter(w)
+
+	for ch := range pending {
+		var line []byte
+
+		select {
+		case line = <-ch:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+
+		if _, err := bw.Write(line); err != nil {
+			return err
+		}
+
+		// Don't hold lines back when the input is slow, eg: tail -f
+		if len(pending) == 0 {
+			if err := bw.Flush(); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := bw.Flush(); err != nil {
+		return err
+	}
+
+	return <-readErr
+}
+
+func (e *Enricher) getClient() *ipasn.Client {
+	e.once.Do(func() {
+		e.client = e.Client
+		if e.client == nil {
+			e.client = ipasn.NewClient(ipasn.WithCache(ipasn.NewMemoryCache(time.Hour, 100000)))
+		}
+	})
+
+	return e.client
+}
+
+func (e *Enricher) timeout() time.Duration {
+	if e.Timeout <= 0 {
+		return 5 * time.Second
+	}
+
+	return e.Timeout
+}
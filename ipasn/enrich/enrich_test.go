@@ -0,0 +1,138 @@
+// Copyright 2019 Freman/Fremnet (Shannon Wynter). All rights reserved.
+
+package enrich_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/freman/cymru/ipasn"
+	"github.com/freman/cymru/ipasn/enrich"
+	"github.com/freman/cymru/ipasn/ipasntest"
+)
+
+func testResolver() *ipasntest.Resolver {
+	r := ipasntest.NewResolver(ipasntest.Golden())
+
+	_, cloudflare, _ := net.ParseCIDR("1.1.1.0/24")
+	r.AddOrigin(ipasn.OriginInfo{ASN: 13335, Network: cloudflare, Country: "AU", Authority: "apnic"})
+	r.AddASN(ipasn.ASNInfo{ASN: 13335, Country: "US", Authority: "arin", Description: "CLOUDFLARENET - Cloudflare, Inc., US"})
+
+	return r
+}
+
+func TestEnrich(t *testing.T) {
+	t.Parallel()
+
+	input := "1.1.1.1 - - \"GET / HTTP/1.1\" 200\r\n" +
+		"no addresses here\n" +
+		"from 216.90.108.31:22 and 192.168.0.1 and 8.8.8.8\n" +
+		"2001:4860:b002::68 without a newline"
+
+	tests := []struct {
+		name     string
+		enricher *enrich.Enricher
+		expected string
+	}{
+		{
+			name:     "append",
+			enricher: &enrich.Enricher{Client: ipasn.NewClient(ipasn.WithResolver(testResolver()))},
+			expected: "1.1.1.1 [AS13335 CLOUDFLARENET AU] - - \"GET / HTTP/1.1\" 200\r\n" +
+				"no addresses here\n" +
+				"from 216.90.108.31 [AS23028 TEAM-CYMRU US]:22 and 192.168.0.1 and 8.8.8.8\n" +
+				"2001:4860:b002::68 [AS15169 GOOGLE US] without a newline",
+		},
+		{
+			name:     "substitute",
+			enricher: &enrich.Enricher{Client: ipasn.NewClient(ipasn.WithResolver(testResolver())), Mode: enrich.Substitute, Concurrency: 1},
+			expected: "AS13335 CLOUDFLARENET AU - - \"GET / HTTP/1.1\" 200\r\n" +
+				"no addresses here\n" +
+				"from AS23028 TEAM-CYMRU US:22 and 192.168.0.1 and 8.8.8.8\n" +
+				"AS15169 GOOGLE US without a newline",
+		},
+		{
+			name: "format",
+			enricher: &enrich.Enricher{
+				Client: ipasn.NewClient(ipasn.WithResolver(testResolver())),
+				Format: func(a enrich.Annotation) string {
+					if a.Err != nil {
+						return a.Err.Error()
+					}
+
+					return a.Origin.Network.String()
+				},
+			},
+			expected: "1.1.1.1 [1.1.1.0/24] - - \"GET / HTTP/1.1\" 200\r\n" +
+				"no addresses here\n" +
+				"from 216.90.108.31 [216.90.108.0/24]:22 and 192.168.0.1 [IP is a private address] and 8.8.8.8 [DNS result included no useful records]\n" +
+				"2001:4860:b002::68 [2001:4860::/32] without a newline",
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			var buf bytes.Buffer
+			require.NoError(t, test.enricher.Enrich(context.TODO(), strings.NewReader(input), &buf))
+			require.Equal(t, test.expected, buf.String())
+		})
+	}
+}
+
+func TestEnrichOrder(t *testing.T) {
+	t.Parallel()
+
+	r := testResolver()
+	r.SetLatency(time.Millisecond)
+
+	var input, expected strings.Builder
+
+	for i := 0; i < 200; i++ {
+		ip := net.IPv4(216, 90, 108, byte(i))
+		fmt.Fprintf(&input, "%d %s\n", i, ip)
+		fmt.Fprintf(&expected, "%d %s [AS23028 TEAM-CYMRU US]\n", i, ip)
+	}
+
+	e := &enrich.Enricher{Client: ipasn.NewClient(ipasn.WithResolver(r), ipasn.WithCache(ipasn.NewMemoryCache(time.Hour, 0))), Concurrency: 32}
+
+	var buf bytes.Buffer
+	require.NoError(t, e.Enrich(context.TODO(), strings.NewReader(input.String()), &buf))
+	require.Equal(t, expected.String(), buf.String())
+
+	// The description is only looked up until the cache has it
+	descriptions := 0
+
+	for _, name := range r.Queries() {
+		if name == ipasntest.ASNName(23028) {
+			descriptions++
+		}
+	}
+
+	require.Len(t, r.Queries(), 200+descriptions)
+	require.True(t, descriptions < 100, "looked up the description %d times", descriptions)
+}
+
+func TestEnrichCancel(t *testing.T) {
+	t.Parallel()
+
+	r := testResolver()
+	r.SetLatency(time.Hour)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	e := &enrich.Enricher{Client: ipasn.NewClient(ipasn.WithResolver(r))}
+
+	err := e.Enrich(ctx, strings.NewReader("1.1.1.1\n"), &bytes.Buffer{})
+	require.True(t, errors.Is(err, context.DeadlineExceeded))
+}
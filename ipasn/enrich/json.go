@@ -0,0 +1,146 @@
+// Copyright 2019 Freman/Fremnet (Shannon Wynter). All rights reserved.
+
+package enrich
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net"
+	"strings"
+)
+
+// FieldSuffix is appended to the name of each field to name the object added
+// alongside it by EnrichRecord
+const FieldSuffix = "_as"
+
+// Info is the object added alongside each field by EnrichRecord
+type Info struct {
+	ASN      int    `json:"asn"`
+	Prefix   string `json:"prefix,omitempty"`
+	Country  string `json:"country,omitempty"`
+	Registry string `json:"registry,omitempty"`
+	ASName   string `json:"as_name,omitempty"`
+	Peers    []int  `json:"peers,omitempty"`
+}
+
+// Info returns the annotation as the object added by EnrichRecord, or nil if
+// the lookup failed
+func (a Annotation) Info() *Info {
+	if a.Err != nil || a.Origin.ASN == 0 {
+		return nil
+	}
+
+	info := &Info{
+		ASN:      a.Origin.ASN,
+		Country:  a.Origin.Country,
+		Registry: a.Origin.Authority,
+		ASName:   a.ASN.Description,
+		Peers:    a.Peer.ASNs,
+	}
+
+	if a.Origin.Network != nil {
+		info.Prefix = a.Origin.Network.String()
+	}
+
+	return info
+}
+
+// EnrichJSON copies JSON Lines from r to w, adding an object describing the
+// address in each of the fields to every record, see EnrichRecord. Lines that
+// aren't JSON objects are copied as is, and keys may be reordered.
+func (e *Enricher) EnrichJSON(ctx context.Context, r io.Reader, w io.Writer, fields ...string) error {
+	return e.process(ctx, r, w, func(ctx context.Context, line []byte) []byte {
+		dec := json.NewDecoder(bytes.NewReader(line))
+		dec.UseNumber()
+
+		var record map[string]interface{}
+		if err := dec.Decode(&record); err != nil || record == nil {
+			return line
+		}
+
+		if !e.EnrichRecord(ctx, record, fields...) {
+			return line
+		}
+
+		var buf bytes.Buffer
+
+		enc := json.NewEncoder(&buf)
+		enc.SetEscapeHTML(false)
+
+		if err := enc.Encode(record); err != nil {
+			return line
+		}
+
+		return bytes.TrimSuffix(buf.Bytes(), []byte{'\n'})
+	})
+}
+
+// EnrichRecord adds an Info object alongside each of the fields, which are
+// dotted paths such as client.ip, named with FieldSuffix, eg: client.ip_as.
+// If the field is an array of addresses the object is an array of the same
+// length. Missing fields, and values that aren't addresses or can't be looked
+// up, are skipped, or null in arrays. It reports whether anything was added.
+func (e *Enricher) EnrichRecord(ctx context.Context, record map[string]interface{}, fields ...string) bool {
+	added := false
+
+	for _, field := range fields {
+		path := strings.Split(field, ".")
+		parent, key := record, path[len(path)-1]
+
+		for _, name := range path[:len(path)-1] {
+			child, ok := parent[name].(map[string]interface{})
+			if !ok {
+				parent = nil
+				break
+			}
+
+			parent = child
+		}
+
+		if parent == nil {
+			continue
+		}
+
+		switch v := parent[key].(type) {
+		case string:
+			if info := e.info(ctx, v); info != nil {
+				parent[key+FieldSuffix] = info
+				added = true
+			}
+		case []interface{}:
+			infos := make([]*Info, len(v))
+			found := false
+
+			for i, elem := range v {
+				if s, ok := elem.(string); ok {
+					infos[i] = e.info(ctx, s)
+					found = found || infos[i] != nil
+				}
+			}
+
+			if found {
+				parent[key+FieldSuffix] = infos
+				added = true
+			}
+		}
+	}
+
+	return added
+}
+
+// info looks up the address, which may include a port, including its peers
+func (e *Enricher) info(ctx context.Context, s string) *Info {
+	ip := net.ParseIP(s)
+	if ip == nil {
+		matches := Scan([]byte(s))
+		if len(matches) != 1 || strings.Trim(s[:matches[0].Start], " [") != "" {
+			return nil
+		}
+
+		ip = matches[0].IP
+	}
+
+	return e.annotate(ctx, ip, true).Info()
+}
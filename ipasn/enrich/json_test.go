@@ -0,0 +1,40 @@
+// Copyright 2019 Freman/Fremnet (Shannon Wynter). All rights reserved.
+
+package enrich_test
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/freman/cymru/ipasn"
+	"github.com/freman/cymru/ipasn/enrich"
+)
+
+func TestEnrichJSON(t *testing.T) {
+	t.Parallel()
+
+	input := `{"client":{"ip":"216.90.108.31","port":22},"dst":["1.1.1.1:443","10.0.0.1",7],"size":1.50}` + "\n" +
+		`{"client":{"ip":"not an ip"},"dst":"[2001:4860::1]:443","msg":"<b>"}` + "\n" +
+		`{"client":"216.90.108.31"}` + "\n" +
+		`not json` + "\n" +
+		`[1,2,3]` + "\n" +
+		`{"dst":["8.8.8.8"]}`
+
+	expected := `{"client":{"ip":"216.90.108.31","ip_as":{"asn":23028,"prefix":"216.90.108.0/24","country":"US","registry":"arin","as_name":"TEAM-CYMRU - Team Cymru Inc., US","peers":[701,1239,3549,3561,7132]},"port":22},` +
+		`"dst":["1.1.1.1:443","10.0.0.1",7],"dst_as":[{"asn":13335,"prefix":"1.1.1.0/24","country":"AU","registry":"apnic","as_name":"CLOUDFLARENET - Cloudflare, Inc., US"},null,null],"size":1.50}` + "\n" +
+		`{"client":{"ip":"not an ip"},"dst":"[2001:4860::1]:443","dst_as":{"asn":15169,"prefix":"2001:4860::/32","country":"US","registry":"arin","as_name":"GOOGLE - Google LLC, US"},"msg":"<b>"}` + "\n" +
+		`{"client":"216.90.108.31"}` + "\n" +
+		`not json` + "\n" +
+		`[1,2,3]` + "\n" +
+		`{"dst":["8.8.8.8"]}`
+
+	e := &enrich.Enricher{Client: ipasn.NewClient(ipasn.WithResolver(testResolver()))}
+
+	var buf bytes.Buffer
+	require.NoError(t, e.EnrichJSON(context.TODO(), strings.NewReader(input), &buf, "client.ip", "dst", "missing.field"))
+	require.Equal(t, expected, buf.String())
+}
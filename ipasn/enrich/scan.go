@@ -0,0 +1,103 @@
+// Copyright 2019 Freman/Fremnet (Shannon Wynter). All rights reserved.
+
+package enrich
+
+import (
+	"bytes"
+	"net"
+)
+
+// Match is an address found in a line, Start and End are byte offsets
+type Match struct {
+	Start, End int
+	IP         net.IP
+}
+
+// Scan finds the IPv4 and IPv6 addresses in line, including those with a port
+// such as 1.1.1.1:443 or [2606:4700::1111]:443, without matching the likes of
+// times and MAC addresses.
+func Scan(line []byte) []Match {
+	var matches []Match
+
+	for i := 0; i < len(line); {
+		if !isAddrByte(line[i]) || i > 0 && isWordByte(line[i-1]) {
+			i++
+			continue
+		}
+
+		end := i
+		for end < len(line) && isAddrByte(line[end]) {
+			end++
+		}
+
+		if m, ok := match(line, i, end); ok {
+			matches = append(matches, m)
+		}
+
+		i = end
+	}
+
+	return matches
+}
+
+// match tries the run of address characters line[start:end], trimming a
+// trailing port or full stop if it doesn't parse as is
+func match(line []byte, start, end int) (Match, bool) {
+	// Words carry on past the run, eg: 1.1.1.1x
+	if end < len(line) && isWordByte(line[end]) {
+		return Match{}, false
+	}
+
+	// A sentence can't start with a full stop but an IPv6 address can start
+	// with a colon
+	for start < end && line[start] == '.' {
+		start++
+	}
+
+	run := line[start:end]
+	if bytes.IndexAny(run, ".:") < 0 {
+		return Match{}, false
+	}
+
+	for len(run) > 0 {
+		if ip := parseIP(run); ip != nil {
+			return Match{Start: start, End: start + len(run), IP: ip}, true
+		}
+
+		switch {
+		case run[len(run)-1] == '.' || run[len(run)-1] == ':':
+			run = run[:len(run)-1]
+		case bytes.IndexByte(run, '.') > 0 && bytes.LastIndexByte(run, ':') > 0:
+			// IPv4 with a port, IPv6 with a port would be in brackets
+			run = run[:bytes.LastIndexByte(run, ':')]
+		default:
+			return Match{}, false
+		}
+	}
+
+	return Match{}, false
+}
+
+// parseIP parses the candidate only if it could be an address, which is much
+// cheaper than failing to parse most things
+func parseIP(b []byte) net.IP {
+	dots, colons := bytes.Count(b, []byte{'.'}), bytes.Count(b, []byte{':'})
+
+	switch {
+	case colons == 0 && dots != 3:
+		return nil
+	case colons > 0 && colons < 2:
+		return nil
+	}
+
+	return net.ParseIP(string(b))
+}
+
+func isAddrByte(c byte) bool {
+	return c >= '0' && c <= '9' || c >= 'a' && c <= 'f' || c >= 'A' && c <= 'F' || c == '.' || c == ':'
+}
+
+// isWordByte is true for characters that join an address to a word
+func isWordByte(c byte) bool {
+	return c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c == '_' || c == '-'
+}
@@ -0,0 +1,51 @@
+// Copyright 2019 Freman/Fremnet (Shannon Wynter). All rights reserved.
+
+package enrich_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/freman/cymru/ipasn/enrich"
+)
+
+func TestScan(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		line     string
+		expected []string
+	}{
+		{`1.1.1.1 - - [01/Dec/2019:10:11:12 +0000] "GET / HTTP/1.1" 200`, []string{"1.1.1.1"}},
+		{`Accepted publickey for root from 216.90.108.31 port 22 ssh2`, []string{"216.90.108.31"}},
+		{`Connection from 2001:4860:b002::68 to [2606:4700::1111]:443.`, []string{"2001:4860:b002::68", "2606:4700::1111"}},
+		{`src=10.0.0.1:5353 dst=8.8.8.8:53, done`, []string{"10.0.0.1", "8.8.8.8"}},
+		{`ended at 1.1.1.1.`, []string{"1.1.1.1"}},
+		{`client ::1 connected`, []string{"::1"}},
+		{`...1.2.3.4`, []string{"1.2.3.4"}},
+		{`mac 00:11:22:33:44:55 at 10:11:12.123`, nil},
+		{`version 1.2.3 of deadbeef`, nil},
+		{`host1.1.1.1 1.1.1.1x v1.2.3.4 1.2.3.4.5 999.1.1.1`, nil},
+		{``, nil},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.line, func(t *testing.T) {
+			t.Parallel()
+
+			var found []string
+
+			for _, m := range enrich.Scan([]byte(test.line)) {
+				require.Equal(t, m.IP, m.IP.To16())
+				found = append(found, m.IP.String())
+
+				// Offsets cover the address as written
+				require.Contains(t, []string{m.IP.String(), m.IP.To4().String()}, test.line[m.Start:m.End])
+			}
+
+			require.Equal(t, test.expected, found)
+		})
+	}
+}
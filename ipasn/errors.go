@@ -2,6 +2,12 @@
 
 package ipasn
 
+import (
+	"context"
+	"errors"
+	"net"
+)
+
 // Error is a string that will be returned by Cymru when things go bad
 type Error string
 
@@ -9,6 +15,21 @@ func (s Error) Error() string {
 	return string(s)
 }
 
+// Is reports whether s is one of the filtered errors when target is
+// ErrFiltered, so that errors.Is(err, ErrFiltered) matches any of them
+func (s Error) Is(target error) bool {
+	if target != ErrFiltered {
+		return false
+	}
+
+	switch s {
+	case ErrIPIsUnspecified, ErrIPIsLoopback, ErrIPIsMulticast, ErrIPIsPrivate:
+		return true
+	}
+
+	return false
+}
+
 // Various errors that will be returned depending on how things go
 const (
 	ErrIPIsUnspecified Error = "IP is unspecified"
@@ -16,4 +37,68 @@ const (
 	ErrIPIsMulticast   Error = "IP is a multicast address"
 	ErrIPIsPrivate     Error = "IP is a private address"
 	ErrNotFound        Error = "DNS result included no useful records"
+	ErrMalformed       Error = "DNS result could not be parsed"
+	ErrUnknownZone     Error = "name is not in a known zone"
+	ErrUnsupported     Error = "lookup is not supported by the provider"
+
+	// ErrFiltered is never returned itself, but errors.Is matches it for
+	// each of the ErrIPIs errors, ie: the address was rejected without being
+	// looked up
+	ErrFiltered Error = "IP is filtered"
 )
+
+// LookupError is returned when the Resolver fails, it carries the query name
+// and wraps whatever the Resolver returned, eg: a *net.DNSError.
+//
+// errors.Is(err, ErrNotFound) matches it when the Resolver reported that the
+// name doesn't exist, and it implements net.Error so that retries can key off
+// Timeout and Temporary.
+type LookupError struct {
+	Name string
+	Err  error
+}
+
+//nolint:gochecknoglobals
+var _ net.Error = (*LookupError)(nil)
+
+func (e *LookupError) Error() string {
+	return "lookup " + e.Name + ": " + e.Err.Error()
+}
+
+// Unwrap returns the Resolver's error
+func (e *LookupError) Unwrap() error {
+	return e.Err
+}
+
+// Is matches ErrNotFound when the Resolver reported that the name doesn't
+// exist
+func (e *LookupError) Is(target error) bool {
+	var dnsErr *net.DNSError
+
+	return target == ErrNotFound && errors.As(e.Err, &dnsErr) && dnsErr.IsNotFound
+}
+
+// Timeout reports whether the lookup timed out, either in the Resolver or
+// because the context's deadline passed
+func (e *LookupError) Timeout() bool {
+	var netErr net.Error
+
+	return errors.Is(e.Err, context.DeadlineExceeded) || errors.As(e.Err, &netErr) && netErr.Timeout()
+}
+
+// Temporary reports whether retrying the lookup might succeed, which is the
+// case for timeouts and temporary DNS failures, eg: SERVFAIL
+func (e *LookupError) Temporary() bool {
+	if e.Timeout() {
+		return true
+	}
+
+	var dnsErr *net.DNSError
+	if errors.As(e.Err, &dnsErr) {
+		return dnsErr.IsTemporary
+	}
+
+	var temporary interface{ Temporary() bool }
+
+	return errors.As(e.Err, &temporary) && temporary.Temporary()
+}
@@ -3,8 +3,10 @@
 package ipasn_test
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"net"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -21,6 +23,7 @@ func TestErrors(t *testing.T) {
 		ipasn.ErrIPIsMulticast,
 		ipasn.ErrIPIsPrivate,
 		ipasn.ErrNotFound,
+		ipasn.ErrMalformed,
 	}
 
 	for i, err := range testErrors {
@@ -43,3 +46,75 @@ func TestErrors(t *testing.T) {
 		})
 	}
 }
+
+func TestErrFiltered(t *testing.T) {
+	t.Parallel()
+
+	for _, err := range []error{ipasn.ErrIPIsUnspecified, ipasn.ErrIPIsLoopback, ipasn.ErrIPIsMulticast, ipasn.ErrIPIsPrivate} {
+		require.True(t, errors.Is(err, ipasn.ErrFiltered), err)
+		require.True(t, errors.Is(fmt.Errorf("Wrapped %w", err), ipasn.ErrFiltered), err)
+	}
+
+	for _, err := range []error{ipasn.ErrNotFound, ipasn.ErrMalformed, errors.New("IP is filtered")} {
+		require.False(t, errors.Is(err, ipasn.ErrFiltered), err)
+	}
+
+	require.False(t, errors.Is(ipasn.ErrIPIsPrivate, ipasn.ErrIPIsLoopback))
+}
+
+func TestLookupError(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		err       error
+		notFound  bool
+		timeout   bool
+		temporary bool
+	}{
+		{&net.DNSError{Err: "no such host", IsNotFound: true}, true, false, false},
+		{&net.DNSError{Err: "i/o timeout", IsTimeout: true}, false, true, true},
+		{&net.DNSError{Err: "server misbehaving", IsTemporary: true}, false, false, true},
+		{context.DeadlineExceeded, false, true, true},
+		{context.Canceled, false, false, false},
+		{errors.New("boom"), false, false, false},
+	}
+
+	for _, test := range tests {
+		err := &ipasn.LookupError{Name: "AS23028.asn.cymru.com.", Err: test.err}
+
+		require.Equal(t, "lookup AS23028.asn.cymru.com.: "+test.err.Error(), err.Error())
+		require.True(t, errors.Is(err, test.err))
+		require.Equal(t, test.notFound, errors.Is(err, ipasn.ErrNotFound), test.err)
+		require.Equal(t, test.timeout, err.Timeout(), test.err)
+		require.Equal(t, test.temporary, err.Temporary(), test.err)
+
+		var netErr net.Error
+		require.True(t, errors.As(fmt.Errorf("Wrapped %w", err), &netErr))
+		require.Equal(t, test.timeout, netErr.Timeout())
+	}
+}
+
+func TestClientLookupError(t *testing.T) {
+	t.Parallel()
+
+	nxdomain := &net.DNSError{Err: "no such host", Name: "8.8.8.8.origin.asn.cymru.com.", IsNotFound: true}
+
+	c := ipasn.NewClient(ipasn.WithResolver(mockResolver(func(ctx context.Context, name string) ([]string, error) {
+		return nil, nxdomain
+	})))
+
+	_, err := c.Origin(context.TODO(), net.IPv4(8, 8, 8, 8))
+	require.True(t, errors.Is(err, ipasn.ErrNotFound))
+
+	var lookupErr *ipasn.LookupError
+	require.True(t, errors.As(err, &lookupErr))
+	require.Equal(t, "8.8.8.8.origin.asn.cymru.com.", lookupErr.Name)
+
+	var dnsErr *net.DNSError
+	require.True(t, errors.As(err, &dnsErr))
+	require.Equal(t, nxdomain, dnsErr)
+
+	_, err = c.Origin(context.TODO(), net.IPv4(10, 0, 0, 1))
+	require.True(t, errors.Is(err, ipasn.ErrFiltered))
+	require.False(t, errors.As(err, &lookupErr))
+}
@@ -0,0 +1,5 @@
+// Copyright 2019 Freman/Fremnet (Shannon Wynter). All rights reserved.
+
+// Package firewall renders prefixes, such as those from ipasn.Origin, as rules and address lists for
+// ipset, nftables, iptables, pf, Cisco and Juniper so that whole networks or ASNs can be blocked.
+package firewall
@@ -0,0 +1,192 @@
+// Copyright 2019 Freman/Fremnet (Shannon Wynter). All rights reserved.
+
+package firewall
+
+import (
+	"bytes"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/freman/cymru/ipasn"
+)
+
+// Prefix is a network to render along with the ASN it belongs to, which is
+// used for comments
+type Prefix struct {
+	Network     *net.IPNet
+	ASN         int
+	Description string
+}
+
+// Comment returns the ASN and its description, eg: AS23028 TEAM-CYMRU - Team Cymru Inc., US
+func (p Prefix) Comment() string {
+	if p.ASN == 0 {
+		return p.Description
+	}
+
+	if p.Description == "" {
+		return "AS" + strconv.Itoa(p.ASN)
+	}
+
+	return "AS" + strconv.Itoa(p.ASN) + " " + p.Description
+}
+
+// FromOrigins returns the networks of the origins, descriptions are looked up
+// by ASN and may be nil
+func FromOrigins(origins []ipasn.OriginInfo, descriptions map[int]string) []Prefix {
+	prefixes := make([]Prefix, 0, len(origins))
+
+	for _, o := range origins {
+		if o.Network == nil {
+			continue
+		}
+
+		prefixes = append(prefixes, Prefix{Network: o.Network, ASN: o.ASN, Description: descriptions[o.ASN]})
+	}
+
+	return prefixes
+}
+
+// FromASN returns the networks announced by a single ASN
+func FromASN(asn int, description string, networks []*net.IPNet) []Prefix {
+	prefixes := make([]Prefix, 0, len(networks))
+
+	for _, n := range networks {
+		prefixes = append(prefixes, Prefix{Network: n, ASN: asn, Description: description})
+	}
+
+	return prefixes
+}
+
+// Aggregate returns the smallest list of prefixes covering the same addresses,
+// dropping prefixes that are contained by others and merging adjacent ones.
+// Prefixes are only combined with others of the same ASN so the comments stay
+// accurate. The result is sorted by ASN then address, IPv4 first.
+func Aggregate(prefixes []Prefix) []Prefix {
+	sorted := normalise(prefixes)
+
+	var (
+		out   []Prefix
+		start int
+	)
+
+	for i := 1; i <= len(sorted); i++ {
+		if i < len(sorted) && sorted[i].ASN == sorted[start].ASN && len(sorted[i].Network.IP) == len(sorted[start].Network.IP) {
+			continue
+		}
+
+		out = append(out, aggregate(sorted[start:i])...)
+		start = i
+	}
+
+	return out
+}
+
+// aggregate merges a sorted run of prefixes of the same ASN and family
+func aggregate(prefixes []Prefix) []Prefix {
+	var stack []Prefix
+
+	for _, p := range prefixes {
+		if len(stack) > 0 && stack[len(stack)-1].Network.Contains(p.Network.IP) {
+			continue
+		}
+
+		stack = append(stack, p)
+
+		// Keep merging the last two while they're halves of the same parent
+		for len(stack) > 1 {
+			a, b := stack[len(stack)-2], stack[len(stack)-1]
+
+			parent, ok := siblings(a.Network, b.Network)
+			if !ok {
+				break
+			}
+
+			a.Network = parent
+			stack = append(stack[:len(stack)-2], a)
+		}
+	}
+
+	return stack
+}
+
+// siblings returns the parent of a and b if they're its two halves
+func siblings(a, b *net.IPNet) (*net.IPNet, bool) {
+	ones, bits := a.Mask.Size()
+	if bOnes, _ := b.Mask.Size(); ones == 0 || ones != bOnes {
+		return nil, false
+	}
+
+	mask := net.CIDRMask(ones-1, bits)
+	if !a.IP.Mask(mask).Equal(b.IP.Mask(mask)) || a.IP.Equal(b.IP) {
+		return nil, false
+	}
+
+	return &net.IPNet{IP: a.IP.Mask(mask), Mask: mask}, true
+}
+
+// normalise copies the prefixes, masking the networks and using 4 byte IPv4
+// addresses, then sorts them by ASN, family, address and prefix length
+func normalise(prefixes []Prefix) []Prefix {
+	sorted := make([]Prefix, 0, len(prefixes))
+
+	for _, p := range prefixes {
+		if p.Network == nil {
+			continue
+		}
+
+		ip, mask := p.Network.IP, p.Network.Mask
+		if ip4 := ip.To4(); ip4 != nil {
+			if ones, bits := mask.Size(); bits == 8*net.IPv4len {
+				ip = ip4
+			} else if ones >= 8*(net.IPv6len-net.IPv4len) {
+				ip, mask = ip4, net.CIDRMask(ones-8*(net.IPv6len-net.IPv4len), 8*net.IPv4len)
+			}
+		}
+
+		p.Network = &net.IPNet{IP: ip.Mask(mask), Mask: mask}
+		sorted = append(sorted, p)
+	}
+
+	sort.SliceStable(sorted, func(i, j int) bool {
+		a, b := sorted[i], sorted[j]
+
+		switch {
+		case a.ASN != b.ASN:
+			return a.ASN < b.ASN
+		case len(a.Network.IP) != len(b.Network.IP):
+			return len(a.Network.IP) < len(b.Network.IP)
+		}
+
+		if c := bytes.Compare(a.Network.IP, b.Network.IP); c != 0 {
+			return c < 0
+		}
+
+		aOnes, _ := a.Network.Mask.Size()
+		bOnes, _ := b.Network.Mask.Size()
+
+		return aOnes < bOnes
+	})
+
+	return sorted
+}
+
+// split returns the IPv4 and IPv6 prefixes
+func split(prefixes []Prefix) (v4, v6 []Prefix) {
+	for _, p := range prefixes {
+		if len(p.Network.IP) == net.IPv4len {
+			v4 = append(v4, p)
+		} else {
+			v6 = append(v6, p)
+		}
+	}
+
+	return v4, v6
+}
+
+// quote makes the comment safe to use inside double quotes
+func quote(s string) string {
+	return `"` + strings.NewReplacer(`"`, `'`, `\`, `/`, "\n", " ").Replace(s) + `"`
+}
@@ -0,0 +1,104 @@
+// Copyright 2019 Freman/Fremnet (Shannon Wynter). All rights reserved.
+
+package firewall_test
+
+import (
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/freman/cymru/ipasn"
+	"github.com/freman/cymru/ipasn/firewall"
+)
+
+func mustCIDR(s string) *net.IPNet {
+	_, n, err := net.ParseCIDR(s)
+	if err != nil {
+		panic(err)
+	}
+
+	return n
+}
+
+func networks(prefixes []firewall.Prefix) []string {
+	strs := make([]string, len(prefixes))
+	for i, p := range prefixes {
+		strs[i] = p.Comment() + " " + p.Network.String()
+	}
+
+	return strs
+}
+
+func TestAggregate(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		input    []firewall.Prefix
+		expected []string
+	}{
+		{
+			name: "siblings",
+			input: firewall.FromASN(1, "", []*net.IPNet{
+				mustCIDR("10.0.1.0/24"), mustCIDR("10.0.0.0/24"), mustCIDR("10.0.2.0/24"), mustCIDR("10.0.3.0/24"),
+			}),
+			expected: []string{"AS1 10.0.0.0/22"},
+		},
+		{
+			name: "contained",
+			input: firewall.FromASN(1, "", []*net.IPNet{
+				mustCIDR("10.0.0.128/25"), mustCIDR("10.0.0.0/16"), mustCIDR("10.0.5.0/24"), mustCIDR("10.1.0.0/24"),
+			}),
+			expected: []string{"AS1 10.0.0.0/16", "AS1 10.1.0.0/24"},
+		},
+		{
+			name: "not siblings",
+			input: firewall.FromASN(1, "", []*net.IPNet{
+				mustCIDR("10.0.1.0/24"), mustCIDR("10.0.2.0/24"),
+			}),
+			expected: []string{"AS1 10.0.1.0/24", "AS1 10.0.2.0/24"},
+		},
+		{
+			name: "cascade",
+			input: firewall.FromASN(1, "", []*net.IPNet{
+				mustCIDR("10.0.0.0/25"), mustCIDR("10.0.1.0/24"), mustCIDR("10.0.0.128/25"),
+			}),
+			expected: []string{"AS1 10.0.0.0/23"},
+		},
+		{
+			name: "separate asns and families",
+			input: append(append(
+				firewall.FromASN(2, "TWO", []*net.IPNet{mustCIDR("10.0.1.0/24"), mustCIDR("2001:db8::/33"), mustCIDR("2001:db8:8000::/33")}),
+				firewall.FromASN(1, "ONE", []*net.IPNet{mustCIDR("10.0.0.0/24")})...),
+				firewall.Prefix{Network: &net.IPNet{IP: net.ParseIP("10.0.2.0"), Mask: net.CIDRMask(120, 128)}, ASN: 2, Description: "TWO"},
+				firewall.Prefix{Network: &net.IPNet{IP: net.ParseIP("10.0.3.0"), Mask: net.CIDRMask(24, 32)}, ASN: 2, Description: "TWO"},
+			),
+			expected: []string{"AS1 ONE 10.0.0.0/24", "AS2 TWO 10.0.1.0/24", "AS2 TWO 10.0.2.0/23", "AS2 TWO 2001:db8::/32"},
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			require.Equal(t, test.expected, networks(firewall.Aggregate(test.input)))
+		})
+	}
+}
+
+func TestFromOrigins(t *testing.T) {
+	t.Parallel()
+
+	prefixes := firewall.FromOrigins([]ipasn.OriginInfo{
+		{ASN: 23028, Network: mustCIDR("216.90.108.0/24")},
+		{ASN: 15169, Network: mustCIDR("2001:4860::/32")},
+		{},
+	}, map[int]string{23028: "TEAM-CYMRU - Team Cymru Inc., US"})
+
+	require.Equal(t, []string{
+		"AS23028 TEAM-CYMRU - Team Cymru Inc., US 216.90.108.0/24",
+		"AS15169 2001:4860::/32",
+	}, networks(prefixes))
+}
@@ -0,0 +1,230 @@
+// Copyright 2019 Freman/Fremnet (Shannon Wynter). All rights reserved.
+
+package firewall
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"sort"
+	"strings"
+)
+
+// Renderer writes the prefixes in the format of a firewall, or router, using
+// name for the set, table, chain or list. Prefixes are sorted by ASN then
+// address, and IPv6 prefixes go in a separate set where the format needs one,
+// named with a 6 suffix.
+type Renderer func(w io.Writer, name string, prefixes []Prefix) error
+
+// renderers are keyed by the names used by Lookup and Formats
+//
+//nolint:gochecknoglobals
+var renderers = map[string]Renderer{
+	"ipset":     IPSet,
+	"nftables":  NFTables,
+	"iptables":  IPTables,
+	"pf":        PF,
+	"cisco":     CiscoPrefixList,
+	"cisco-acl": CiscoACL,
+	"juniper":   JuniperPrefixList,
+}
+
+// Lookup returns the Renderer for the named format, see Formats
+func Lookup(format string) (Renderer, bool) {
+	r, found := renderers[strings.ToLower(format)]
+	return r, found
+}
+
+// Formats returns the names of the formats known to Lookup
+func Formats() []string {
+	names := make([]string, 0, len(renderers))
+	for name := range renderers {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+// IPSet renders an ipset restore file of hash:net sets with a comment on
+// every entry, eg: ipset restore -exist < file
+func IPSet(w io.Writer, name string, prefixes []Prefix) error {
+	bw := bufio.NewWriter(w)
+	v4, v6 := split(normalise(prefixes))
+
+	for _, set := range []struct {
+		name     string
+		family   string
+		prefixes []Prefix
+	}{{name, "inet", v4}, {name + "6", "inet6", v6}} {
+		if len(set.prefixes) == 0 {
+			continue
+		}
+
+		fmt.Fprintf(bw, "create %s hash:net family %s comment\n", set.name, set.family)
+
+		for _, p := range set.prefixes {
+			fmt.Fprintf(bw, "add %s %s comment %s\n", set.name, p.Network, quote(p.Comment()))
+		}
+	}
+
+	return bw.Flush()
+}
+
+// NFTables renders interval set definitions, with a comment on every element,
+// to be included in a table
+func NFTables(w io.Writer, name string, prefixes []Prefix) error {
+	bw := bufio.NewWriter(w)
+	v4, v6 := split(normalise(prefixes))
+
+	for _, set := range []struct {
+		name     string
+		typ      string
+		prefixes []Prefix
+	}{{name, "ipv4_addr", v4}, {name + "6", "ipv6_addr", v6}} {
+		if len(set.prefixes) == 0 {
+			continue
+		}
+
+		fmt.Fprintf(bw, "set %s {\n\ttype %s\n\tflags interval\n\telements = {\n", set.name, set.typ)
+
+		for i, p := range set.prefixes {
+			sep := ","
+			if i == len(set.prefixes)-1 {
+				sep = ""
+			}
+
+			fmt.Fprintf(bw, "\t\t%s comment %s%s\n", p.Network, quote(p.Comment()), sep)
+		}
+
+		fmt.Fprint(bw, "\t}\n}\n")
+	}
+
+	return bw.Flush()
+}
+
+// IPTables renders iptables, and ip6tables, commands appending a rule to drop
+// traffic from each prefix to the chain called name
+func IPTables(w io.Writer, name string, prefixes []Prefix) error {
+	bw := bufio.NewWriter(w)
+
+	for _, p := range normalise(prefixes) {
+		cmd := "iptables"
+		if len(p.Network.IP) == net.IPv6len {
+			cmd = "ip6tables"
+		}
+
+		fmt.Fprintf(bw, "%s -A %s -s %s -m comment --comment %s -j DROP\n", cmd, name, p.Network, quote(p.Comment()))
+	}
+
+	return bw.Flush()
+}
+
+// PF renders a pf table file, eg: pfctl -t name -T replace -f file
+func PF(w io.Writer, name string, prefixes []Prefix) error {
+	bw := bufio.NewWriter(w)
+
+	fmt.Fprintf(bw, "# table <%s>\n", name)
+	grouped(normalise(prefixes), func(p Prefix, first bool) {
+		if first {
+			fmt.Fprintf(bw, "# %s\n", p.Comment())
+		}
+
+		fmt.Fprintf(bw, "%s\n", p.Network)
+	})
+
+	return bw.Flush()
+}
+
+// CiscoPrefixList renders IOS ip, and ipv6, prefix-list deny entries
+func CiscoPrefixList(w io.Writer, name string, prefixes []Prefix) error {
+	bw := bufio.NewWriter(w)
+	seq := map[bool]int{}
+
+	grouped(normalise(prefixes), func(p Prefix, first bool) {
+		if first {
+			fmt.Fprintf(bw, "! %s\n", p.Comment())
+		}
+
+		v6 := len(p.Network.IP) == net.IPv6len
+		seq[v6] += 5
+
+		if v6 {
+			fmt.Fprintf(bw, "ipv6 prefix-list %s6 seq %d deny %s\n", name, seq[v6], p.Network)
+		} else {
+			fmt.Fprintf(bw, "ip prefix-list %s seq %d deny %s\n", name, seq[v6], p.Network)
+		}
+	})
+
+	return bw.Flush()
+}
+
+// CiscoACL renders IOS extended, and IPv6, access lists denying traffic from
+// each prefix with a remark for each ASN, the lists end permitting everything
+// else
+func CiscoACL(w io.Writer, name string, prefixes []Prefix) error {
+	bw := bufio.NewWriter(w)
+	v4, v6 := split(normalise(prefixes))
+
+	if len(v4) > 0 {
+		fmt.Fprintf(bw, "ip access-list extended %s\n", name)
+		grouped(v4, func(p Prefix, first bool) {
+			if first {
+				fmt.Fprintf(bw, " remark %s\n", p.Comment())
+			}
+
+			fmt.Fprintf(bw, " deny ip %s %s any\n", p.Network.IP, wildcard(p.Network.Mask))
+		})
+		fmt.Fprint(bw, " permit ip any any\n")
+	}
+
+	if len(v6) > 0 {
+		fmt.Fprintf(bw, "ipv6 access-list %s6\n", name)
+		grouped(v6, func(p Prefix, first bool) {
+			if first {
+				fmt.Fprintf(bw, " remark %s\n", p.Comment())
+			}
+
+			fmt.Fprintf(bw, " deny ipv6 %s any\n", p.Network)
+		})
+		fmt.Fprint(bw, " permit ipv6 any any\n")
+	}
+
+	return bw.Flush()
+}
+
+// JuniperPrefixList renders a Junos policy-options prefix-list
+func JuniperPrefixList(w io.Writer, name string, prefixes []Prefix) error {
+	bw := bufio.NewWriter(w)
+
+	fmt.Fprintf(bw, "policy-options {\n    prefix-list %s {\n", name)
+	grouped(normalise(prefixes), func(p Prefix, first bool) {
+		if first {
+			fmt.Fprintf(bw, "        /* %s */\n", strings.Replace(p.Comment(), "*/", "* /", -1))
+		}
+
+		fmt.Fprintf(bw, "        %s;\n", p.Network)
+	})
+	fmt.Fprint(bw, "    }\n}\n")
+
+	return bw.Flush()
+}
+
+// grouped calls fn for each prefix noting the first of each ASN
+func grouped(prefixes []Prefix, fn func(p Prefix, first bool)) {
+	for i, p := range prefixes {
+		fn(p, i == 0 || prefixes[i-1].ASN != p.ASN || prefixes[i-1].Description != p.Description)
+	}
+}
+
+// wildcard returns the inverse of mask as Cisco expects in access lists
+func wildcard(mask net.IPMask) net.IP {
+	w := make(net.IP, len(mask))
+	for i, b := range mask {
+		w[i] = ^b
+	}
+
+	return w
+}
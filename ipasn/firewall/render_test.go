@@ -0,0 +1,130 @@
+// Copyright 2019 Freman/Fremnet (Shannon Wynter). All rights reserved.
+
+package firewall_test
+
+import (
+	"bytes"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/freman/cymru/ipasn/firewall"
+)
+
+func testPrefixes() []firewall.Prefix {
+	return append(
+		firewall.FromASN(23028, `TEAM-CYMRU - "Team Cymru" Inc., US`, []*net.IPNet{mustCIDR("216.90.108.0/24"), mustCIDR("2001:db8::/32")}),
+		firewall.FromASN(1234, "", []*net.IPNet{mustCIDR("10.0.0.0/8")})...,
+	)
+}
+
+func TestRender(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		format   string
+		expected string
+	}{
+		{
+			format: "ipset",
+			expected: `create blocked hash:net family inet comment
+add blocked 10.0.0.0/8 comment "AS1234"
+add blocked 216.90.108.0/24 comment "AS23028 TEAM-CYMRU - 'Team Cymru' Inc., US"
+create blocked6 hash:net family inet6 comment
+add blocked6 2001:db8::/32 comment "AS23028 TEAM-CYMRU - 'Team Cymru' Inc., US"
+`,
+		},
+		{
+			format: "nftables",
+			expected: `set blocked {
+	type ipv4_addr
+	flags interval
+	elements = {
+		10.0.0.0/8 comment "AS1234",
+		216.90.108.0/24 comment "AS23028 TEAM-CYMRU - 'Team Cymru' Inc., US"
+	}
+}
+set blocked6 {
+	type ipv6_addr
+	flags interval
+	elements = {
+		2001:db8::/32 comment "AS23028 TEAM-CYMRU - 'Team Cymru' Inc., US"
+	}
+}
+`,
+		},
+		{
+			format: "iptables",
+			expected: `iptables -A blocked -s 10.0.0.0/8 -m comment --comment "AS1234" -j DROP
+iptables -A blocked -s 216.90.108.0/24 -m comment --comment "AS23028 TEAM-CYMRU - 'Team Cymru' Inc., US" -j DROP
+ip6tables -A blocked -s 2001:db8::/32 -m comment --comment "AS23028 TEAM-CYMRU - 'Team Cymru' Inc., US" -j DROP
+`,
+		},
+		{
+			format: "pf",
+			expected: `# table <blocked>
+# AS1234
+10.0.0.0/8
+# AS23028 TEAM-CYMRU - "Team Cymru" Inc., US
+216.90.108.0/24
+2001:db8::/32
+`,
+		},
+		{
+			format: "cisco",
+			expected: `! AS1234
+ip prefix-list blocked seq 5 deny 10.0.0.0/8
+! AS23028 TEAM-CYMRU - "Team Cymru" Inc., US
+ip prefix-list blocked seq 10 deny 216.90.108.0/24
+ipv6 prefix-list blocked6 seq 5 deny 2001:db8::/32
+`,
+		},
+		{
+			format: "cisco-acl",
+			expected: `ip access-list extended blocked
+ remark AS1234
+ deny ip 10.0.0.0 0.255.255.255 any
+ remark AS23028 TEAM-CYMRU - "Team Cymru" Inc., US
+ deny ip 216.90.108.0 0.0.0.255 any
+ permit ip any any
+ipv6 access-list blocked6
+ remark AS23028 TEAM-CYMRU - "Team Cymru" Inc., US
+ deny ipv6 2001:db8::/32 any
+ permit ipv6 any any
+`,
+		},
+		{
+			format: "juniper",
+			expected: `policy-options {
+    prefix-list blocked {
+        /* AS1234 */
+        10.0.0.0/8;
+        /* AS23028 TEAM-CYMRU - "Team Cymru" Inc., US */
+        216.90.108.0/24;
+        2001:db8::/32;
+    }
+}
+`,
+		},
+	}
+
+	require.Len(t, firewall.Formats(), len(tests))
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.format, func(t *testing.T) {
+			t.Parallel()
+
+			render, found := firewall.Lookup(test.format)
+			require.True(t, found)
+
+			var buf bytes.Buffer
+			require.NoError(t, render(&buf, "blocked", testPrefixes()))
+			require.Equal(t, test.expected, buf.String())
+		})
+	}
+
+	_, found := firewall.Lookup("hosts.deny")
+	require.False(t, found)
+}
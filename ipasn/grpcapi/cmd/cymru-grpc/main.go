@@ -0,0 +1,115 @@
+// Copyright 2019 Freman/Fremnet (Shannon Wynter). All rights reserved.
+
+// Command cymru-grpc serves ipasn lookups over gRPC, see ipasn.proto, and
+// optionally the HTTP/JSON API of cymru-server sharing the same cache.
+//
+// Usage:
+//
+//	cymru-grpc -listen 127.0.0.1:9090 [-http 127.0.0.1:8080] [-resolver host:port] [-zone asn.cymru.com.] [-provider cymru,routeviews] [-timeout 5s] [-ttl 4h] [-cache-size 100000] [-concurrency 16]
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"google.golang.org/grpc"
+
+	"github.com/freman/cymru/ipasn"
+	"github.com/freman/cymru/ipasn/grpcapi"
+	"github.com/freman/cymru/ipasn/httpapi"
+)
+
+func main() {
+	var (
+		cfg                ipasn.Config
+		listen, httpListen string
+		timeout            time.Duration
+		concurrency        int
+	)
+
+	flag.StringVar(&listen, "listen", "127.0.0.1:9090", "gRPC address to listen on")
+	flag.StringVar(&httpListen, "http", "", "HTTP address to also serve the HTTP/JSON API on")
+	cfg.RegisterFlags(flag.CommandLine)
+	flag.DurationVar(&timeout, "timeout", 5*time.Second, "Timeout for each lookup")
+	flag.DurationVar(&cfg.CacheTTL, "ttl", 4*time.Hour, "How long answers are cached, this should match the TTL of the zone")
+	flag.IntVar(&cfg.CacheSize, "cache-size", 100000, "Number of answers to cache")
+	flag.IntVar(&concurrency, "concurrency", 16, "Number of lookups to run at once for each Lookup stream")
+	flag.Parse()
+
+	client, err := cfg.NewClient()
+	if err != nil {
+		fmt.Println("Error:", err)
+		os.Exit(2)
+	}
+
+	gs := grpc.NewServer()
+	grpcapi.RegisterIPASNServer(gs, &grpcapi.Server{
+		Client:      client,
+		Timeout:     timeout,
+		Concurrency: concurrency,
+	})
+
+	l, err := net.Listen("tcp", listen)
+	if err != nil {
+		fmt.Println("Error starting server:", err)
+		os.Exit(1)
+	}
+
+	fmt.Println("Serving gRPC on", l.Addr())
+
+	var hs *http.Server
+
+	if httpListen != "" {
+		hs = &http.Server{
+			Addr: httpListen,
+			Handler: &httpapi.Handler{
+				Client:  client,
+				MaxAge:  cfg.CacheTTL,
+				Timeout: timeout,
+			},
+			ReadHeaderTimeout: 10 * time.Second,
+		}
+
+		hl, err := net.Listen("tcp", httpListen)
+		if err != nil {
+			fmt.Println("Error starting HTTP server:", err)
+			os.Exit(1)
+		}
+
+		fmt.Println("Serving HTTP on", hl.Addr())
+
+		go func() {
+			if err := hs.Serve(hl); err != http.ErrServerClosed {
+				fmt.Println("Error serving HTTP:", err)
+				os.Exit(1)
+			}
+		}()
+	}
+
+	go func() {
+		signals := make(chan os.Signal, 1)
+		signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+		<-signals
+
+		if hs != nil {
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+
+			_ = hs.Shutdown(ctx)
+		}
+
+		gs.GracefulStop()
+	}()
+
+	if err := gs.Serve(l); err != nil {
+		fmt.Println("Error serving gRPC:", err)
+		os.Exit(1)
+	}
+}
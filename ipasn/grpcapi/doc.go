@@ -0,0 +1,9 @@
+// Copyright 2019 Freman/Fremnet (Shannon Wynter). All rights reserved.
+
+// Package grpcapi implements a gRPC API for ipasn lookups, including
+// bidirectional streaming of batches, see ipasn.proto.
+//
+// It's a separate module so that the rest of ipasn doesn't depend on gRPC.
+package grpcapi
+
+//go:generate protoc --go_out=plugins=grpc,paths=source_relative:. ipasn.proto
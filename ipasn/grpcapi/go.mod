@@ -0,0 +1,12 @@
+module github.com/freman/cymru/ipasn/grpcapi
+
+go 1.13
+
+replace github.com/freman/cymru => ../..
+
+require (
+	github.com/freman/cymru v0.0.0-00010101000000-000000000000
+	github.com/golang/protobuf v1.3.2
+	github.com/stretchr/testify v1.4.0
+	google.golang.org/grpc v1.27.0
+)
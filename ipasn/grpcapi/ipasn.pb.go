@@ -0,0 +1,821 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// source: ipasn.proto
+
+package grpcapi
+
+import (
+	context "context"
+	fmt "fmt"
+	proto "github.com/golang/protobuf/proto"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+	math "math"
+)
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ = proto.Marshal
+var _ = fmt.Errorf
+var _ = math.Inf
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the proto package it is being compiled against.
+// A compilation error at this line likely means your copy of the
+// proto package needs to be updated.
+const _ = proto.ProtoPackageIsVersion3 // please upgrade the proto package
+
+// IPRequest is an IP address or prefix, prefixes are looked up by their
+// network address
+type IPRequest struct {
+	Ip                   string   `protobuf:"bytes,1,opt,name=ip,proto3" json:"ip,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *IPRequest) Reset()         { *m = IPRequest{} }
+func (m *IPRequest) String() string { return proto.CompactTextString(m) }
+func (*IPRequest) ProtoMessage()    {}
+func (*IPRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_d08a48cc46004e77, []int{0}
+}
+
+func (m *IPRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_IPRequest.Unmarshal(m, b)
+}
+func (m *IPRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_IPRequest.Marshal(b, m, deterministic)
+}
+func (m *IPRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_IPRequest.Merge(m, src)
+}
+func (m *IPRequest) XXX_Size() int {
+	return xxx_messageInfo_IPRequest.Size(m)
+}
+func (m *IPRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_IPRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_IPRequest proto.InternalMessageInfo
+
+func (m *IPRequest) GetIp() string {
+	if m != nil {
+		return m.Ip
+	}
+	return ""
+}
+
+type ASNRequest struct {
+	Asn                  uint32   `protobuf:"varint,1,opt,name=asn,proto3" json:"asn,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ASNRequest) Reset()         { *m = ASNRequest{} }
+func (m *ASNRequest) String() string { return proto.CompactTextString(m) }
+func (*ASNRequest) ProtoMessage()    {}
+func (*ASNRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_d08a48cc46004e77, []int{1}
+}
+
+func (m *ASNRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ASNRequest.Unmarshal(m, b)
+}
+func (m *ASNRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ASNRequest.Marshal(b, m, deterministic)
+}
+func (m *ASNRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ASNRequest.Merge(m, src)
+}
+func (m *ASNRequest) XXX_Size() int {
+	return xxx_messageInfo_ASNRequest.Size(m)
+}
+func (m *ASNRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_ASNRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ASNRequest proto.InternalMessageInfo
+
+func (m *ASNRequest) GetAsn() uint32 {
+	if m != nil {
+		return m.Asn
+	}
+	return 0
+}
+
+type OriginReply struct {
+	Asn      uint32 `protobuf:"varint,1,opt,name=asn,proto3" json:"asn,omitempty"`
+	Prefix   string `protobuf:"bytes,2,opt,name=prefix,proto3" json:"prefix,omitempty"`
+	Country  string `protobuf:"bytes,3,opt,name=country,proto3" json:"country,omitempty"`
+	Registry string `protobuf:"bytes,4,opt,name=registry,proto3" json:"registry,omitempty"`
+	// allocated is the date the prefix was allocated, as YYYY-MM-DD
+	Allocated            string   `protobuf:"bytes,5,opt,name=allocated,proto3" json:"allocated,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *OriginReply) Reset()         { *m = OriginReply{} }
+func (m *OriginReply) String() string { return proto.CompactTextString(m) }
+func (*OriginReply) ProtoMessage()    {}
+func (*OriginReply) Descriptor() ([]byte, []int) {
+	return fileDescriptor_d08a48cc46004e77, []int{2}
+}
+
+func (m *OriginReply) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_OriginReply.Unmarshal(m, b)
+}
+func (m *OriginReply) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_OriginReply.Marshal(b, m, deterministic)
+}
+func (m *OriginReply) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_OriginReply.Merge(m, src)
+}
+func (m *OriginReply) XXX_Size() int {
+	return xxx_messageInfo_OriginReply.Size(m)
+}
+func (m *OriginReply) XXX_DiscardUnknown() {
+	xxx_messageInfo_OriginReply.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_OriginReply proto.InternalMessageInfo
+
+func (m *OriginReply) GetAsn() uint32 {
+	if m != nil {
+		return m.Asn
+	}
+	return 0
+}
+
+func (m *OriginReply) GetPrefix() string {
+	if m != nil {
+		return m.Prefix
+	}
+	return ""
+}
+
+func (m *OriginReply) GetCountry() string {
+	if m != nil {
+		return m.Country
+	}
+	return ""
+}
+
+func (m *OriginReply) GetRegistry() string {
+	if m != nil {
+		return m.Registry
+	}
+	return ""
+}
+
+func (m *OriginReply) GetAllocated() string {
+	if m != nil {
+		return m.Allocated
+	}
+	return ""
+}
+
+type PeerReply struct {
+	Peers                []uint32 `protobuf:"varint,1,rep,packed,name=peers,proto3" json:"peers,omitempty"`
+	Prefix               string   `protobuf:"bytes,2,opt,name=prefix,proto3" json:"prefix,omitempty"`
+	Country              string   `protobuf:"bytes,3,opt,name=country,proto3" json:"country,omitempty"`
+	Registry             string   `protobuf:"bytes,4,opt,name=registry,proto3" json:"registry,omitempty"`
+	Allocated            string   `protobuf:"bytes,5,opt,name=allocated,proto3" json:"allocated,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *PeerReply) Reset()         { *m = PeerReply{} }
+func (m *PeerReply) String() string { return proto.CompactTextString(m) }
+func (*PeerReply) ProtoMessage()    {}
+func (*PeerReply) Descriptor() ([]byte, []int) {
+	return fileDescriptor_d08a48cc46004e77, []int{3}
+}
+
+func (m *PeerReply) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_PeerReply.Unmarshal(m, b)
+}
+func (m *PeerReply) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_PeerReply.Marshal(b, m, deterministic)
+}
+func (m *PeerReply) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_PeerReply.Merge(m, src)
+}
+func (m *PeerReply) XXX_Size() int {
+	return xxx_messageInfo_PeerReply.Size(m)
+}
+func (m *PeerReply) XXX_DiscardUnknown() {
+	xxx_messageInfo_PeerReply.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_PeerReply proto.InternalMessageInfo
+
+func (m *PeerReply) GetPeers() []uint32 {
+	if m != nil {
+		return m.Peers
+	}
+	return nil
+}
+
+func (m *PeerReply) GetPrefix() string {
+	if m != nil {
+		return m.Prefix
+	}
+	return ""
+}
+
+func (m *PeerReply) GetCountry() string {
+	if m != nil {
+		return m.Country
+	}
+	return ""
+}
+
+func (m *PeerReply) GetRegistry() string {
+	if m != nil {
+		return m.Registry
+	}
+	return ""
+}
+
+func (m *PeerReply) GetAllocated() string {
+	if m != nil {
+		return m.Allocated
+	}
+	return ""
+}
+
+type ASNReply struct {
+	Asn                  uint32   `protobuf:"varint,1,opt,name=asn,proto3" json:"asn,omitempty"`
+	Country              string   `protobuf:"bytes,2,opt,name=country,proto3" json:"country,omitempty"`
+	Registry             string   `protobuf:"bytes,3,opt,name=registry,proto3" json:"registry,omitempty"`
+	Allocated            string   `protobuf:"bytes,4,opt,name=allocated,proto3" json:"allocated,omitempty"`
+	AsName               string   `protobuf:"bytes,5,opt,name=as_name,json=asName,proto3" json:"as_name,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ASNReply) Reset()         { *m = ASNReply{} }
+func (m *ASNReply) String() string { return proto.CompactTextString(m) }
+func (*ASNReply) ProtoMessage()    {}
+func (*ASNReply) Descriptor() ([]byte, []int) {
+	return fileDescriptor_d08a48cc46004e77, []int{4}
+}
+
+func (m *ASNReply) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ASNReply.Unmarshal(m, b)
+}
+func (m *ASNReply) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ASNReply.Marshal(b, m, deterministic)
+}
+func (m *ASNReply) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ASNReply.Merge(m, src)
+}
+func (m *ASNReply) XXX_Size() int {
+	return xxx_messageInfo_ASNReply.Size(m)
+}
+func (m *ASNReply) XXX_DiscardUnknown() {
+	xxx_messageInfo_ASNReply.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ASNReply proto.InternalMessageInfo
+
+func (m *ASNReply) GetAsn() uint32 {
+	if m != nil {
+		return m.Asn
+	}
+	return 0
+}
+
+func (m *ASNReply) GetCountry() string {
+	if m != nil {
+		return m.Country
+	}
+	return ""
+}
+
+func (m *ASNReply) GetRegistry() string {
+	if m != nil {
+		return m.Registry
+	}
+	return ""
+}
+
+func (m *ASNReply) GetAllocated() string {
+	if m != nil {
+		return m.Allocated
+	}
+	return ""
+}
+
+func (m *ASNReply) GetAsName() string {
+	if m != nil {
+		return m.AsName
+	}
+	return ""
+}
+
+type Query struct {
+	// id is returned in the Answer
+	Id uint64 `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	// Types that are valid to be assigned to Query:
+	//	*Query_Origin
+	//	*Query_Peer
+	//	*Query_Asn
+	Query                isQuery_Query `protobuf_oneof:"query"`
+	XXX_NoUnkeyedLiteral struct{}      `json:"-"`
+	XXX_unrecognized     []byte        `json:"-"`
+	XXX_sizecache        int32         `json:"-"`
+}
+
+func (m *Query) Reset()         { *m = Query{} }
+func (m *Query) String() string { return proto.CompactTextString(m) }
+func (*Query) ProtoMessage()    {}
+func (*Query) Descriptor() ([]byte, []int) {
+	return fileDescriptor_d08a48cc46004e77, []int{5}
+}
+
+func (m *Query) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Query.Unmarshal(m, b)
+}
+func (m *Query) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Query.Marshal(b, m, deterministic)
+}
+func (m *Query) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Query.Merge(m, src)
+}
+func (m *Query) XXX_Size() int {
+	return xxx_messageInfo_Query.Size(m)
+}
+func (m *Query) XXX_DiscardUnknown() {
+	xxx_messageInfo_Query.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Query proto.InternalMessageInfo
+
+func (m *Query) GetId() uint64 {
+	if m != nil {
+		return m.Id
+	}
+	return 0
+}
+
+type isQuery_Query interface {
+	isQuery_Query()
+}
+
+type Query_Origin struct {
+	Origin string `protobuf:"bytes,2,opt,name=origin,proto3,oneof"`
+}
+
+type Query_Peer struct {
+	Peer string `protobuf:"bytes,3,opt,name=peer,proto3,oneof"`
+}
+
+type Query_Asn struct {
+	Asn uint32 `protobuf:"varint,4,opt,name=asn,proto3,oneof"`
+}
+
+func (*Query_Origin) isQuery_Query() {}
+
+func (*Query_Peer) isQuery_Query() {}
+
+func (*Query_Asn) isQuery_Query() {}
+
+func (m *Query) GetQuery() isQuery_Query {
+	if m != nil {
+		return m.Query
+	}
+	return nil
+}
+
+func (m *Query) GetOrigin() string {
+	if x, ok := m.GetQuery().(*Query_Origin); ok {
+		return x.Origin
+	}
+	return ""
+}
+
+func (m *Query) GetPeer() string {
+	if x, ok := m.GetQuery().(*Query_Peer); ok {
+		return x.Peer
+	}
+	return ""
+}
+
+func (m *Query) GetAsn() uint32 {
+	if x, ok := m.GetQuery().(*Query_Asn); ok {
+		return x.Asn
+	}
+	return 0
+}
+
+// XXX_OneofWrappers is for the internal use of the proto package.
+func (*Query) XXX_OneofWrappers() []interface{} {
+	return []interface{}{
+		(*Query_Origin)(nil),
+		(*Query_Peer)(nil),
+		(*Query_Asn)(nil),
+	}
+}
+
+type Answer struct {
+	Id uint64 `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	// Types that are valid to be assigned to Result:
+	//	*Answer_Origin
+	//	*Answer_Peer
+	//	*Answer_Asn
+	Result isAnswer_Result `protobuf_oneof:"result"`
+	// code is the google.golang.org/grpc/codes.Code the query would have
+	// failed with on its own, with error its message
+	Code                 uint32   `protobuf:"varint,5,opt,name=code,proto3" json:"code,omitempty"`
+	Error                string   `protobuf:"bytes,6,opt,name=error,proto3" json:"error,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *Answer) Reset()         { *m = Answer{} }
+func (m *Answer) String() string { return proto.CompactTextString(m) }
+func (*Answer) ProtoMessage()    {}
+func (*Answer) Descriptor() ([]byte, []int) {
+	return fileDescriptor_d08a48cc46004e77, []int{6}
+}
+
+func (m *Answer) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Answer.Unmarshal(m, b)
+}
+func (m *Answer) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Answer.Marshal(b, m, deterministic)
+}
+func (m *Answer) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Answer.Merge(m, src)
+}
+func (m *Answer) XXX_Size() int {
+	return xxx_messageInfo_Answer.Size(m)
+}
+func (m *Answer) XXX_DiscardUnknown() {
+	xxx_messageInfo_Answer.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Answer proto.InternalMessageInfo
+
+func (m *Answer) GetId() uint64 {
+	if m != nil {
+		return m.Id
+	}
+	return 0
+}
+
+type isAnswer_Result interface {
+	isAnswer_Result()
+}
+
+type Answer_Origin struct {
+	Origin *OriginReply `protobuf:"bytes,2,opt,name=origin,proto3,oneof"`
+}
+
+type Answer_Peer struct {
+	Peer *PeerReply `protobuf:"bytes,3,opt,name=peer,proto3,oneof"`
+}
+
+type Answer_Asn struct {
+	Asn *ASNReply `protobuf:"bytes,4,opt,name=asn,proto3,oneof"`
+}
+
+func (*Answer_Origin) isAnswer_Result() {}
+
+func (*Answer_Peer) isAnswer_Result() {}
+
+func (*Answer_Asn) isAnswer_Result() {}
+
+func (m *Answer) GetResult() isAnswer_Result {
+	if m != nil {
+		return m.Result
+	}
+	return nil
+}
+
+func (m *Answer) GetOrigin() *OriginReply {
+	if x, ok := m.GetResult().(*Answer_Origin); ok {
+		return x.Origin
+	}
+	return nil
+}
+
+func (m *Answer) GetPeer() *PeerReply {
+	if x, ok := m.GetResult().(*Answer_Peer); ok {
+		return x.Peer
+	}
+	return nil
+}
+
+func (m *Answer) GetAsn() *ASNReply {
+	if x, ok := m.GetResult().(*Answer_Asn); ok {
+		return x.Asn
+	}
+	return nil
+}
+
+func (m *Answer) GetCode() uint32 {
+	if m != nil {
+		return m.Code
+	}
+	return 0
+}
+
+func (m *Answer) GetError() string {
+	if m != nil {
+		return m.Error
+	}
+	return ""
+}
+
+// XXX_OneofWrappers is for the internal use of the proto package.
+func (*Answer) XXX_OneofWrappers() []interface{} {
+	return []interface{}{
+		(*Answer_Origin)(nil),
+		(*Answer_Peer)(nil),
+		(*Answer_Asn)(nil),
+	}
+}
+
+func init() {
+	proto.RegisterType((*IPRequest)(nil), "cymru.ipasn.IPRequest")
+	proto.RegisterType((*ASNRequest)(nil), "cymru.ipasn.ASNRequest")
+	proto.RegisterType((*OriginReply)(nil), "cymru.ipasn.OriginReply")
+	proto.RegisterType((*PeerReply)(nil), "cymru.ipasn.PeerReply")
+	proto.RegisterType((*ASNReply)(nil), "cymru.ipasn.ASNReply")
+	proto.RegisterType((*Query)(nil), "cymru.ipasn.Query")
+	proto.RegisterType((*Answer)(nil), "cymru.ipasn.Answer")
+}
+
+func init() { proto.RegisterFile("ipasn.proto", fileDescriptor_d08a48cc46004e77) }
+
+var fileDescriptor_d08a48cc46004e77 = []byte{
+	// 469 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xbc, 0x94, 0xcd, 0x8e, 0xd3, 0x30,
+	0x10, 0xc7, 0xeb, 0x7c, 0xb5, 0x99, 0xa8, 0x08, 0x0d, 0xcb, 0x6e, 0x54, 0x10, 0x5a, 0xe5, 0x54,
+	0x24, 0x54, 0xa1, 0xa0, 0xe5, 0xc0, 0xad, 0x3d, 0x75, 0x25, 0x54, 0x4a, 0xf6, 0xc6, 0x05, 0x99,
+	0xc4, 0x54, 0x16, 0x6d, 0xec, 0xb5, 0x13, 0x41, 0x1f, 0x01, 0x21, 0x1e, 0x90, 0x47, 0xe0, 0x2d,
+	0x50, 0xec, 0xb6, 0x9b, 0xd0, 0x2d, 0x47, 0x6e, 0xf9, 0x7b, 0x3c, 0xfe, 0xff, 0xe6, 0xa3, 0x85,
+	0x88, 0x4b, 0xaa, 0xcb, 0x89, 0x54, 0xa2, 0x12, 0x18, 0xe5, 0xdb, 0x8d, 0xaa, 0x27, 0xe6, 0x28,
+	0x79, 0x02, 0xe1, 0xf5, 0x32, 0x63, 0xb7, 0x35, 0xd3, 0x15, 0x3e, 0x00, 0x87, 0xcb, 0x98, 0x5c,
+	0x92, 0x71, 0x98, 0x39, 0x5c, 0x26, 0xcf, 0x00, 0xa6, 0x37, 0x8b, 0x7d, 0xf4, 0x21, 0xb8, 0x54,
+	0x97, 0x26, 0x3c, 0xcc, 0x9a, 0xcf, 0xe4, 0x07, 0x81, 0xe8, 0x9d, 0xe2, 0x2b, 0x5e, 0x66, 0x4c,
+	0xae, 0xb7, 0xc7, 0x37, 0xf0, 0x1c, 0x02, 0xa9, 0xd8, 0x67, 0xfe, 0x2d, 0x76, 0xcc, 0xab, 0x3b,
+	0x85, 0x31, 0xf4, 0x73, 0x51, 0x97, 0x95, 0xda, 0xc6, 0xae, 0x09, 0xec, 0x25, 0x8e, 0x60, 0xa0,
+	0xd8, 0x8a, 0xeb, 0x26, 0xe4, 0x99, 0xd0, 0x41, 0xe3, 0x53, 0x08, 0xe9, 0x7a, 0x2d, 0x72, 0x5a,
+	0xb1, 0x22, 0xf6, 0x4d, 0xf0, 0xee, 0x20, 0xf9, 0x49, 0x20, 0x5c, 0x32, 0xa6, 0x2c, 0xcb, 0x19,
+	0xf8, 0x92, 0x31, 0xa5, 0x63, 0x72, 0xe9, 0x8e, 0x87, 0x99, 0x15, 0xff, 0x95, 0xe7, 0x3b, 0x81,
+	0x81, 0x69, 0xdf, 0xfd, 0xad, 0x69, 0x59, 0x3a, 0xa7, 0x2d, 0xdd, 0x7f, 0x59, 0x7a, 0x7f, 0x59,
+	0xe2, 0x05, 0xf4, 0xa9, 0xfe, 0x58, 0xd2, 0x0d, 0xdb, 0xe1, 0x04, 0x54, 0x2f, 0xe8, 0x86, 0x25,
+	0x05, 0xf8, 0xef, 0x6b, 0xa6, 0xb6, 0x66, 0xc4, 0x85, 0xc1, 0xf0, 0x32, 0x87, 0x17, 0x18, 0x43,
+	0x20, 0xcc, 0x04, 0x2d, 0xc4, 0xbc, 0x97, 0xed, 0x34, 0x9e, 0x81, 0xd7, 0xf4, 0xcc, 0x12, 0xcc,
+	0x7b, 0x99, 0x51, 0x88, 0xb6, 0x8e, 0xc6, 0x79, 0x38, 0xef, 0x99, 0x4a, 0x66, 0x7d, 0xf0, 0x6f,
+	0x9b, 0xc7, 0x93, 0x5f, 0x04, 0x82, 0x69, 0xa9, 0xbf, 0x32, 0x75, 0xe4, 0x93, 0x76, 0x7c, 0xa2,
+	0x34, 0x9e, 0xb4, 0xb6, 0x70, 0xd2, 0x5a, 0xa2, 0x16, 0xc1, 0x8b, 0x16, 0x41, 0x94, 0x9e, 0x77,
+	0x32, 0x0e, 0x83, 0x3e, 0x90, 0x3d, 0xbf, 0x23, 0x8b, 0xd2, 0xc7, 0x9d, 0xcb, 0xfb, 0x29, 0xec,
+	0x80, 0x11, 0xc1, 0xcb, 0x45, 0x61, 0x7b, 0x34, 0xcc, 0xcc, 0x77, 0xb3, 0x2f, 0x4c, 0x29, 0xa1,
+	0xe2, 0xc0, 0x34, 0xce, 0x8a, 0xd9, 0x00, 0x02, 0xc5, 0x74, 0xbd, 0xae, 0xd2, 0xdf, 0x04, 0xfc,
+	0xeb, 0xe5, 0xf4, 0x66, 0x81, 0x6f, 0x20, 0xb0, 0xbc, 0xd8, 0x45, 0x3a, 0xfc, 0x8e, 0x46, 0x27,
+	0x8b, 0xc3, 0xd7, 0xe0, 0x35, 0xe4, 0x27, 0x33, 0x4f, 0x14, 0x89, 0x57, 0xe0, 0x36, 0xd6, 0x17,
+	0xc7, 0x65, 0xd9, 0xbc, 0xfb, 0xeb, 0xc5, 0x2b, 0x08, 0xde, 0x0a, 0xf1, 0xa5, 0x96, 0x88, 0x9d,
+	0x0b, 0x66, 0x17, 0x46, 0x8f, 0xba, 0x49, 0x66, 0x70, 0x63, 0xf2, 0x92, 0xcc, 0xc2, 0x0f, 0xfd,
+	0x95, 0x92, 0x39, 0x95, 0xfc, 0x53, 0x60, 0xfe, 0x33, 0x5e, 0xfd, 0x19, 0x00, 0x61, 0x71, 0x12,
+	0x65, 0x42, 0x04, 0x00, 0x00,
+}
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ context.Context
+var _ grpc.ClientConn
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+const _ = grpc.SupportPackageIsVersion4
+
+// IPASNClient is the client API for IPASN service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://godoc.org/google.golang.org/grpc#ClientConn.NewStream.
+type IPASNClient interface {
+	// Origin looks up the origin ASN of an IP address or prefix
+	Origin(ctx context.Context, in *IPRequest, opts ...grpc.CallOption) (*OriginReply, error)
+	// Peer looks up the peer ASNs of an IP address or prefix
+	Peer(ctx context.Context, in *IPRequest, opts ...grpc.CallOption) (*PeerReply, error)
+	// ASN looks up the description of an ASN
+	ASN(ctx context.Context, in *ASNRequest, opts ...grpc.CallOption) (*ASNReply, error)
+	// Lookup answers a stream of queries, answers are sent as soon as they're
+	// ready so may be out of order, use Query.id to match them up
+	Lookup(ctx context.Context, opts ...grpc.CallOption) (IPASN_LookupClient, error)
+}
+
+type iPASNClient struct {
+	cc *grpc.ClientConn
+}
+
+func NewIPASNClient(cc *grpc.ClientConn) IPASNClient {
+	return &iPASNClient{cc}
+}
+
+func (c *iPASNClient) Origin(ctx context.Context, in *IPRequest, opts ...grpc.CallOption) (*OriginReply, error) {
+	out := new(OriginReply)
+	err := c.cc.Invoke(ctx, "/cymru.ipasn.IPASN/Origin", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *iPASNClient) Peer(ctx context.Context, in *IPRequest, opts ...grpc.CallOption) (*PeerReply, error) {
+	out := new(PeerReply)
+	err := c.cc.Invoke(ctx, "/cymru.ipasn.IPASN/Peer", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *iPASNClient) ASN(ctx context.Context, in *ASNRequest, opts ...grpc.CallOption) (*ASNReply, error) {
+	out := new(ASNReply)
+	err := c.cc.Invoke(ctx, "/cymru.ipasn.IPASN/ASN", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *iPASNClient) Lookup(ctx context.Context, opts ...grpc.CallOption) (IPASN_LookupClient, error) {
+	stream, err := c.cc.NewStream(ctx, &_IPASN_serviceDesc.Streams[0], "/cymru.ipasn.IPASN/Lookup", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &iPASNLookupClient{stream}
+	return x, nil
+}
+
+type IPASN_LookupClient interface {
+	Send(*Query) error
+	Recv() (*Answer, error)
+	grpc.ClientStream
+}
+
+type iPASNLookupClient struct {
+	grpc.ClientStream
+}
+
+func (x *iPASNLookupClient) Send(m *Query) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *iPASNLookupClient) Recv() (*Answer, error) {
+	m := new(Answer)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// IPASNServer is the server API for IPASN service.
+type IPASNServer interface {
+	// Origin looks up the origin ASN of an IP address or prefix
+	Origin(context.Context, *IPRequest) (*OriginReply, error)
+	// Peer looks up the peer ASNs of an IP address or prefix
+	Peer(context.Context, *IPRequest) (*PeerReply, error)
+	// ASN looks up the description of an ASN
+	ASN(context.Context, *ASNRequest) (*ASNReply, error)
+	// Lookup answers a stream of queries, answers are sent as soon as they're
+	// ready so may be out of order, use Query.id to match them up
+	Lookup(IPASN_LookupServer) error
+}
+
+// UnimplementedIPASNServer can be embedded to have forward compatible implementations.
+type UnimplementedIPASNServer struct {
+}
+
+func (*UnimplementedIPASNServer) Origin(ctx context.Context, req *IPRequest) (*OriginReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Origin not implemented")
+}
+func (*UnimplementedIPASNServer) Peer(ctx context.Context, req *IPRequest) (*PeerReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Peer not implemented")
+}
+func (*UnimplementedIPASNServer) ASN(ctx context.Context, req *ASNRequest) (*ASNReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ASN not implemented")
+}
+func (*UnimplementedIPASNServer) Lookup(srv IPASN_LookupServer) error {
+	return status.Errorf(codes.Unimplemented, "method Lookup not implemented")
+}
+
+func RegisterIPASNServer(s *grpc.Server, srv IPASNServer) {
+	s.RegisterService(&_IPASN_serviceDesc, srv)
+}
+
+func _IPASN_Origin_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(IPRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(IPASNServer).Origin(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/cymru.ipasn.IPASN/Origin",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(IPASNServer).Origin(ctx, req.(*IPRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _IPASN_Peer_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(IPRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(IPASNServer).Peer(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/cymru.ipasn.IPASN/Peer",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(IPASNServer).Peer(ctx, req.(*IPRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _IPASN_ASN_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ASNRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(IPASNServer).ASN(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/cymru.ipasn.IPASN/ASN",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(IPASNServer).ASN(ctx, req.(*ASNRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _IPASN_Lookup_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(IPASNServer).Lookup(&iPASNLookupServer{stream})
+}
+
+type IPASN_LookupServer interface {
+	Send(*Answer) error
+	Recv() (*Query, error)
+	grpc.ServerStream
+}
+
+type iPASNLookupServer struct {
+	grpc.ServerStream
+}
+
+func (x *iPASNLookupServer) Send(m *Answer) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *iPASNLookupServer) Recv() (*Query, error) {
+	m := new(Query)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+var _IPASN_serviceDesc = grpc.ServiceDesc{
+	ServiceName: "cymru.ipasn.IPASN",
+	HandlerType: (*IPASNServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Origin",
+			Handler:    _IPASN_Origin_Handler,
+		},
+		{
+			MethodName: "Peer",
+			Handler:    _IPASN_Peer_Handler,
+		},
+		{
+			MethodName: "ASN",
+			Handler:    _IPASN_ASN_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Lookup",
+			Handler:       _IPASN_Lookup_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
+	},
+	Metadata: "ipasn.proto",
+}
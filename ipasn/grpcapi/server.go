@@ -0,0 +1,307 @@
+// Copyright 2019 Freman/Fremnet (Shannon Wynter). All rights reserved.
+
+package grpcapi
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/freman/cymru/ipasn"
+)
+
+// Server implements IPASNServer using an ipasn.Client, register it with
+// RegisterIPASNServer.
+//
+// Errors are gRPC statuses with a code matching the error, eg: NotFound for
+// ipasn.ErrNotFound and FailedPrecondition for ipasn.ErrFiltered.
+//
+// The zero value is ready to use, with a Client that caches answers for an
+// hour.
+type Server struct {
+	// Client does the lookups, it should have a Cache
+	Client *ipasn.Client
+
+	// Timeout limits each lookup, it defaults to 5 seconds
+	Timeout time.Duration
+
+	// Concurrency is how many queries of each Lookup stream are looked up at
+	// once, it defaults to 16
+	Concurrency int
+
+	once   sync.Once
+	client *ipasn.Client
+}
+
+// errBadQuery is returned for queries that can't be parsed
+var errBadQuery = errors.New("not a valid query")
+
+func (s *Server) init() {
+	s.client = s.Client
+	if s.client == nil {
+		s.client = ipasn.NewClient(ipasn.WithCache(ipasn.NewMemoryCache(time.Hour, 100000)))
+	}
+}
+
+func (s *Server) context(ctx context.Context) (context.Context, context.CancelFunc) {
+	s.once.Do(s.init)
+
+	timeout := s.Timeout
+	if timeout <= 0 {
+		timeout = 5 * time.Second
+	}
+
+	return context.WithTimeout(ctx, timeout)
+}
+
+// Origin implements IPASNServer
+func (s *Server) Origin(ctx context.Context, req *IPRequest) (*OriginReply, error) {
+	reply, err := s.origin(ctx, req.GetIp())
+	return reply, statusError(err)
+}
+
+// Peer implements IPASNServer
+func (s *Server) Peer(ctx context.Context, req *IPRequest) (*PeerReply, error) {
+	reply, err := s.peer(ctx, req.GetIp())
+	return reply, statusError(err)
+}
+
+// ASN implements IPASNServer
+func (s *Server) ASN(ctx context.Context, req *ASNRequest) (*ASNReply, error) {
+	reply, err := s.asn(ctx, req.GetAsn())
+	return reply, statusError(err)
+}
+
+// Lookup implements IPASNServer
+func (s *Server) Lookup(stream IPASN_LookupServer) error {
+	concurrency := s.Concurrency
+	if concurrency <= 0 {
+		concurrency = 16
+	}
+
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		sendErr error
+	)
+
+	sem := make(chan struct{}, concurrency)
+	ctx := stream.Context()
+
+	defer wg.Wait()
+
+	for {
+		q, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			return err
+		}
+
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+
+		wg.Add(1)
+
+		go func(q *Query) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+
+			answer := s.answer(ctx, q)
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			if sendErr == nil {
+				sendErr = stream.Send(answer)
+			}
+		}(q)
+	}
+
+	wg.Wait()
+
+	return sendErr
+}
+
+// answer looks up a single streamed query
+func (s *Server) answer(ctx context.Context, q *Query) *Answer {
+	var (
+		answer = &Answer{Id: q.GetId()}
+		err    error
+	)
+
+	switch query := q.GetQuery().(type) {
+	case *Query_Origin:
+		var reply *OriginReply
+		if reply, err = s.origin(ctx, query.Origin); err == nil {
+			answer.Result = &Answer_Origin{Origin: reply}
+		}
+	case *Query_Peer:
+		var reply *PeerReply
+		if reply, err = s.peer(ctx, query.Peer); err == nil {
+			answer.Result = &Answer_Peer{Peer: reply}
+		}
+	case *Query_Asn:
+		var reply *ASNReply
+		if reply, err = s.asn(ctx, query.Asn); err == nil {
+			answer.Result = &Answer_Asn{Asn: reply}
+		}
+	default:
+		err = errBadQuery
+	}
+
+	if err != nil {
+		answer.Code = uint32(Code(err))
+		answer.Error = err.Error()
+	}
+
+	return answer
+}
+
+func (s *Server) origin(ctx context.Context, query string) (*OriginReply, error) {
+	ip := parseIP(query)
+	if ip == nil {
+		return nil, errBadQuery
+	}
+
+	ctx, cancel := s.context(ctx)
+	defer cancel()
+
+	o, err := s.client.Origin(ctx, ip)
+	if err != nil {
+		return nil, err
+	}
+
+	return &OriginReply{
+		Asn:       uint32(o.ASN),
+		Prefix:    prefix(o.Network),
+		Country:   o.Country,
+		Registry:  o.Authority,
+		Allocated: date(o.Updated),
+	}, nil
+}
+
+func (s *Server) peer(ctx context.Context, query string) (*PeerReply, error) {
+	ip := parseIP(query)
+	if ip == nil {
+		return nil, errBadQuery
+	}
+
+	ctx, cancel := s.context(ctx)
+	defer cancel()
+
+	p, err := s.client.Peer(ctx, ip)
+	if err != nil {
+		return nil, err
+	}
+
+	peers := make([]uint32, len(p.ASNs))
+	for i, asn := range p.ASNs {
+		peers[i] = uint32(asn)
+	}
+
+	return &PeerReply{
+		Peers:     peers,
+		Prefix:    prefix(p.Network),
+		Country:   p.Country,
+		Registry:  p.Authority,
+		Allocated: date(p.Updated),
+	}, nil
+}
+
+func (s *Server) asn(ctx context.Context, asn uint32) (*ASNReply, error) {
+	if asn == 0 {
+		return nil, errBadQuery
+	}
+
+	ctx, cancel := s.context(ctx)
+	defer cancel()
+
+	a, err := s.client.ASN(ctx, int(asn))
+	if err != nil {
+		return nil, err
+	}
+
+	return &ASNReply{
+		Asn:       uint32(a.ASN),
+		Country:   a.Country,
+		Registry:  a.Authority,
+		Allocated: date(a.Updated),
+		AsName:    a.Description,
+	}, nil
+}
+
+// Code maps errors returned by ipasn.Client to gRPC status codes
+func Code(err error) codes.Code {
+	var netErr net.Error
+
+	switch {
+	case err == nil:
+		return codes.OK
+	case err == errBadQuery:
+		return codes.InvalidArgument
+	case errors.Is(err, ipasn.ErrNotFound):
+		return codes.NotFound
+	case errors.Is(err, ipasn.ErrFiltered):
+		return codes.FailedPrecondition
+	case errors.Is(err, ipasn.ErrUnsupported):
+		return codes.Unimplemented
+	case errors.Is(err, context.Canceled):
+		return codes.Canceled
+	case errors.Is(err, context.DeadlineExceeded), errors.As(err, &netErr) && netErr.Timeout():
+		return codes.DeadlineExceeded
+	}
+
+	return codes.Unavailable
+}
+
+func statusError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	return status.Error(Code(err), err.Error())
+}
+
+// parseIP accepts an address or a prefix, which is looked up by its network
+// address
+func parseIP(s string) net.IP {
+	if ip := net.ParseIP(s); ip != nil {
+		return ip
+	}
+
+	if _, network, err := net.ParseCIDR(s); err == nil {
+		return network.IP
+	}
+
+	return nil
+}
+
+func prefix(n *net.IPNet) string {
+	if n == nil {
+		return ""
+	}
+
+	return n.String()
+}
+
+func date(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+
+	return t.Format("2006-01-02")
+}
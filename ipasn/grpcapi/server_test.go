@@ -0,0 +1,165 @@
+// Copyright 2019 Freman/Fremnet (Shannon Wynter). All rights reserved.
+
+package grpcapi_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	"github.com/freman/cymru/ipasn"
+	"github.com/freman/cymru/ipasn/grpcapi"
+	"github.com/freman/cymru/ipasn/ipasntest"
+)
+
+func newClient(t *testing.T, s *grpcapi.Server) (grpcapi.IPASNClient, func()) {
+	l := bufconn.Listen(1 << 16)
+	gs := grpc.NewServer()
+	grpcapi.RegisterIPASNServer(gs, s)
+
+	go func() {
+		_ = gs.Serve(l)
+	}()
+
+	conn, err := grpc.Dial("bufconn",
+		grpc.WithInsecure(),
+		grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) {
+			return l.Dial()
+		}),
+	)
+	require.NoError(t, err)
+
+	return grpcapi.NewIPASNClient(conn), func() {
+		conn.Close()
+		gs.Stop()
+	}
+}
+
+func TestServer(t *testing.T) {
+	t.Parallel()
+
+	resolver := ipasntest.NewResolver(ipasntest.Golden())
+	resolver.FailQuery(ipasntest.OriginName(net.ParseIP("216.90.108.0")), errors.New("boom"))
+
+	client, done := newClient(t, &grpcapi.Server{
+		Client:  ipasn.NewClient(ipasn.WithResolver(resolver)),
+		Timeout: 50 * time.Millisecond,
+	})
+	defer done()
+
+	ctx := context.Background()
+
+	origin, err := client.Origin(ctx, &grpcapi.IPRequest{Ip: "216.90.108.31"})
+	require.NoError(t, err)
+	require.Equal(t, uint32(23028), origin.Asn)
+	require.Equal(t, "216.90.108.0/24", origin.Prefix)
+
+	origin, err = client.Origin(ctx, &grpcapi.IPRequest{Ip: "2001:4860::/32"})
+	require.NoError(t, err)
+	require.Equal(t, uint32(15169), origin.Asn)
+
+	peer, err := client.Peer(ctx, &grpcapi.IPRequest{Ip: "216.90.108.31"})
+	require.NoError(t, err)
+	require.Equal(t, []uint32{701, 1239, 3549, 3561, 7132}, peer.Peers)
+
+	asn, err := client.ASN(ctx, &grpcapi.ASNRequest{Asn: 15169})
+	require.NoError(t, err)
+	require.Equal(t, "GOOGLE - Google LLC, US", asn.AsName)
+	require.Equal(t, "2000-03-30", asn.Allocated)
+
+	tests := []struct {
+		ip   string
+		code codes.Code
+	}{
+		{"192.168.0.1", codes.FailedPrecondition},
+		{"127.0.0.1", codes.FailedPrecondition},
+		{"1.1.1.1", codes.NotFound},
+		{"banana", codes.InvalidArgument},
+		{"216.90.108.0", codes.Unavailable},
+	}
+
+	for _, test := range tests {
+		_, err := client.Origin(ctx, &grpcapi.IPRequest{Ip: test.ip})
+		require.Equal(t, test.code, status.Code(err), test.ip)
+	}
+
+	_, err = client.ASN(ctx, &grpcapi.ASNRequest{})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	resolver.SetLatency(time.Hour)
+
+	_, err = client.Origin(ctx, &grpcapi.IPRequest{Ip: "2001:4860::1"})
+	require.Equal(t, codes.DeadlineExceeded, status.Code(err))
+}
+
+func TestLookup(t *testing.T) {
+	t.Parallel()
+
+	client, done := newClient(t, &grpcapi.Server{
+		Client:      ipasn.NewClient(ipasn.WithResolver(ipasntest.NewResolver(ipasntest.Golden()))),
+		Concurrency: 2,
+	})
+	defer done()
+
+	stream, err := client.Lookup(context.Background())
+	require.NoError(t, err)
+
+	queries := []*grpcapi.Query{
+		{Id: 1, Query: &grpcapi.Query_Origin{Origin: "216.90.108.31"}},
+		{Id: 2, Query: &grpcapi.Query_Peer{Peer: "216.90.108.31"}},
+		{Id: 3, Query: &grpcapi.Query_Asn{Asn: 15169}},
+		{Id: 4, Query: &grpcapi.Query_Origin{Origin: "10.0.0.1"}},
+		{Id: 5},
+	}
+
+	for _, q := range queries {
+		require.NoError(t, stream.Send(q))
+	}
+
+	require.NoError(t, stream.CloseSend())
+
+	answers := make(map[uint64]*grpcapi.Answer)
+
+	for {
+		answer, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+
+		require.NoError(t, err)
+
+		answers[answer.Id] = answer
+	}
+
+	require.Len(t, answers, len(queries))
+	require.Equal(t, uint32(23028), answers[1].GetOrigin().GetAsn())
+	require.Equal(t, []uint32{701, 1239, 3549, 3561, 7132}, answers[2].GetPeer().GetPeers())
+	require.Equal(t, "GOOGLE - Google LLC, US", answers[3].GetAsn().GetAsName())
+	require.Equal(t, uint32(codes.FailedPrecondition), answers[4].Code)
+	require.Equal(t, "IP is a private address", answers[4].Error)
+	require.Nil(t, answers[4].Result)
+	require.Equal(t, uint32(codes.InvalidArgument), answers[5].Code)
+}
+
+func TestCode(t *testing.T) {
+	t.Parallel()
+
+	require.Equal(t, codes.OK, grpcapi.Code(nil))
+	require.Equal(t, codes.NotFound, grpcapi.Code(ipasn.ErrNotFound))
+	require.Equal(t, codes.NotFound, grpcapi.Code(&ipasn.LookupError{Err: &net.DNSError{IsNotFound: true}}))
+	require.Equal(t, codes.DeadlineExceeded, grpcapi.Code(&ipasn.LookupError{Err: &net.DNSError{IsTimeout: true}}))
+	require.Equal(t, codes.Unavailable, grpcapi.Code(&ipasn.LookupError{Err: &net.DNSError{IsTemporary: true}}))
+	require.Equal(t, codes.Canceled, grpcapi.Code(context.Canceled))
+	require.Equal(t, codes.FailedPrecondition, grpcapi.Code(ipasn.ErrIPIsMulticast))
+	require.Equal(t, codes.Unimplemented, grpcapi.Code(ipasn.ErrUnsupported))
+	require.Equal(t, codes.Unavailable, grpcapi.Code(ipasn.ErrMalformed))
+}
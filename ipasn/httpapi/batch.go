@@ -0,0 +1,84 @@
+// Copyright 2019 Freman/Fremnet (Shannon Wynter). All rights reserved.
+
+package httpapi
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+)
+
+// batchConcurrency is how many queries of a batch are looked up at once
+const batchConcurrency = 16
+
+// Query is an entry in a batch request
+type Query struct {
+	Type  string `json:"type"`
+	Query string `json:"query"`
+}
+
+// Answer is an entry in a batch response, in the same order as the request,
+// Status is the status code the query would have had on its own
+type Answer struct {
+	Query
+	Status int     `json:"status"`
+	Result *Result `json:"result,omitempty"`
+	Error  string  `json:"error,omitempty"`
+}
+
+func (h *Handler) serveBatch(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Cache-Control", "no-store")
+
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", "POST")
+		writeError(w, http.StatusMethodNotAllowed, errors.New(http.StatusText(http.StatusMethodNotAllowed)))
+
+		return
+	}
+
+	maxBatch := h.MaxBatch
+	if maxBatch <= 0 {
+		maxBatch = 1000
+	}
+
+	var queries []Query
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, int64(maxBatch)*256)).Decode(&queries); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid batch: %w", err))
+		return
+	}
+
+	if len(queries) > maxBatch {
+		writeError(w, http.StatusRequestEntityTooLarge, fmt.Errorf("batches are limited to %d queries", maxBatch))
+		return
+	}
+
+	answers := make([]Answer, len(queries))
+	sem := make(chan struct{}, batchConcurrency)
+
+	var wg sync.WaitGroup
+
+	for i, q := range queries {
+		wg.Add(1)
+		sem <- struct{}{}
+
+		go func(i int, q Query) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+
+			res, err := h.lookup(r.Context(), q.Type, q.Query)
+
+			answers[i] = Answer{Query: q, Status: statusOf(err), Result: res}
+			if err != nil {
+				answers[i].Error = err.Error()
+			}
+		}(i, q)
+	}
+
+	wg.Wait()
+
+	writeJSON(w, http.StatusOK, answers)
+}
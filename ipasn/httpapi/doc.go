@@ -0,0 +1,5 @@
+// Copyright 2019 Freman/Fremnet (Shannon Wynter). All rights reserved.
+
+// Package httpapi implements an HTTP/JSON API for ipasn lookups so that they can be used without
+// embedding this library, see cmd/cymru-server.
+package httpapi
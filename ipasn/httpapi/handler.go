@@ -0,0 +1,284 @@
+// Copyright 2019 Freman/Fremnet (Shannon Wynter). All rights reserved.
+
+package httpapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/freman/cymru/ipasn"
+)
+
+// Handler serves the API:
+//
+//	GET  /origin/{ip}  the origin of an IP address or prefix
+//	GET  /peer/{ip}    the peers of an IP address or prefix
+//	GET  /asn/{asn}    the description of an ASN, eg: 23028 or AS23028
+//	POST /batch        a JSON array of {"type": "origin", "query": "1.1.1.1"}
+//
+// Answers are JSON, errors are {"error": "..."} with a status code matching
+// the error, eg: 404 for ipasn.ErrNotFound and 422 for ipasn.ErrFiltered.
+//
+// The zero value is ready to use, with a Client that caches answers for an
+// hour.
+type Handler struct {
+	// Client does the lookups, it should have a Cache
+	Client *ipasn.Client
+
+	// MaxAge caps the Cache-Control max-age of answers, which is how much
+	// longer the Client's Cache holds them if it's an ipasn.TTLCache, eg:
+	// ipasn.MemoryCache. That's the lifetime of the local cache, not the TTL
+	// of the DNS records, which Go's resolver doesn't expose. Answers that
+	// aren't cached get MaxAge itself. It defaults to an hour.
+	MaxAge time.Duration
+
+	// ErrorMaxAge is the Cache-Control max-age of 4xx errors, eg: 404 for
+	// names that don't exist, which are never cached. It defaults to a minute.
+	ErrorMaxAge time.Duration
+
+	// Timeout limits each lookup, it defaults to 5 seconds
+	Timeout time.Duration
+
+	// MaxBatch is the most queries in a batch, it defaults to 1000
+	MaxBatch int
+
+	once   sync.Once
+	client *ipasn.Client
+	mux    *http.ServeMux
+}
+
+// Result is the JSON answer to a query, only the fields relevant to the
+// query type are set
+type Result struct {
+	ASN       int    `json:"asn,omitempty"`
+	Peers     []int  `json:"peers,omitempty"`
+	Prefix    string `json:"prefix,omitempty"`
+	Country   string `json:"country,omitempty"`
+	Registry  string `json:"registry,omitempty"`
+	Allocated string `json:"allocated,omitempty"`
+	ASName    string `json:"as_name,omitempty"`
+}
+
+// errBadQuery is returned for queries that can't be parsed
+var errBadQuery = errors.New("not a valid query")
+
+func (h *Handler) init() {
+	h.client = h.Client
+	if h.client == nil {
+		h.client = ipasn.NewClient(ipasn.WithCache(ipasn.NewMemoryCache(time.Hour, 100000)))
+	}
+
+	h.mux = http.NewServeMux()
+	h.mux.HandleFunc("/batch", h.serveBatch)
+
+	for _, typ := range []string{"origin", "peer", "asn"} {
+		typ := typ
+
+		h.mux.HandleFunc("/"+typ+"/", func(w http.ResponseWriter, r *http.Request) {
+			h.serveQuery(w, r, typ, strings.TrimPrefix(r.URL.Path, "/"+typ+"/"))
+		})
+	}
+}
+
+// ServeHTTP implements http.Handler
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.once.Do(h.init)
+	h.mux.ServeHTTP(w, r)
+}
+
+func (h *Handler) serveQuery(w http.ResponseWriter, r *http.Request, typ, query string) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		writeError(w, http.StatusMethodNotAllowed, errors.New(http.StatusText(http.StatusMethodNotAllowed)))
+
+		return
+	}
+
+	ttl := time.Duration(-1)
+	res, err := h.lookup(r.Context(), typ, query, ipasn.ReportTTL(&ttl))
+	status := statusOf(err)
+
+	maxAge := h.maxAge()
+
+	switch {
+	case err != nil:
+		maxAge = h.errorMaxAge()
+	case ttl >= 0 && ttl < maxAge:
+		maxAge = ttl
+	}
+
+	if status < 500 {
+		w.Header().Set("Cache-Control", "public, max-age="+strconv.Itoa(int(maxAge/time.Second)))
+	} else {
+		w.Header().Set("Cache-Control", "no-store")
+	}
+
+	if err != nil {
+		writeError(w, status, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, res)
+}
+
+// lookup does a single query of the given type
+func (h *Handler) lookup(ctx context.Context, typ, query string, opts ...ipasn.CallOption) (*Result, error) {
+	timeout := h.Timeout
+	if timeout <= 0 {
+		timeout = 5 * time.Second
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	switch typ {
+	case "origin":
+		ip := parseIP(query)
+		if ip == nil {
+			return nil, errBadQuery
+		}
+
+		o, err := h.client.Origin(ctx, ip, opts...)
+		if err != nil {
+			return nil, err
+		}
+
+		return &Result{
+			ASN:       o.ASN,
+			Prefix:    prefix(o.Network),
+			Country:   o.Country,
+			Registry:  o.Authority,
+			Allocated: date(o.Updated),
+		}, nil
+	case "peer":
+		ip := parseIP(query)
+		if ip == nil {
+			return nil, errBadQuery
+		}
+
+		p, err := h.client.Peer(ctx, ip, opts...)
+		if err != nil {
+			return nil, err
+		}
+
+		return &Result{
+			Peers:     p.ASNs,
+			Prefix:    prefix(p.Network),
+			Country:   p.Country,
+			Registry:  p.Authority,
+			Allocated: date(p.Updated),
+		}, nil
+	case "asn":
+		if len(query) > 2 && strings.EqualFold(query[:2], "AS") {
+			query = query[2:]
+		}
+
+		asn, err := strconv.Atoi(query)
+		if err != nil || asn <= 0 {
+			return nil, errBadQuery
+		}
+
+		a, err := h.client.ASN(ctx, asn, opts...)
+		if err != nil {
+			return nil, err
+		}
+
+		return &Result{
+			ASN:       a.ASN,
+			Country:   a.Country,
+			Registry:  a.Authority,
+			Allocated: date(a.Updated),
+			ASName:    a.Description,
+		}, nil
+	}
+
+	return nil, errBadQuery
+}
+
+func (h *Handler) errorMaxAge() time.Duration {
+	if h.ErrorMaxAge <= 0 {
+		return time.Minute
+	}
+
+	return h.ErrorMaxAge
+}
+
+func (h *Handler) maxAge() time.Duration {
+	if h.MaxAge <= 0 {
+		return time.Hour
+	}
+
+	return h.MaxAge
+}
+
+// statusOf maps errors to HTTP status codes
+func statusOf(err error) int {
+	var netErr net.Error
+
+	switch {
+	case err == nil:
+		return http.StatusOK
+	case err == errBadQuery:
+		return http.StatusBadRequest
+	case errors.Is(err, ipasn.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, ipasn.ErrFiltered):
+		return http.StatusUnprocessableEntity
+	case errors.Is(err, ipasn.ErrUnsupported):
+		return http.StatusNotImplemented
+	case errors.Is(err, context.DeadlineExceeded), errors.As(err, &netErr) && netErr.Timeout():
+		return http.StatusGatewayTimeout
+	}
+
+	return http.StatusBadGateway
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, struct {
+		Error string `json:"error"`
+	}{err.Error()})
+}
+
+// parseIP accepts an address or a prefix, which is looked up by its network
+// address
+func parseIP(s string) net.IP {
+	if ip := net.ParseIP(s); ip != nil {
+		return ip
+	}
+
+	if _, network, err := net.ParseCIDR(s); err == nil {
+		return network.IP
+	}
+
+	return nil
+}
+
+func prefix(n *net.IPNet) string {
+	if n == nil {
+		return ""
+	}
+
+	return n.String()
+}
+
+func date(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+
+	return t.Format("2006-01-02")
+}
@@ -0,0 +1,210 @@
+// Copyright 2019 Freman/Fremnet (Shannon Wynter). All rights reserved.
+
+package httpapi_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/freman/cymru/ipasn"
+	"github.com/freman/cymru/ipasn/httpapi"
+	"github.com/freman/cymru/ipasn/ipasntest"
+)
+
+func newHandler() (*httpapi.Handler, *ipasntest.Resolver) {
+	resolver := ipasntest.NewResolver(ipasntest.Golden())
+
+	return &httpapi.Handler{
+		Client:  ipasn.NewClient(ipasn.WithResolver(resolver)),
+		MaxAge:  4 * time.Hour,
+		Timeout: 50 * time.Millisecond,
+	}, resolver
+}
+
+func TestHandler(t *testing.T) {
+	t.Parallel()
+
+	h, resolver := newHandler()
+	resolver.FailQuery(ipasntest.OriginName(net.ParseIP("216.90.108.0")), errors.New("boom"))
+
+	tests := []struct {
+		path   string
+		status int
+		cache  string
+		body   string
+	}{
+		{"/origin/216.90.108.31", http.StatusOK, "public, max-age=14400", `"asn":23028,"prefix":"216.90.108.0/24"`},
+		{"/origin/2001:4860::/32", http.StatusOK, "public, max-age=14400", `"asn":15169,"prefix":"2001:4860::/32"`},
+		{"/peer/216.90.108.31", http.StatusOK, "public, max-age=14400", `"peers":[701,1239,3549,3561,7132]`},
+		{"/asn/AS15169", http.StatusOK, "public, max-age=14400", `"as_name":"GOOGLE - Google LLC, US"`},
+		{"/asn/15169", http.StatusOK, "public, max-age=14400", `"allocated":"2000-03-30"`},
+		{"/origin/192.168.0.1", http.StatusUnprocessableEntity, "public, max-age=60", `"error":"IP is a private address"`},
+		{"/origin/127.0.0.1", http.StatusUnprocessableEntity, "public, max-age=60", `"error"`},
+		{"/origin/1.1.1.1", http.StatusNotFound, "public, max-age=60", `"error"`},
+		{"/asn/4321", http.StatusNotFound, "public, max-age=60", `"error"`},
+		{"/origin/banana", http.StatusBadRequest, "public, max-age=60", `"error":"not a valid query"`},
+		{"/asn/AS", http.StatusBadRequest, "public, max-age=60", `"error"`},
+		{"/origin/216.90.108.0", http.StatusBadGateway, "no-store", `"error":"lookup 0.108.90.216.origin.asn.cymru.com.: boom"`},
+		{"/nothing", http.StatusNotFound, "", ``},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.path, func(t *testing.T) {
+			t.Parallel()
+
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, test.path, nil))
+
+			require.Equal(t, test.status, rec.Code, rec.Body.String())
+			require.Equal(t, test.cache, rec.Header().Get("Cache-Control"))
+			require.Contains(t, rec.Body.String(), test.body)
+		})
+	}
+}
+
+// expiringCache is an ipasn.TTLCache whose entries always have ttl left
+type expiringCache struct {
+	ipasn.Cache
+	ttl time.Duration
+}
+
+func (e expiringCache) GetTTL(name string) ([]string, time.Duration, bool) {
+	vals, ok := e.Get(name)
+	return vals, e.ttl, ok
+}
+
+// plainCache hides the ipasn.TTLCache of the Cache it wraps
+type plainCache struct {
+	ipasn.Cache
+}
+
+func TestHandlerMaxAge(t *testing.T) {
+	t.Parallel()
+
+	resolver := ipasntest.NewResolver(ipasntest.Golden())
+
+	tests := []struct {
+		name  string
+		cache ipasn.Cache
+		path  string
+		want  string
+	}{
+		{"expiring", expiringCache{ipasn.NewMemoryCache(time.Hour, 0), 90 * time.Second}, "/origin/216.90.108.31", "public, max-age=90"},
+		{"capped", ipasn.NewMemoryCache(24*time.Hour, 0), "/asn/15169", "public, max-age=14400"},
+		{"no ttl", plainCache{ipasn.NewMemoryCache(time.Hour, 0)}, "/asn/15169", "public, max-age=14400"},
+		{"error", expiringCache{ipasn.NewMemoryCache(time.Hour, 0), 90 * time.Second}, "/origin/192.168.0.1", "public, max-age=30"},
+		{"not found", ipasn.NewMemoryCache(time.Hour, 0), "/origin/1.1.1.1", "public, max-age=30"},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			h := &httpapi.Handler{
+				Client:      ipasn.NewClient(ipasn.WithResolver(resolver), ipasn.WithCache(test.cache)),
+				MaxAge:      4 * time.Hour,
+				ErrorMaxAge: 30 * time.Second,
+			}
+
+			// Twice, so the second is answered from the cache
+			for i := 0; i < 2; i++ {
+				rec := httptest.NewRecorder()
+				h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, test.path, nil))
+				require.Equal(t, test.want, rec.Header().Get("Cache-Control"))
+			}
+		})
+	}
+}
+
+func TestHandlerErrors(t *testing.T) {
+	t.Parallel()
+
+	h, resolver := newHandler()
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/origin/216.90.108.31", nil))
+	require.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+	require.Equal(t, "GET, HEAD", rec.Header().Get("Allow"))
+
+	resolver.SetLatency(time.Hour)
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/origin/216.90.108.31", nil))
+	require.Equal(t, http.StatusGatewayTimeout, rec.Code)
+	require.Equal(t, "no-store", rec.Header().Get("Cache-Control"))
+}
+
+func TestBatch(t *testing.T) {
+	t.Parallel()
+
+	h, _ := newHandler()
+
+	body := `[
+		{"type": "origin", "query": "216.90.108.31"},
+		{"type": "peer", "query": "216.90.108.31"},
+		{"type": "asn", "query": "AS15169"},
+		{"type": "origin", "query": "10.0.0.1"},
+		{"type": "prefix", "query": "216.90.108.31"}
+	]`
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/batch", strings.NewReader(body)))
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Equal(t, "no-store", rec.Header().Get("Cache-Control"))
+
+	var answers []httpapi.Answer
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &answers))
+	require.Len(t, answers, 5)
+
+	require.Equal(t, http.StatusOK, answers[0].Status)
+	require.Equal(t, 23028, answers[0].Result.ASN)
+	require.Equal(t, []int{701, 1239, 3549, 3561, 7132}, answers[1].Result.Peers)
+	require.Equal(t, "GOOGLE - Google LLC, US", answers[2].Result.ASName)
+	require.Equal(t, http.StatusUnprocessableEntity, answers[3].Status)
+	require.Equal(t, "IP is a private address", answers[3].Error)
+	require.Equal(t, http.StatusBadRequest, answers[4].Status)
+	require.Equal(t, "prefix", answers[4].Type)
+}
+
+func TestBatchErrors(t *testing.T) {
+	t.Parallel()
+
+	h, _ := newHandler()
+	h.MaxBatch = 2
+
+	tests := []struct {
+		name   string
+		method string
+		body   string
+		status int
+	}{
+		{"method", http.MethodGet, ``, http.StatusMethodNotAllowed},
+		{"invalid", http.MethodPost, `{"type": "origin"}`, http.StatusBadRequest},
+		{"too many", http.MethodPost, `[{}, {}, {}]`, http.StatusRequestEntityTooLarge},
+		{"empty", http.MethodPost, `[]`, http.StatusOK},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, httptest.NewRequest(test.method, "/batch", strings.NewReader(test.body)))
+			require.Equal(t, test.status, rec.Code, rec.Body.String())
+		})
+	}
+}
@@ -28,7 +28,18 @@ type Resolver interface {
 // of networks returned by DefaultPrivateNetworks() for your convenience you can
 // configure this as NoPrivateNetworks()
 //
-// You can override either of these properties at any time.
+// Results can be cached by setting Cache (eg: NewMemoryCache) and Strict will
+// cause malformed results to return ErrMalformed instead of partial results.
+// Zones can point the Client at a mirror of the Team Cymru zones, or Provider
+// at another service entirely, with Fallbacks tried in turn if it fails.
+// OnLookup, if set, is told about every lookup, eg: for metrics, and Tracer
+// can wrap each lookup in a span.
+//
+// NewClient offers the same configuration through functional options, and
+// each call accepts CallOptions to vary the behaviour for just that call.
+//
+// The Client never modifies itself so it is safe for concurrent use, as long
+// as the properties aren't changed while it's in use.
 //
 // The original Team Cymru documentation specifies that a prefix (eg: 216.90.108)
 // only send 108.90.216 but for simplicity, this package doesn't omit the leading
@@ -36,101 +47,224 @@ type Resolver interface {
 type Client struct {
 	Resolver        Resolver
 	PrivateNetworks NetworkFilter
+	Cache           Cache
+	Strict          bool
+	Zones           Zones
+	Provider        Provider
+	Fallbacks       []Provider
+	OnLookup        func(LookupEvent)
+	Tracer          Tracer
 }
 
 const dateFormat = `2006-01-02`
 
+//nolint:gochecknoglobals
+var (
+	defaultResolver        Resolver      = net.DefaultResolver
+	defaultPrivateNetworks NetworkFilter = DefaultPrivateNetworks()
+)
+
 // Origin is used to map an IPv4 or IPv6 address or prefix to a corresponding
 // BGP Origin ASN.
-func (c *Client) Origin(ctx context.Context, ip net.IP) (o OriginInfo, err error) {
-	if err := c.checkInputIP(ip); err != nil {
-		return o, err
-	}
+func (c *Client) Origin(ctx context.Context, ip net.IP, opts ...CallOption) (o OriginInfo, err error) {
+	co := c.callOptions(opts)
+	ev := LookupEvent{Zone: zoneOf(ip, "origin")}
+	start := time.Now()
 
-	dat, err := c.lookupTXT(ctx, asLookupString(ip, "origin"))
-	if err != nil {
+	ctx, finish := c.trace(ctx, ev)
+	defer func() {
+		if err == nil {
+			ev.ASN, ev.Network = o.ASN, o.Network
+		}
+
+		c.observe(&ev, start, finish, err)
+	}()
+
+	if err := c.checkInputIP(ip, co); err != nil {
 		return o, err
 	}
 
-	if len(dat) == 5 {
-		o.ASN, _ = strconv.Atoi(dat[0])
-		_, o.Network, _ = net.ParseCIDR(dat[1])
-		o.Country = dat[2]
-		o.Authority = dat[3]
-		o.Updated, _ = time.Parse(dateFormat, dat[4])
+	for _, p := range co.providers {
+		if o, err = c.origin(ctx, p, ip, co, &ev); !failover(ctx, err) {
+			break
+		}
 	}
 
-	return o, nil
+	return o, err
 }
 
 // Peer is used to map an IP address or prefix to the possible BGP peer ASNs that
 // are one AS hop away from the BGP Origin ASN's prefix.
-func (c *Client) Peer(ctx context.Context, ip net.IP) (p PeerInfo, err error) {
-	if err := c.checkInputIP(ip); err != nil {
-		return p, err
-	}
+func (c *Client) Peer(ctx context.Context, ip net.IP, opts ...CallOption) (p PeerInfo, err error) {
+	co := c.callOptions(opts)
+	ev := LookupEvent{Zone: zoneOf(ip, "peer")}
+	start := time.Now()
 
-	dat, err := c.lookupTXT(ctx, asLookupString(ip, "peer"))
-	if err != nil {
+	ctx, finish := c.trace(ctx, ev)
+	defer func() {
+		if err == nil {
+			ev.ASN, ev.Network = 0, p.Network
+		}
+
+		c.observe(&ev, start, finish, err)
+	}()
+
+	if err := c.checkInputIP(ip, co); err != nil {
 		return p, err
 	}
 
-	if len(dat) == 5 {
-		p.ASNs = parseASNList(dat[0])
-		_, p.Network, _ = net.ParseCIDR(dat[1])
-		p.Country = dat[2]
-		p.Authority = dat[3]
-		p.Updated, _ = time.Parse(dateFormat, dat[4])
+	for _, rp := range co.providers {
+		if p, err = c.peer(ctx, rp, ip, co, &ev); !failover(ctx, err) {
+			break
+		}
 	}
 
-	return p, nil
+	return p, err
 }
 
 // ASN is used to determine the AS description of a given BGP ASN.
 // Notably this function returns the Description of the AS but not the network.
-func (c *Client) ASN(ctx context.Context, asn int) (a ASNInfo, err error) {
-	dat, err := c.lookupTXT(ctx, "AS"+strconv.Itoa(asn)+".asn.cymru.com.")
+func (c *Client) ASN(ctx context.Context, asn int, opts ...CallOption) (a ASNInfo, err error) {
+	co := c.callOptions(opts)
+	ev := LookupEvent{Zone: "asn"}
+	start := time.Now()
+
+	ctx, finish := c.trace(ctx, ev)
+	defer func() {
+		if err == nil {
+			ev.ASN, ev.Network = a.ASN, nil
+		}
+
+		c.observe(&ev, start, finish, err)
+	}()
+
+	for _, p := range co.providers {
+		if a, err = c.asn(ctx, p, asn, co, &ev); !failover(ctx, err) {
+			break
+		}
+	}
+
+	return a, err
+}
+
+// origin looks up the origin of ip from a single provider
+func (c *Client) origin(ctx context.Context, p Provider, ip net.IP, co callOptions, ev *LookupEvent) (OriginInfo, error) {
+	name := p.OriginName(ip)
+	if name == "" {
+		return OriginInfo{}, ErrUnsupported
+	}
+
+	dat, err := c.lookupTXT(ctx, name, co, ev)
 	if err != nil {
-		return a, err
+		return OriginInfo{}, err
 	}
 
-	if len(dat) >= 5 {
-		a.ASN, _ = strconv.Atoi(dat[0])
-		a.Country = dat[1]
-		a.Authority = dat[2]
-		a.Updated, _ = time.Parse(dateFormat, dat[3])
-		a.Description = strings.Join(dat[4:], " | ")
+	o, err := p.ParseOrigin(ip, dat)
+	if err = co.parsed(err); err != nil {
+		return OriginInfo{}, err
 	}
 
-	return a, nil
+	return o, nil
+}
+
+// peer looks up the peers of ip from a single provider
+func (c *Client) peer(ctx context.Context, p Provider, ip net.IP, co callOptions, ev *LookupEvent) (PeerInfo, error) {
+	name := p.PeerName(ip)
+	if name == "" {
+		return PeerInfo{}, ErrUnsupported
+	}
+
+	dat, err := c.lookupTXT(ctx, name, co, ev)
+	if err != nil {
+		return PeerInfo{}, err
+	}
+
+	pi, err := p.ParsePeer(ip, dat)
+	if err = co.parsed(err); err != nil {
+		return PeerInfo{}, err
+	}
+
+	return pi, nil
 }
 
-// lookupTXT checks there's a resolver (or makes one available) before
-// simply forwarding the call to the lookupTXT
-func (c *Client) lookupTXT(ctx context.Context, name string) ([]string, error) {
-	if c.Resolver == nil {
-		c.Resolver = net.DefaultResolver
+// asn looks up the description of asn from a single provider
+func (c *Client) asn(ctx context.Context, p Provider, asn int, co callOptions, ev *LookupEvent) (ASNInfo, error) {
+	name := p.ASNName(asn)
+	if name == "" {
+		return ASNInfo{}, ErrUnsupported
 	}
 
-	vals, err := c.Resolver.LookupTXT(ctx, name)
+	dat, err := c.lookupTXT(ctx, name, co, ev)
 	if err != nil {
-		return nil, err
+		return ASNInfo{}, err
+	}
+
+	a, err := p.ParseASN(asn, dat)
+	if err = co.parsed(err); err != nil {
+		return ASNInfo{}, err
+	}
+
+	return a, nil
+}
+
+// failover reports whether the next provider should be tried after err,
+// there's no point once the caller has given up
+func failover(ctx context.Context, err error) bool {
+	return err != nil && ctx.Err() == nil
+}
+
+// lookupTXT consults the cache, if there is one, before forwarding the call
+// to the resolver, recording what happened in ev
+func (c *Client) lookupTXT(ctx context.Context, name string, co callOptions, ev *LookupEvent) ([]string, error) {
+	useCache := c.Cache != nil && !co.bypassCache
+
+	ev.Name = name
+
+	vals, cached := []string(nil), false
+	if useCache {
+		vals, cached = c.Cache.Get(name)
+
+		ev.Cache = CacheMiss
+		if cached {
+			ev.Cache = CacheHit
+		}
+	}
+
+	if !cached {
+		var err error
+
+		vals, err = co.resolver.LookupTXT(ctx, name)
+		if err != nil {
+			return nil, &LookupError{Name: name, Err: err}
+		}
 	}
 
 	if len(vals) == 0 {
 		return nil, ErrNotFound
 	}
 
-	return strings.Split(vals[0], " | "), nil
+	if useCache && !cached {
+		c.Cache.Set(name, vals)
+	}
+
+	if useCache && co.ttl != nil {
+		if tc, ok := c.Cache.(TTLCache); ok {
+			if _, ttl, ok := tc.GetTTL(name); ok {
+				*co.ttl = ttl
+			}
+		}
+	}
+
+	return vals, nil
 }
 
 // isPrivateNetwork checks if the given ip falls in the list of private
 // networks
 //
-// Optionally builds that list list from the default
+// Falls back to the default list if the client doesn't have one
 func (c *Client) isPrivateNetwork(ip net.IP) bool {
 	if c.PrivateNetworks == nil {
-		c.PrivateNetworks = DefaultPrivateNetworks()
+		return defaultPrivateNetworks.Contains(ip)
 	}
 
 	return c.PrivateNetworks.Contains(ip)
@@ -139,7 +273,7 @@ func (c *Client) isPrivateNetwork(ip net.IP) bool {
 // checkInputIP performs basic sanity checking on the given IP to
 // attempt to reduce the network traffic and lookups for things that
 // realistically won't resolve.
-func (c *Client) checkInputIP(ip net.IP) error {
+func (c *Client) checkInputIP(ip net.IP, co callOptions) error {
 	switch {
 	case ip == nil || ip.IsUnspecified():
 		return ErrIPIsUnspecified
@@ -147,57 +281,62 @@ func (c *Client) checkInputIP(ip net.IP) error {
 		return ErrIPIsLoopback
 	case ip.IsMulticast():
 		return ErrIPIsMulticast
-	case c.isPrivateNetwork(ip):
+	case !co.skipFilter && c.isPrivateNetwork(ip):
 		return ErrIPIsPrivate
 	}
 
 	return nil
 }
 
-// asLookupString does the mangling of the given ip to fit the required
-// format of the dns request.
-func asLookupString(ip net.IP, zone string) string {
-	const hexDigit = "0123456789abcdef"
+// recordParser is cheap and nasty string parsing that remembers the
+// first thing to go wrong
+type recordParser struct {
+	err error
+}
 
-	zone6 := zone
-	if zone == "origin" {
-		zone6 += "6"
+func (p *recordParser) atoi(in string) int {
+	i, err := strconv.Atoi(in)
+	if err != nil && p.err == nil {
+		p.err = err
 	}
 
-	if ip.To4() != nil {
-		end := len(ip) - 1
+	return i
+}
 
-		return strings.Join([]string{
-			strconv.Itoa(int(ip[end])),
-			strconv.Itoa(int(ip[end-1])),
-			strconv.Itoa(int(ip[end-2])),
-			strconv.Itoa(int(ip[end-3])),
-			zone,
-			"asn.cymru.com.",
-		}, ".")
+func (p *recordParser) cidr(in string) *net.IPNet {
+	_, n, err := net.ParseCIDR(in)
+	if err != nil && p.err == nil {
+		p.err = err
 	}
 
-	// Must be IPv6
-	buf := make([]byte, 0, len(ip)*4+len(zone6+".asn.cymru.com."))
+	return n
+}
 
-	// Add it, in reverse, to the buffer
-	for i := len(ip) - 1; i >= 0; i-- {
-		buf = append(buf, hexDigit[ip[i]&0xF], '.', hexDigit[ip[i]>>4], '.')
+func (p *recordParser) date(in string) time.Time {
+	t, err := time.Parse(dateFormat, in)
+	if err != nil && p.err == nil {
+		p.err = err
 	}
 
-	buf = append(buf, zone6+".asn.cymru.com."...)
-
-	return string(buf)
+	return t
 }
 
-// parseASNList is cheap and nasty string to list of int parsing
-func parseASNList(in string) []int {
+func (p *recordParser) asnList(in string) []int {
 	tmp := strings.Fields(in)
 	r := make([]int, len(tmp))
 
 	for i, t := range tmp {
-		r[i], _ = strconv.Atoi(t)
+		r[i] = p.atoi(t)
 	}
 
 	return r
 }
+
+// malformed returns ErrMalformed if anything went wrong
+func (p *recordParser) malformed() error {
+	if p.err != nil {
+		return ErrMalformed
+	}
+
+	return nil
+}
@@ -5,6 +5,7 @@ package ipasn_test
 import (
 	"context"
 	"errors"
+	"flag"
 	"fmt"
 	"net"
 	"testing"
@@ -13,8 +14,12 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/freman/cymru/ipasn"
+	"github.com/freman/cymru/ipasn/ipasntest"
 )
 
+//nolint:gochecknoglobals
+var cassette = flag.String("cassette", "replay", "replay, record or passthrough the answers in testdata/online.json")
+
 type mockResolver func(ctx context.Context, name string) ([]string, error)
 
 func (m mockResolver) LookupTXT(ctx context.Context, name string) ([]string, error) {
@@ -132,7 +137,7 @@ var testCases = struct {
 		}, {
 			net.IPv4(1, 1, 1, 1),
 			ipasn.OriginInfo{},
-			errors.New("what? 1.1.1.1.origin.asn.cymru.com. not found"),
+			&ipasn.LookupError{Name: "1.1.1.1.origin.asn.cymru.com.", Err: errors.New("what? 1.1.1.1.origin.asn.cymru.com. not found")},
 			"",
 		}, {
 			net.IPv4(8, 8, 8, 8),
@@ -181,7 +186,7 @@ var testCases = struct {
 		}, {
 			net.IPv4(1, 1, 1, 1),
 			ipasn.PeerInfo{},
-			errors.New("what? 1.1.1.1.peer.asn.cymru.com. not found"),
+			&ipasn.LookupError{Name: "1.1.1.1.peer.asn.cymru.com.", Err: errors.New("what? 1.1.1.1.peer.asn.cymru.com. not found")},
 			"",
 		}, {
 			net.IPv4(8, 8, 8, 8),
@@ -221,7 +226,7 @@ var testCases = struct {
 		}, {
 			1111,
 			ipasn.ASNInfo{},
-			errors.New("what? AS1111.asn.cymru.com. not found"),
+			&ipasn.LookupError{Name: "AS1111.asn.cymru.com.", Err: errors.New("what? AS1111.asn.cymru.com. not found")},
 			"",
 		}, {
 			911,
@@ -307,16 +312,26 @@ func TestDefaultClient(t *testing.T) {
 	}
 }
 
-// TestOnlineDefaultResolver tests the online behavior with the default resolver.
-// These tests are separate and isolated in case something in the online world changes
-// and can be skipped by passing the short test flag
+// TestOnlineDefaultResolver tests the behavior with answers recorded from the default resolver.
+// Pass -cassette=passthrough to test against the online world instead, or -cassette=record to
+// update the recording when something in the online world changes
 func TestOnlineDefaultResolver(t *testing.T) {
-	if testing.Short() {
+	var mode ipasntest.Mode
+	require.NoError(t, mode.Set(*cassette))
+
+	if mode != ipasntest.Replay && testing.Short() {
 		t.Skip("Online tests not enabled in short tests")
 		return
 	}
 
-	c := &ipasn.Client{}
+	recorder, err := ipasntest.NewRecorder("testdata/online.json", mode, nil)
+	require.NoError(t, err)
+
+	defer func() {
+		require.NoError(t, recorder.Save())
+	}()
+
+	c := &ipasn.Client{Resolver: recorder}
 	origin, err := c.Origin(context.TODO(), net.IPv4(216, 90, 108, 31))
 	require.Equal(t, nil, err)
 	require.Equal(t, ipasn.OriginInfo{
@@ -0,0 +1,8 @@
+// Copyright 2019 Freman/Fremnet (Shannon Wynter). All rights reserved.
+
+// Package ipasnotel traces ipasn.Client lookups, and the queries they make of
+// their Resolver, with OpenTelemetry.
+//
+// It's a separate module so that the rest of ipasn doesn't depend on
+// OpenTelemetry, a Client without a Tracer doesn't trace at all.
+package ipasnotel
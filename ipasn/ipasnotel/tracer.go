@@ -0,0 +1,185 @@
+// Copyright 2019 Freman/Fremnet (Shannon Wynter). All rights reserved.
+
+package ipasnotel
+
+import (
+	"context"
+	"errors"
+	"net"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/freman/cymru/ipasn"
+)
+
+// InstrumentationName is the name of the tracer
+const InstrumentationName = "github.com/freman/cymru/ipasn/ipasnotel"
+
+// Span attributes
+const (
+	ZoneKey       = attribute.Key("ipasn.zone")
+	QueryNameKey  = attribute.Key("ipasn.query_name")
+	CacheKey      = attribute.Key("ipasn.cache")
+	ASNKey        = attribute.Key("ipasn.asn")
+	PrefixKey     = attribute.Key("ipasn.prefix")
+	ErrorClassKey = attribute.Key("ipasn.error_class")
+	AnswersKey    = attribute.Key("ipasn.answers")
+)
+
+// Tracer implements ipasn.Tracer, starting a span for every lookup named
+// after the method, eg: ipasn.Origin, with the attributes:
+//
+//	ipasn.zone         origin, origin6, peer or asn
+//	ipasn.query_name   the DNS query name, unless the lookup was rejected
+//	ipasn.cache        hit, miss or none
+//	ipasn.asn          the origin ASN, or the ASN described
+//	ipasn.prefix       the prefix of the address
+//	ipasn.error_class  see ErrorClass
+//
+// Filtered and not found lookups aren't considered to be errors.
+type Tracer struct {
+	tracer trace.Tracer
+}
+
+// NewTracer returns a Tracer using the given provider, or the global one if
+// it's nil
+func NewTracer(provider trace.TracerProvider) *Tracer {
+	if provider == nil {
+		provider = otel.GetTracerProvider()
+	}
+
+	return &Tracer{tracer: provider.Tracer(InstrumentationName)}
+}
+
+// Option returns an ipasn.Option that sets the Client's Tracer
+func (t *Tracer) Option() ipasn.Option {
+	return ipasn.WithTracer(t)
+}
+
+// StartLookup implements ipasn.Tracer
+func (t *Tracer) StartLookup(ctx context.Context, ev ipasn.LookupEvent) (context.Context, func(ipasn.LookupEvent)) {
+	ctx, span := t.tracer.Start(ctx, spanName(ev.Zone),
+		trace.WithSpanKind(trace.SpanKindInternal),
+		trace.WithAttributes(ZoneKey.String(ev.Zone)),
+	)
+
+	return ctx, func(ev ipasn.LookupEvent) {
+		defer span.End()
+
+		span.SetAttributes(CacheKey.String(ev.Cache.String()))
+
+		if ev.Name != "" {
+			span.SetAttributes(QueryNameKey.String(ev.Name))
+		}
+
+		if ev.ASN != 0 {
+			span.SetAttributes(ASNKey.Int(ev.ASN))
+		}
+
+		if ev.Network != nil {
+			span.SetAttributes(PrefixKey.String(ev.Network.String()))
+		}
+
+		setError(span, ev.Err)
+	}
+}
+
+// Resolver returns r, or net.DefaultResolver if r is nil, instrumented with
+// a client span for every query
+func (t *Tracer) Resolver(r ipasn.Resolver) ipasn.Resolver {
+	if r == nil {
+		r = net.DefaultResolver
+	}
+
+	return &resolver{Resolver: r, tracer: t.tracer}
+}
+
+type resolver struct {
+	ipasn.Resolver
+	tracer trace.Tracer
+}
+
+// LookupTXT implements ipasn.Resolver
+func (r *resolver) LookupTXT(ctx context.Context, name string) ([]string, error) {
+	ctx, span := r.tracer.Start(ctx, "ipasn.LookupTXT",
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(QueryNameKey.String(name)),
+	)
+	defer span.End()
+
+	vals, err := r.Resolver.LookupTXT(ctx, name)
+
+	span.SetAttributes(AnswersKey.Int(len(vals)))
+	setError(span, err)
+
+	return vals, err
+}
+
+// The error classes
+const (
+	ErrorNotFound    = "not_found"
+	ErrorFiltered    = "filtered"
+	ErrorUnsupported = "unsupported"
+	ErrorMalformed   = "malformed"
+	ErrorTimeout     = "timeout"
+	ErrorResolver    = "resolver_error"
+	ErrorCanceled    = "canceled"
+)
+
+// ErrorClass classifies an error returned by a Client, or its Resolver, it
+// returns an empty string for nil
+func ErrorClass(err error) string {
+	var (
+		dnsErr *net.DNSError
+		netErr net.Error
+	)
+
+	switch {
+	case err == nil:
+		return ""
+	case errors.Is(err, ipasn.ErrNotFound), errors.As(err, &dnsErr) && dnsErr.IsNotFound:
+		return ErrorNotFound
+	case errors.Is(err, ipasn.ErrFiltered):
+		return ErrorFiltered
+	case errors.Is(err, ipasn.ErrUnsupported):
+		return ErrorUnsupported
+	case err == ipasn.ErrMalformed:
+		return ErrorMalformed
+	case errors.Is(err, context.Canceled):
+		return ErrorCanceled
+	case errors.Is(err, context.DeadlineExceeded), errors.As(err, &netErr) && netErr.Timeout():
+		return ErrorTimeout
+	}
+
+	return ErrorResolver
+}
+
+func setError(span trace.Span, err error) {
+	class := ErrorClass(err)
+	if class == "" {
+		return
+	}
+
+	span.SetAttributes(ErrorClassKey.String(class))
+
+	if class == ErrorNotFound || class == ErrorFiltered || class == ErrorUnsupported {
+		return
+	}
+
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+}
+
+func spanName(zone string) string {
+	switch zone {
+	case "origin", "origin6":
+		return "ipasn.Origin"
+	case "peer":
+		return "ipasn.Peer"
+	}
+
+	return "ipasn.ASN"
+}
@@ -0,0 +1,120 @@
+// Copyright 2019 Freman/Fremnet (Shannon Wynter). All rights reserved.
+
+package ipasnotel_test
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"github.com/freman/cymru/ipasn"
+	"github.com/freman/cymru/ipasn/ipasnotel"
+	"github.com/freman/cymru/ipasn/ipasntest"
+)
+
+func attributes(span sdktrace.ReadOnlySpan) map[attribute.Key]attribute.Value {
+	attrs := make(map[attribute.Key]attribute.Value)
+	for _, kv := range span.Attributes() {
+		attrs[kv.Key] = kv.Value
+	}
+
+	return attrs
+}
+
+func TestTracer(t *testing.T) {
+	t.Parallel()
+
+	recorder := tracetest.NewSpanRecorder()
+	tracer := ipasnotel.NewTracer(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+
+	resolver := ipasntest.NewResolver(ipasntest.Golden())
+	resolver.FailQuery(ipasntest.ASNName(1234), errors.New("boom"))
+
+	client := ipasn.NewClient(
+		ipasn.WithResolver(tracer.Resolver(resolver)),
+		ipasn.WithCache(ipasn.NewMemoryCache(time.Minute, 0)),
+		tracer.Option(),
+	)
+
+	ctx := context.Background()
+
+	_, err := client.Origin(ctx, net.ParseIP("216.90.108.31"))
+	require.NoError(t, err)
+
+	_, err = client.Origin(ctx, net.ParseIP("216.90.108.31"))
+	require.NoError(t, err)
+
+	_, err = client.Peer(ctx, net.ParseIP("10.0.0.1"))
+	require.Equal(t, ipasn.ErrIPIsPrivate, err)
+
+	_, err = client.ASN(ctx, 1234)
+	require.Error(t, err)
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 6)
+
+	// The resolver span is the child of the first lookup
+	require.Equal(t, "ipasn.LookupTXT", spans[0].Name())
+	require.Equal(t, "ipasn.Origin", spans[1].Name())
+	require.Equal(t, spans[1].SpanContext().SpanID(), spans[0].Parent().SpanID())
+	require.Equal(t, int64(1), attributes(spans[0])[ipasnotel.AnswersKey].AsInt64())
+
+	attrs := attributes(spans[1])
+	require.Equal(t, "origin", attrs[ipasnotel.ZoneKey].AsString())
+	require.Equal(t, ipasntest.OriginName(net.ParseIP("216.90.108.31")), attrs[ipasnotel.QueryNameKey].AsString())
+	require.Equal(t, "miss", attrs[ipasnotel.CacheKey].AsString())
+	require.Equal(t, int64(23028), attrs[ipasnotel.ASNKey].AsInt64())
+	require.Equal(t, "216.90.108.0/24", attrs[ipasnotel.PrefixKey].AsString())
+	require.Equal(t, codes.Unset, spans[1].Status().Code)
+
+	// Cached
+	require.Equal(t, "ipasn.Origin", spans[2].Name())
+	require.Equal(t, "hit", attributes(spans[2])[ipasnotel.CacheKey].AsString())
+
+	// Filtered
+	attrs = attributes(spans[3])
+	require.Equal(t, "ipasn.Peer", spans[3].Name())
+	require.Equal(t, ipasnotel.ErrorFiltered, attrs[ipasnotel.ErrorClassKey].AsString())
+	require.NotContains(t, attrs, ipasnotel.QueryNameKey)
+	require.Equal(t, codes.Unset, spans[3].Status().Code)
+
+	// Failed
+	require.Equal(t, "ipasn.LookupTXT", spans[4].Name())
+	require.Equal(t, codes.Error, spans[4].Status().Code)
+	require.Equal(t, "ipasn.ASN", spans[5].Name())
+	require.Equal(t, codes.Error, spans[5].Status().Code)
+	require.Equal(t, ipasnotel.ErrorResolver, attributes(spans[5])[ipasnotel.ErrorClassKey].AsString())
+}
+
+func TestErrorClass(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		err   error
+		class string
+	}{
+		{nil, ""},
+		{ipasn.ErrNotFound, ipasnotel.ErrorNotFound},
+		{&net.DNSError{IsNotFound: true}, ipasnotel.ErrorNotFound},
+		{ipasn.ErrIPIsLoopback, ipasnotel.ErrorFiltered},
+		{ipasn.ErrMalformed, ipasnotel.ErrorMalformed},
+		{ipasn.ErrUnsupported, ipasnotel.ErrorUnsupported},
+		{context.Canceled, ipasnotel.ErrorCanceled},
+		{&net.DNSError{IsTimeout: true}, ipasnotel.ErrorTimeout},
+		{&ipasn.LookupError{Err: &net.DNSError{IsTimeout: true}}, ipasnotel.ErrorTimeout},
+		{&ipasn.LookupError{Err: context.Canceled}, ipasnotel.ErrorCanceled},
+		{errors.New("boom"), ipasnotel.ErrorResolver},
+	}
+
+	for _, test := range tests {
+		require.Equal(t, test.class, ipasnotel.ErrorClass(test.err), test.err)
+	}
+}
@@ -0,0 +1,8 @@
+// Copyright 2019 Freman/Fremnet (Shannon Wynter). All rights reserved.
+
+// Package ipasnprom exports Prometheus metrics for ipasn.Client lookups and
+// the queries they make of their Resolver.
+//
+// It's a separate module so that the rest of ipasn doesn't depend on the
+// Prometheus client.
+package ipasnprom
@@ -0,0 +1,11 @@
+module github.com/freman/cymru/ipasn/ipasnprom
+
+go 1.13
+
+replace github.com/freman/cymru => ../..
+
+require (
+	github.com/freman/cymru v0.0.0-00010101000000-000000000000
+	github.com/prometheus/client_golang v1.3.0
+	github.com/stretchr/testify v1.4.0
+)
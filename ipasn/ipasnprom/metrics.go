@@ -0,0 +1,203 @@
+// Copyright 2019 Freman/Fremnet (Shannon Wynter). All rights reserved.
+
+package ipasnprom
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/freman/cymru/ipasn"
+)
+
+// Metrics collects:
+//
+//	ipasn_lookups_total{zone, result}                 calls to Origin, Peer and ASN
+//	ipasn_lookup_duration_seconds{zone, result}       how long they took
+//	ipasn_cache_requests_total{zone, status}          cache hits and misses
+//	ipasn_resolver_duration_seconds{zone, result}     queries made of the Resolver
+//
+// zone is one of origin, origin6, peer or asn and result is one of the
+// classes returned by Result. The cache hit ratio is the rate of
+// status="hit" over the rate of all cache requests.
+//
+// Register it with a prometheus.Registerer, then pass Observe to the Client
+// with ipasn.WithOnLookup and wrap its Resolver with Resolver.
+type Metrics struct {
+	lookups  *prometheus.CounterVec
+	duration *prometheus.HistogramVec
+	cache    *prometheus.CounterVec
+	resolver *prometheus.HistogramVec
+}
+
+// The result classes
+const (
+	ResultOK                  = "ok"
+	ResultNotFound            = "not_found"
+	ResultFilteredPrivate     = "filtered_private"
+	ResultFilteredLoopback    = "filtered_loopback"
+	ResultFilteredMulticast   = "filtered_multicast"
+	ResultFilteredUnspecified = "filtered_unspecified"
+	ResultMalformed           = "malformed"
+	ResultUnsupported         = "unsupported"
+	ResultTimeout             = "timeout"
+	ResultResolverError       = "resolver_error"
+)
+
+// New returns Metrics with the given namespace, which defaults to ipasn
+func New(namespace string) *Metrics {
+	if namespace == "" {
+		namespace = "ipasn"
+	}
+
+	return &Metrics{
+		lookups: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "lookups_total",
+			Help:      "Lookups made by the client, by zone and result.",
+		}, []string{"zone", "result"}),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "lookup_duration_seconds",
+			Help:      "How long lookups took, including the cache, by zone and result.",
+			Buckets:   prometheus.ExponentialBuckets(0.0001, 4, 10),
+		}, []string{"zone", "result"}),
+		cache: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "cache_requests_total",
+			Help:      "Lookups answered, or not, from the cache, by zone and status.",
+		}, []string{"zone", "status"}),
+		resolver: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "resolver_duration_seconds",
+			Help:      "How long queries of the resolver took, by zone and result.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"zone", "result"}),
+	}
+}
+
+// Describe implements prometheus.Collector
+func (m *Metrics) Describe(ch chan<- *prometheus.Desc) {
+	m.lookups.Describe(ch)
+	m.duration.Describe(ch)
+	m.cache.Describe(ch)
+	m.resolver.Describe(ch)
+}
+
+// Collect implements prometheus.Collector
+func (m *Metrics) Collect(ch chan<- prometheus.Metric) {
+	m.lookups.Collect(ch)
+	m.duration.Collect(ch)
+	m.cache.Collect(ch)
+	m.resolver.Collect(ch)
+}
+
+// Observe records a lookup, it's intended to be the Client's OnLookup
+func (m *Metrics) Observe(ev ipasn.LookupEvent) {
+	result := Result(ev.Err)
+
+	m.lookups.WithLabelValues(ev.Zone, result).Inc()
+	m.duration.WithLabelValues(ev.Zone, result).Observe(ev.Duration.Seconds())
+
+	if ev.Cache != ipasn.CacheNone {
+		m.cache.WithLabelValues(ev.Zone, ev.Cache.String()).Inc()
+	}
+}
+
+// Option returns an ipasn.Option that sets the Client's OnLookup to Observe
+func (m *Metrics) Option() ipasn.Option {
+	return ipasn.WithOnLookup(m.Observe)
+}
+
+// Resolver returns r, or net.DefaultResolver if r is nil, instrumented to
+// record every query
+func (m *Metrics) Resolver(r ipasn.Resolver) ipasn.Resolver {
+	if r == nil {
+		r = net.DefaultResolver
+	}
+
+	return &resolver{Resolver: r, metrics: m}
+}
+
+type resolver struct {
+	ipasn.Resolver
+	metrics *Metrics
+}
+
+// LookupTXT implements ipasn.Resolver
+func (r *resolver) LookupTXT(ctx context.Context, name string) ([]string, error) {
+	start := time.Now()
+	vals, err := r.Resolver.LookupTXT(ctx, name)
+
+	result := Result(err)
+	if err == nil && len(vals) == 0 {
+		result = ResultNotFound
+	}
+
+	r.metrics.resolver.WithLabelValues(zone(name), result).Observe(time.Since(start).Seconds())
+
+	return vals, err
+}
+
+// Result classifies an error returned by a Client, or its Resolver
+func Result(err error) string {
+	var (
+		dnsErr *net.DNSError
+		netErr net.Error
+	)
+
+	switch {
+	case err == nil:
+		return ResultOK
+	case errors.Is(err, ipasn.ErrNotFound), errors.As(err, &dnsErr) && dnsErr.IsNotFound:
+		return ResultNotFound
+	case err == ipasn.ErrIPIsPrivate:
+		return ResultFilteredPrivate
+	case err == ipasn.ErrIPIsLoopback:
+		return ResultFilteredLoopback
+	case err == ipasn.ErrIPIsMulticast:
+		return ResultFilteredMulticast
+	case err == ipasn.ErrIPIsUnspecified:
+		return ResultFilteredUnspecified
+	case err == ipasn.ErrMalformed:
+		return ResultMalformed
+	case errors.Is(err, ipasn.ErrUnsupported):
+		return ResultUnsupported
+	case errors.Is(err, context.DeadlineExceeded), errors.As(err, &netErr) && netErr.Timeout():
+		return ResultTimeout
+	}
+
+	return ResultResolverError
+}
+
+// zone works out the zone of a query name laid out like the Team Cymru
+// zones, under any parent, eg: origin6 from 8.6.0.0.[...].origin6.asn.cymru.com.
+func zone(name string) string {
+	labels := strings.Split(strings.ToLower(name), ".")
+
+	if strings.HasPrefix(labels[0], "as") {
+		if _, err := strconv.Atoi(labels[0][2:]); err == nil {
+			return "asn"
+		}
+	}
+
+	for _, label := range labels {
+		// Skip the octets, or nibbles, of the address
+		if _, err := strconv.ParseUint(label, 10, 8); err == nil || len(label) == 1 {
+			continue
+		}
+
+		if label == "origin" || label == "origin6" || label == "peer" {
+			return label
+		}
+
+		break
+	}
+
+	return "other"
+}
@@ -0,0 +1,169 @@
+// Copyright 2019 Freman/Fremnet (Shannon Wynter). All rights reserved.
+
+package ipasnprom_test
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+
+	"github.com/freman/cymru/ipasn"
+	"github.com/freman/cymru/ipasn/ipasnprom"
+	"github.com/freman/cymru/ipasn/ipasntest"
+)
+
+func TestMetrics(t *testing.T) {
+	t.Parallel()
+
+	metrics := ipasnprom.New("")
+
+	registry := prometheus.NewPedanticRegistry()
+	require.NoError(t, registry.Register(metrics))
+
+	client := ipasn.NewClient(
+		ipasn.WithResolver(metrics.Resolver(ipasntest.NewResolver(ipasntest.Golden()))),
+		ipasn.WithCache(ipasn.NewMemoryCache(time.Minute, 0)),
+		metrics.Option(),
+	)
+
+	ctx := context.Background()
+	_, _ = client.Origin(ctx, net.ParseIP("216.90.108.31"))
+	_, _ = client.Origin(ctx, net.ParseIP("216.90.108.31"))
+	_, _ = client.Origin(ctx, net.ParseIP("2001:4860::1"))
+	_, _ = client.Origin(ctx, net.ParseIP("10.0.0.1"))
+	_, _ = client.Origin(ctx, net.ParseIP("127.0.0.1"))
+	_, _ = client.Peer(ctx, net.ParseIP("1.1.1.1"))
+	_, _ = client.ASN(ctx, 15169)
+
+	require.NoError(t, testutil.GatherAndCompare(registry, strings.NewReader(`
+# HELP ipasn_lookups_total Lookups made by the client, by zone and result.
+# TYPE ipasn_lookups_total counter
+ipasn_lookups_total{result="filtered_loopback",zone="origin"} 1
+ipasn_lookups_total{result="filtered_private",zone="origin"} 1
+ipasn_lookups_total{result="not_found",zone="peer"} 1
+ipasn_lookups_total{result="ok",zone="asn"} 1
+ipasn_lookups_total{result="ok",zone="origin"} 2
+ipasn_lookups_total{result="ok",zone="origin6"} 1
+# HELP ipasn_cache_requests_total Lookups answered, or not, from the cache, by zone and status.
+# TYPE ipasn_cache_requests_total counter
+ipasn_cache_requests_total{status="hit",zone="origin"} 1
+ipasn_cache_requests_total{status="miss",zone="asn"} 1
+ipasn_cache_requests_total{status="miss",zone="origin"} 1
+ipasn_cache_requests_total{status="miss",zone="origin6"} 1
+ipasn_cache_requests_total{status="miss",zone="peer"} 1
+`), "ipasn_lookups_total", "ipasn_cache_requests_total"))
+
+	families, err := registry.Gather()
+	require.NoError(t, err)
+
+	counts := make(map[string]uint64)
+
+	for _, family := range families {
+		for _, m := range family.GetMetric() {
+			if h := m.GetHistogram(); h != nil {
+				key := family.GetName()
+				for _, l := range m.GetLabel() {
+					key += " " + l.GetValue()
+				}
+
+				counts[key] = h.GetSampleCount()
+			}
+		}
+	}
+
+	require.Equal(t, map[string]uint64{
+		"ipasn_lookup_duration_seconds filtered_loopback origin": 1,
+		"ipasn_lookup_duration_seconds filtered_private origin":  1,
+		"ipasn_lookup_duration_seconds not_found peer":           1,
+		"ipasn_lookup_duration_seconds ok asn":                   1,
+		"ipasn_lookup_duration_seconds ok origin":                2,
+		"ipasn_lookup_duration_seconds ok origin6":               1,
+		"ipasn_resolver_duration_seconds not_found peer":         1,
+		"ipasn_resolver_duration_seconds ok asn":                 1,
+		"ipasn_resolver_duration_seconds ok origin":              1,
+		"ipasn_resolver_duration_seconds ok origin6":             1,
+	}, counts)
+}
+
+func TestResult(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		err    error
+		result string
+	}{
+		{nil, ipasnprom.ResultOK},
+		{ipasn.ErrNotFound, ipasnprom.ResultNotFound},
+		{&net.DNSError{IsNotFound: true}, ipasnprom.ResultNotFound},
+		{ipasn.ErrIPIsPrivate, ipasnprom.ResultFilteredPrivate},
+		{ipasn.ErrIPIsLoopback, ipasnprom.ResultFilteredLoopback},
+		{ipasn.ErrIPIsMulticast, ipasnprom.ResultFilteredMulticast},
+		{ipasn.ErrIPIsUnspecified, ipasnprom.ResultFilteredUnspecified},
+		{ipasn.ErrMalformed, ipasnprom.ResultMalformed},
+		{ipasn.ErrUnsupported, ipasnprom.ResultUnsupported},
+		{context.DeadlineExceeded, ipasnprom.ResultTimeout},
+		{&net.DNSError{IsTimeout: true}, ipasnprom.ResultTimeout},
+		{&ipasn.LookupError{Err: &net.DNSError{IsNotFound: true}}, ipasnprom.ResultNotFound},
+		{&ipasn.LookupError{Err: context.DeadlineExceeded}, ipasnprom.ResultTimeout},
+		{errors.New("boom"), ipasnprom.ResultResolverError},
+	}
+
+	for _, test := range tests {
+		require.Equal(t, test.result, ipasnprom.Result(test.err), test.err)
+	}
+}
+
+type emptyResolver struct{}
+
+func (emptyResolver) LookupTXT(context.Context, string) ([]string, error) {
+	return nil, nil
+}
+
+func TestMetricsOtherZones(t *testing.T) {
+	t.Parallel()
+
+	metrics := ipasnprom.New("mirror")
+
+	registry := prometheus.NewPedanticRegistry()
+	require.NoError(t, registry.Register(metrics))
+
+	client := ipasn.NewClient(
+		ipasn.WithResolver(metrics.Resolver(emptyResolver{})),
+		ipasn.WithZones(ipasn.ZonesUnder("asn.example.internal")),
+	)
+
+	ctx := context.Background()
+	_, _ = client.Origin(ctx, net.ParseIP("2001:4860::1"))
+	_, _ = client.Peer(ctx, net.ParseIP("216.90.108.31"))
+	_, _ = client.ASN(ctx, 15169)
+
+	_, _ = metrics.Resolver(emptyResolver{}).LookupTXT(ctx, "example.com.")
+
+	families, err := registry.Gather()
+	require.NoError(t, err)
+
+	var zones []string
+
+	for _, family := range families {
+		if family.GetName() != "mirror_resolver_duration_seconds" {
+			continue
+		}
+
+		for _, m := range family.GetMetric() {
+			for _, l := range m.GetLabel() {
+				if l.GetName() == "zone" {
+					zones = append(zones, l.GetValue())
+				}
+			}
+		}
+	}
+
+	require.ElementsMatch(t, []string{"origin6", "peer", "asn", "other"}, zones)
+}
@@ -0,0 +1,5 @@
+// Copyright 2019 Freman/Fremnet (Shannon Wynter). All rights reserved.
+
+// Package ipasntest provides utilities for testing code that uses the ipasn package, chiefly a fake
+// Resolver that answers from fixtures instead of the Team Cymru DNS service.
+package ipasntest
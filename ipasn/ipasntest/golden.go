@@ -0,0 +1,57 @@
+// Copyright 2019 Freman/Fremnet (Shannon Wynter). All rights reserved.
+
+package ipasntest
+
+import (
+	"net"
+	"time"
+
+	"github.com/freman/cymru/ipasn"
+)
+
+// Fixture is a table of answers for a Resolver, origins and peers match any
+// address within their Network while ASNs match exactly.
+type Fixture struct {
+	Origins []ipasn.OriginInfo
+	Peers   []ipasn.PeerInfo
+	ASNs    []ipasn.ASNInfo
+}
+
+// Golden returns a fixture of well known answers, as they were published by
+// Team Cymru, for use in tests:
+//
+//	216.90.108.0/24  23028  TEAM-CYMRU - Team Cymru Inc., US
+//	2001:4860::/32   15169  GOOGLE - Google LLC, US
+//	AS1234                  FORTUM-AS | Fortum, FI
+func Golden() Fixture {
+	cymru := mustCIDR("216.90.108.0/24")
+	google := mustCIDR("2001:4860::/32")
+
+	return Fixture{
+		Origins: []ipasn.OriginInfo{
+			{ASN: 23028, Network: cymru, Country: "US", Authority: "arin", Updated: date(1998, 9, 25)},
+			{ASN: 15169, Network: google, Country: "US", Authority: "arin", Updated: date(2005, 3, 14)},
+		},
+		Peers: []ipasn.PeerInfo{
+			{ASNs: []int{701, 1239, 3549, 3561, 7132}, Network: cymru, Country: "US", Authority: "arin", Updated: date(1998, 9, 25)},
+		},
+		ASNs: []ipasn.ASNInfo{
+			{ASN: 23028, Country: "US", Authority: "arin", Updated: date(2002, 1, 4), Description: "TEAM-CYMRU - Team Cymru Inc., US"},
+			{ASN: 15169, Country: "US", Authority: "arin", Updated: date(2000, 3, 30), Description: "GOOGLE - Google LLC, US"},
+			{ASN: 1234, Country: "EU", Authority: "ripencc", Updated: date(1993, 9, 1), Description: "FORTUM-AS | Fortum, FI"},
+		},
+	}
+}
+
+func mustCIDR(s string) *net.IPNet {
+	_, n, err := net.ParseCIDR(s)
+	if err != nil {
+		panic(err)
+	}
+
+	return n
+}
+
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
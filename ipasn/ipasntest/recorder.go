@@ -0,0 +1,219 @@
+// Copyright 2019 Freman/Fremnet (Shannon Wynter). All rights reserved.
+
+package ipasntest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/freman/cymru/ipasn"
+)
+
+// Mode controls whether a Recorder records, replays or passes queries through.
+//
+// It implements flag.Value so it can be chosen on the command line, eg:
+//
+//	flag.Var(&mode, "cassette", "record, replay or passthrough")
+type Mode int
+
+// Recorder modes
+const (
+	// Replay answers from the cassette, failing queries that aren't on it
+	Replay Mode = iota
+	// Record forwards queries to the Resolver and saves the exchanges to the
+	// cassette
+	Record
+	// Passthrough forwards queries to the Resolver leaving the cassette alone
+	Passthrough
+)
+
+func (m Mode) String() string {
+	switch m {
+	case Replay:
+		return "replay"
+	case Record:
+		return "record"
+	case Passthrough:
+		return "passthrough"
+	}
+
+	return fmt.Sprintf("Mode(%d)", int(m))
+}
+
+// Set implements flag.Value
+func (m *Mode) Set(s string) error {
+	switch strings.ToLower(s) {
+	case "replay", "":
+		*m = Replay
+	case "record":
+		*m = Record
+	case "passthrough":
+		*m = Passthrough
+	default:
+		return fmt.Errorf("ipasntest: unknown mode %q", s)
+	}
+
+	return nil
+}
+
+// Recorder is an ipasn.Resolver that records the LookupTXT exchanges of
+// another Resolver to a cassette file so they can be replayed in later tests,
+// giving realistic answers without the network.
+//
+// In Record mode the cassette is only written by Save, and errors are recorded
+// along with answers. In Replay mode queries that aren't on the cassette
+// return ErrUnexpectedQuery.
+type Recorder struct {
+	// Resolver is consulted in Record and Passthrough modes, it defaults to
+	// net.DefaultResolver
+	Resolver ipasn.Resolver
+
+	// Path is the cassette file
+	Path string
+
+	// Mode is Replay by default
+	Mode Mode
+
+	mu           sync.Mutex
+	interactions map[string]interaction
+}
+
+// interaction is a single exchange on the cassette, errors are replayed as
+// *net.DNSError because that's what net.DefaultResolver returns
+type interaction struct {
+	Name       string   `json:"name"`
+	Answers    []string `json:"answers,omitempty"`
+	Err        string   `json:"error,omitempty"`
+	IsNotFound bool     `json:"not_found,omitempty"`
+}
+
+type cassette struct {
+	Interactions []interaction `json:"interactions"`
+}
+
+// NewRecorder returns a Recorder for the cassette at path, loading it if the
+// mode is Replay
+func NewRecorder(path string, mode Mode, resolver ipasn.Resolver) (*Recorder, error) {
+	r := &Recorder{
+		Resolver: resolver,
+		Path:     path,
+		Mode:     mode,
+	}
+
+	if mode == Replay {
+		if err := r.Load(); err != nil {
+			return nil, err
+		}
+	}
+
+	return r, nil
+}
+
+// Load reads the cassette, replacing any exchanges already held
+func (r *Recorder) Load() error {
+	b, err := ioutil.ReadFile(r.Path)
+	if err != nil {
+		return err
+	}
+
+	var c cassette
+	if err := json.Unmarshal(b, &c); err != nil {
+		return fmt.Errorf("ipasntest: invalid cassette %s: %w", r.Path, err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.interactions = make(map[string]interaction, len(c.Interactions))
+	for _, i := range c.Interactions {
+		r.interactions[strings.ToLower(i.Name)] = i
+	}
+
+	return nil
+}
+
+// Save writes the recorded exchanges to the cassette, sorted by name so that
+// re-recording produces minimal diffs. It does nothing unless the mode is
+// Record.
+func (r *Recorder) Save() error {
+	if r.Mode != Record {
+		return nil
+	}
+
+	r.mu.Lock()
+
+	c := cassette{Interactions: make([]interaction, 0, len(r.interactions))}
+	for _, i := range r.interactions {
+		c.Interactions = append(c.Interactions, i)
+	}
+
+	r.mu.Unlock()
+
+	sort.Slice(c.Interactions, func(i, j int) bool {
+		return c.Interactions[i].Name < c.Interactions[j].Name
+	})
+
+	b, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(r.Path, append(b, '\n'), 0644)
+}
+
+// LookupTXT implements ipasn.Resolver
+func (r *Recorder) LookupTXT(ctx context.Context, name string) ([]string, error) {
+	if r.Mode == Replay {
+		r.mu.Lock()
+		i, found := r.interactions[strings.ToLower(name)]
+		r.mu.Unlock()
+
+		if !found {
+			return nil, fmt.Errorf("%w: %q is not on cassette %s", ErrUnexpectedQuery, name, r.Path)
+		}
+
+		if i.Err != "" {
+			return nil, &net.DNSError{Err: i.Err, Name: i.Name, IsNotFound: i.IsNotFound}
+		}
+
+		return append([]string(nil), i.Answers...), nil
+	}
+
+	resolver := r.Resolver
+	if resolver == nil {
+		resolver = net.DefaultResolver
+	}
+
+	answers, err := resolver.LookupTXT(ctx, name)
+
+	if r.Mode == Record && ctx.Err() == nil {
+		i := interaction{Name: name, Answers: answers}
+
+		if err != nil {
+			i.Err = err.Error()
+
+			var dnsErr *net.DNSError
+			if errors.As(err, &dnsErr) {
+				i.Err, i.IsNotFound = dnsErr.Err, dnsErr.IsNotFound
+			}
+		}
+
+		r.mu.Lock()
+
+		if r.interactions == nil {
+			r.interactions = make(map[string]interaction)
+		}
+
+		r.interactions[strings.ToLower(name)] = i
+		r.mu.Unlock()
+	}
+
+	return answers, err
+}
@@ -0,0 +1,99 @@
+// Copyright 2019 Freman/Fremnet (Shannon Wynter). All rights reserved.
+
+package ipasntest_test
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/freman/cymru/ipasn"
+	"github.com/freman/cymru/ipasn/ipasntest"
+)
+
+func TestRecorder(t *testing.T) {
+	t.Parallel()
+
+	dir, err := ioutil.TempDir("", "ipasntest")
+	require.NoError(t, err)
+
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "cassette.json")
+
+	upstream := ipasntest.NewResolver(ipasntest.Golden())
+	upstream.FailQuery(ipasntest.OriginName(net.IPv4(1, 1, 1, 1)), &net.DNSError{Err: "no such host", IsNotFound: true})
+
+	exercise := func(r ipasn.Resolver) []interface{} {
+		c := &ipasn.Client{Resolver: r}
+
+		origin, err1 := c.Origin(context.TODO(), net.IPv4(216, 90, 108, 31))
+		peer, err2 := c.Peer(context.TODO(), net.IPv4(216, 90, 108, 31))
+		asn, err3 := c.ASN(context.TODO(), 1234)
+		_, err4 := c.ASN(context.TODO(), 911)
+		_, err5 := c.Origin(context.TODO(), net.IPv4(1, 1, 1, 1))
+
+		var dnsErr *net.DNSError
+
+		return []interface{}{origin, err1, peer, err2, asn, err3, err4, errors.As(err5, &dnsErr) && dnsErr.IsNotFound}
+	}
+
+	recorder, err := ipasntest.NewRecorder(path, ipasntest.Record, upstream)
+	require.NoError(t, err)
+
+	recorded := exercise(recorder)
+	require.Len(t, upstream.Queries(), 5)
+	require.NoError(t, recorder.Save())
+
+	player, err := ipasntest.NewRecorder(path, ipasntest.Replay, nil)
+	require.NoError(t, err)
+	require.Equal(t, recorded, exercise(player))
+	require.Len(t, upstream.Queries(), 5)
+
+	_, err = player.LookupTXT(context.TODO(), ipasntest.OriginName(net.IPv4(8, 8, 8, 8)))
+	require.True(t, errors.Is(err, ipasntest.ErrUnexpectedQuery))
+
+	b, err := ioutil.ReadFile(path)
+	require.NoError(t, err)
+
+	passthrough, err := ipasntest.NewRecorder(path, ipasntest.Passthrough, upstream)
+	require.NoError(t, err)
+	require.Equal(t, recorded, exercise(passthrough))
+	require.Len(t, upstream.Queries(), 10)
+	require.NoError(t, passthrough.Save())
+
+	after, err := ioutil.ReadFile(path)
+	require.NoError(t, err)
+	require.Equal(t, b, after)
+
+	_, err = ipasntest.NewRecorder(filepath.Join(dir, "missing.json"), ipasntest.Replay, nil)
+	require.True(t, os.IsNotExist(err))
+}
+
+func TestMode(t *testing.T) {
+	t.Parallel()
+
+	var mode ipasntest.Mode
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.SetOutput(ioutil.Discard)
+	fs.Var(&mode, "cassette", "")
+
+	require.Equal(t, "replay", mode.String())
+
+	require.NoError(t, fs.Parse([]string{"-cassette", "record"}))
+	require.Equal(t, ipasntest.Record, mode)
+
+	require.NoError(t, fs.Parse([]string{"-cassette", "Passthrough"}))
+	require.Equal(t, ipasntest.Passthrough, mode)
+	require.Equal(t, "passthrough", mode.String())
+
+	require.Error(t, fs.Parse([]string{"-cassette", "rewind"}))
+}
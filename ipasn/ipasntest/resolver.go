@@ -0,0 +1,211 @@
+// Copyright 2019 Freman/Fremnet (Shannon Wynter). All rights reserved.
+
+package ipasntest
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/freman/cymru/ipasn"
+	"github.com/freman/cymru/ipasn/localdb"
+)
+
+// Resolver is a fake ipasn.Resolver that answers from fixtures, in exactly
+// the same format as the Team Cymru DNS service, and records every query it's
+// asked so tests can make assertions about them.
+//
+// Addresses and ASNs without a fixture get no records, which ipasn.Client
+// reports as ipasn.ErrNotFound, and names outside of the Team Cymru zones
+// get ErrUnexpectedQuery.
+//
+// It's safe for concurrent use, including changing the fixtures, latency and
+// errors while it's in use.
+type Resolver struct {
+	mu      sync.Mutex
+	origins *localdb.DB
+	peers   *localdb.DB
+	answers map[string][]string
+	errs    map[string]error
+	err     error
+	latency time.Duration
+	queries []string
+}
+
+// ErrUnexpectedQuery is returned for names that aren't Team Cymru queries
+const ErrUnexpectedQuery = ipasn.Error("ipasntest: unexpected query")
+
+// NewResolver returns a Resolver answering from the given fixtures
+func NewResolver(fixtures ...Fixture) *Resolver {
+	r := &Resolver{
+		origins: localdb.New(),
+		peers:   localdb.New(),
+		answers: make(map[string][]string),
+		errs:    make(map[string]error),
+	}
+
+	for _, f := range fixtures {
+		r.Add(f)
+	}
+
+	return r
+}
+
+// Add adds the fixture, replacing any existing answers for the same networks
+// or ASNs
+func (r *Resolver) Add(f Fixture) {
+	for _, o := range f.Origins {
+		r.AddOrigin(o)
+	}
+
+	for _, p := range f.Peers {
+		r.AddPeer(p)
+	}
+
+	for _, a := range f.ASNs {
+		r.AddASN(a)
+	}
+}
+
+// AddOrigin answers origin queries for any address within o.Network
+func (r *Resolver) AddOrigin(o ipasn.OriginInfo) {
+	r.origins.Insert(localdb.Record{
+		Network:   o.Network,
+		ASN:       o.ASN,
+		Country:   o.Country,
+		Authority: o.Authority,
+		Updated:   o.Updated,
+	})
+}
+
+// AddPeer answers peer queries for any address within p.Network
+func (r *Resolver) AddPeer(p ipasn.PeerInfo) {
+	r.peers.Insert(localdb.Record{
+		Network:   p.Network,
+		Peers:     p.ASNs,
+		Country:   p.Country,
+		Authority: p.Authority,
+		Updated:   p.Updated,
+	})
+}
+
+// AddASN answers queries for a.ASN
+func (r *Resolver) AddASN(a ipasn.ASNInfo) {
+	r.origins.InsertASN(a)
+}
+
+// SetAnswer answers queries for exactly name with txts, taking precedence
+// over the fixtures, eg: to test malformed answers. No txts means no records.
+func (r *Resolver) SetAnswer(name string, txts ...string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.answers[strings.ToLower(name)] = txts
+}
+
+// SetLatency delays every answer by d, or until the context is done
+func (r *Resolver) SetLatency(d time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.latency = d
+}
+
+// Fail makes every query return err, or stops doing so if err is nil
+func (r *Resolver) Fail(err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.err = err
+}
+
+// FailQuery makes queries for exactly name return err, or stops doing so if
+// err is nil. See OriginName, PeerName and ASNName.
+func (r *Resolver) FailQuery(name string, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err == nil {
+		delete(r.errs, strings.ToLower(name))
+		return
+	}
+
+	r.errs[strings.ToLower(name)] = err
+}
+
+// Queries returns the names queried so far, in order
+func (r *Resolver) Queries() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return append([]string(nil), r.queries...)
+}
+
+// ResetQueries forgets the names queried so far
+func (r *Resolver) ResetQueries() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.queries = nil
+}
+
+// LookupTXT implements ipasn.Resolver
+func (r *Resolver) LookupTXT(ctx context.Context, name string) ([]string, error) {
+	key := strings.ToLower(name)
+
+	r.mu.Lock()
+	r.queries = append(r.queries, name)
+	latency, err, qerr := r.latency, r.err, r.errs[key]
+	answer, answered := r.answers[key]
+	r.mu.Unlock()
+
+	if latency > 0 {
+		timer := time.NewTimer(latency)
+		defer timer.Stop()
+
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
+	switch {
+	case err != nil:
+		return nil, err
+	case qerr != nil:
+		return nil, qerr
+	case answered:
+		return append([]string(nil), answer...), nil
+	}
+
+	db := r.origins
+	if strings.Contains(key, ".peer.") {
+		db = r.peers
+	}
+
+	txts, err := db.LookupTXT(ctx, name)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %q", ErrUnexpectedQuery, name)
+	}
+
+	return txts, nil
+}
+
+// OriginName returns the name ipasn.Client queries for the origin of ip
+func OriginName(ip net.IP) string {
+	return ipasn.DefaultZones().OriginName(ip)
+}
+
+// PeerName returns the name ipasn.Client queries for the peers of ip
+func PeerName(ip net.IP) string {
+	return ipasn.DefaultZones().PeerName(ip)
+}
+
+// ASNName returns the name ipasn.Client queries for the description of asn
+func ASNName(asn int) string {
+	return ipasn.DefaultZones().ASNName(asn)
+}
@@ -0,0 +1,122 @@
+// Copyright 2019 Freman/Fremnet (Shannon Wynter). All rights reserved.
+
+package ipasntest_test
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/freman/cymru/ipasn"
+	"github.com/freman/cymru/ipasn/ipasntest"
+)
+
+func TestResolver(t *testing.T) {
+	t.Parallel()
+
+	r := ipasntest.NewResolver(ipasntest.Golden())
+	c := &ipasn.Client{Resolver: r}
+
+	origin, err := c.Origin(context.TODO(), net.IPv4(216, 90, 108, 31))
+	require.NoError(t, err)
+	require.Equal(t, "23028 | 216.90.108.0/24 | US | arin | 1998-09-25", origin.String())
+
+	origin, err = c.Origin(context.TODO(), net.ParseIP("2001:4860:b002::68"))
+	require.NoError(t, err)
+	require.Equal(t, "15169 | 2001:4860::/32 | US | arin | 2005-03-14", origin.String())
+
+	peer, err := c.Peer(context.TODO(), net.IPv4(216, 90, 108, 31))
+	require.NoError(t, err)
+	require.Equal(t, "701 1239 3549 3561 7132 | 216.90.108.0/24 | US | arin | 1998-09-25", peer.String())
+
+	asn, err := c.ASN(context.TODO(), 1234)
+	require.NoError(t, err)
+	require.Equal(t, "FORTUM-AS | Fortum, FI", asn.Description)
+
+	_, err = c.Peer(context.TODO(), net.ParseIP("2001:4860:b002::68"))
+	require.Equal(t, ipasn.ErrNotFound, err)
+
+	_, err = c.ASN(context.TODO(), 911)
+	require.Equal(t, ipasn.ErrNotFound, err)
+
+	require.Equal(t, []string{
+		"31.108.90.216.origin.asn.cymru.com.",
+		"8.6.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.2.0.0.b.0.6.8.4.1.0.0.2.origin6.asn.cymru.com.",
+		"31.108.90.216.peer.asn.cymru.com.",
+		"AS1234.asn.cymru.com.",
+		"8.6.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.2.0.0.b.0.6.8.4.1.0.0.2.peer.asn.cymru.com.",
+		"AS911.asn.cymru.com.",
+	}, r.Queries())
+
+	r.ResetQueries()
+	require.Empty(t, r.Queries())
+
+	_, err = r.LookupTXT(context.TODO(), "www.example.com.")
+	require.True(t, errors.Is(err, ipasntest.ErrUnexpectedQuery))
+}
+
+func TestQueryNames(t *testing.T) {
+	t.Parallel()
+
+	ip := net.IPv4(216, 90, 108, 31)
+	require.Equal(t, "31.108.90.216.origin.asn.cymru.com.", ipasntest.OriginName(ip))
+	require.Equal(t, "31.108.90.216.peer.asn.cymru.com.", ipasntest.PeerName(ip))
+	require.Equal(t, "AS23028.asn.cymru.com.", ipasntest.ASNName(23028))
+
+	ip = net.ParseIP("2001:4860::1")
+	require.Equal(t, "1.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.6.8.4.1.0.0.2.origin6.asn.cymru.com.", ipasntest.OriginName(ip))
+	require.Equal(t, "1.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.6.8.4.1.0.0.2.peer.asn.cymru.com.", ipasntest.PeerName(ip))
+}
+
+func TestResolverInjection(t *testing.T) {
+	t.Parallel()
+
+	r := ipasntest.NewResolver(ipasntest.Golden())
+	c := &ipasn.Client{Resolver: r, Strict: true}
+	ip := net.IPv4(216, 90, 108, 31)
+	broken := errors.New("broken")
+
+	r.FailQuery(ipasntest.OriginName(ip), broken)
+
+	_, err := c.Origin(context.TODO(), ip)
+	require.True(t, errors.Is(err, broken))
+
+	_, err = c.Peer(context.TODO(), ip)
+	require.NoError(t, err)
+
+	r.FailQuery(ipasntest.OriginName(ip), nil)
+	r.Fail(broken)
+
+	_, err = c.ASN(context.TODO(), 23028)
+	require.True(t, errors.Is(err, broken))
+
+	r.Fail(nil)
+	r.SetAnswer(ipasntest.ASNName(23028), "nonsense")
+
+	_, err = c.ASN(context.TODO(), 23028)
+	require.Equal(t, ipasn.ErrMalformed, err)
+
+	r.SetAnswer(ipasntest.ASNName(23028))
+
+	_, err = c.ASN(context.TODO(), 23028)
+	require.Equal(t, ipasn.ErrNotFound, err)
+
+	r.SetLatency(time.Hour)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	_, err = c.Origin(ctx, ip)
+	require.True(t, errors.Is(err, context.DeadlineExceeded))
+
+	r.SetLatency(time.Millisecond)
+
+	_, err = c.Origin(context.TODO(), ip)
+	require.NoError(t, err)
+
+	require.Len(t, r.Queries(), 7)
+}
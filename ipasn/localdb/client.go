@@ -0,0 +1,32 @@
+// Copyright 2019 Freman/Fremnet (Shannon Wynter). All rights reserved.
+
+package localdb
+
+import (
+	"context"
+	"net"
+
+	"github.com/freman/cymru/ipasn"
+)
+
+// Origin looks up the BGP Origin ASN for ip exactly as ipasn.Client.Origin
+// would using the database as its resolver.
+func (db *DB) Origin(ctx context.Context, ip net.IP, opts ...ipasn.CallOption) (ipasn.OriginInfo, error) {
+	return db.client().Origin(ctx, ip, opts...)
+}
+
+// Peer looks up the BGP peer ASNs for ip exactly as ipasn.Client.Peer would
+// using the database as its resolver.
+func (db *DB) Peer(ctx context.Context, ip net.IP, opts ...ipasn.CallOption) (ipasn.PeerInfo, error) {
+	return db.client().Peer(ctx, ip, opts...)
+}
+
+// ASN looks up the AS description exactly as ipasn.Client.ASN would using
+// the database as its resolver.
+func (db *DB) ASN(ctx context.Context, asn int, opts ...ipasn.CallOption) (ipasn.ASNInfo, error) {
+	return db.client().ASN(ctx, asn, opts...)
+}
+
+func (db *DB) client() *ipasn.Client {
+	return &ipasn.Client{Resolver: db}
+}
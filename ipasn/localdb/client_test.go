@@ -0,0 +1,40 @@
+// Copyright 2019 Freman/Fremnet (Shannon Wynter). All rights reserved.
+
+package localdb_test
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/freman/cymru/ipasn"
+)
+
+func TestClientSurface(t *testing.T) {
+	t.Parallel()
+
+	db := testDB()
+
+	origin, err := db.Origin(context.TODO(), net.ParseIP("216.90.108.31"))
+	require.NoError(t, err)
+	require.Equal(t, 23028, origin.ASN)
+
+	_, err = db.Origin(context.TODO(), net.ParseIP("10.0.0.1"))
+	require.Equal(t, ipasn.ErrIPIsPrivate, err)
+
+	_, err = db.Origin(context.TODO(), net.ParseIP("1.1.1.1"))
+	require.Equal(t, ipasn.ErrNotFound, err)
+
+	peer, err := db.Peer(context.TODO(), net.ParseIP("216.90.108.31"))
+	require.NoError(t, err)
+	require.Equal(t, []int{701, 1239}, peer.ASNs)
+
+	asn, err := db.ASN(context.TODO(), 23028)
+	require.NoError(t, err)
+	require.Equal(t, "TEAM-CYMRU - Team Cymru Inc., US", asn.Description)
+
+	_, err = db.ASN(context.TODO(), 1)
+	require.Equal(t, ipasn.ErrNotFound, err)
+}
@@ -0,0 +1,35 @@
+// Copyright 2019 Freman/Fremnet (Shannon Wynter). All rights reserved.
+
+package localdb
+
+import (
+	"bufio"
+	"compress/bzip2"
+	"compress/gzip"
+	"io"
+)
+
+// decompress sniffs the first few bytes of the reader for gzip or bzip2 magic
+// so that datasets can be loaded as they're published.
+func decompress(r io.Reader) (*bufio.Reader, error) {
+	br := bufio.NewReader(r)
+
+	magic, err := br.Peek(3)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+
+	switch {
+	case len(magic) >= 2 && magic[0] == 0x1f && magic[1] == 0x8b:
+		gz, err := gzip.NewReader(br)
+		if err != nil {
+			return nil, err
+		}
+
+		return bufio.NewReader(gz), nil
+	case len(magic) == 3 && string(magic) == "BZh":
+		return bufio.NewReader(bzip2.NewReader(br)), nil
+	}
+
+	return br, nil
+}
@@ -0,0 +1,200 @@
+// Copyright 2019 Freman/Fremnet (Shannon Wynter). All rights reserved.
+
+package localdb
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math/big"
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/freman/cymru/ipasn"
+)
+
+// LoadPfx2AS reads a CAIDA RouteViews prefix to AS dataset, one tab separated
+// prefix, prefix length and AS per line.
+//
+// Multi-origin prefixes (eg: 4134_4812) and AS sets (eg: 64512,64513) are
+// recorded against the first AS listed.
+func (db *DB) LoadPfx2AS(r io.Reader) error {
+	return scanLines(r, "\t", func(fields []string) error {
+		if len(fields) != 3 {
+			return fmt.Errorf("expected 3 fields, got %d", len(fields))
+		}
+
+		bits, err := strconv.Atoi(fields[1])
+		if err != nil {
+			return err
+		}
+
+		_, network, err := net.ParseCIDR(fields[0] + "/" + strconv.Itoa(bits))
+		if err != nil {
+			return err
+		}
+
+		origins := strings.FieldsFunc(fields[2], func(r rune) bool {
+			return r == '_' || r == ','
+		})
+		if len(origins) == 0 {
+			return fmt.Errorf("missing AS for %s", network)
+		}
+
+		asn, err := strconv.Atoi(origins[0])
+		if err != nil {
+			return err
+		}
+
+		db.Insert(Record{Network: network, ASN: asn})
+
+		return nil
+	})
+}
+
+// LoadIPToASN reads an iptoasn.com dataset, one tab separated start address,
+// end address, AS, country and AS description per line.
+//
+// Ranges are split into the prefixes that cover them, ranges that aren't
+// routed (AS 0) are skipped, and the description fills in the ASN information
+// unless it's already known.
+func (db *DB) LoadIPToASN(r io.Reader) error {
+	return scanLines(r, "\t", func(fields []string) error {
+		if len(fields) != 5 {
+			return fmt.Errorf("expected 5 fields, got %d", len(fields))
+		}
+
+		start, end := net.ParseIP(fields[0]), net.ParseIP(fields[1])
+		if start == nil || end == nil {
+			return fmt.Errorf("invalid range %s - %s", fields[0], fields[1])
+		}
+
+		asn, err := strconv.Atoi(fields[2])
+		if err != nil {
+			return err
+		}
+
+		if asn == 0 {
+			return nil
+		}
+
+		country := fields[3]
+		if country == "None" {
+			country = ""
+		}
+
+		for _, network := range rangeToNetworks(start, end) {
+			db.Insert(Record{Network: network, ASN: asn, Country: country})
+		}
+
+		if _, known := db.LookupASN(asn); !known {
+			db.InsertASN(ipasn.ASNInfo{ASN: asn, Country: country, Description: fields[4]})
+		}
+
+		return nil
+	})
+}
+
+// LoadASNames reads a list of AS descriptions, one AS number followed by
+// whitespace and the description per line, in the style of RIPE's asn.txt.
+// The AS number may be prefixed with AS.
+//
+// The descriptions replace any that are already known.
+func (db *DB) LoadASNames(r io.Reader) error {
+	return scanLines(r, "", func(fields []string) error {
+		if len(fields) != 2 {
+			return fmt.Errorf("expected an AS and description")
+		}
+
+		number := strings.TrimPrefix(strings.ToUpper(fields[0]), "AS")
+
+		asn, err := strconv.Atoi(number)
+		if err != nil {
+			return err
+		}
+
+		info, _ := db.LookupASN(asn)
+		info.ASN = asn
+		info.Description = strings.TrimSpace(fields[1])
+		db.InsertASN(info)
+
+		return nil
+	})
+}
+
+// scanLines calls fn for every line that isn't blank or a comment, splitting
+// each line by sep or, if sep is empty, into the first word and the rest.
+func scanLines(r io.Reader, sep string, fn func(fields []string) error) error {
+	br, err := decompress(r)
+	if err != nil {
+		return err
+	}
+
+	scanner := bufio.NewScanner(br)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimRight(scanner.Text(), "\r\n")
+		if strings.TrimSpace(text) == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		var fields []string
+
+		if sep != "" {
+			fields = strings.Split(text, sep)
+		} else {
+			text = strings.TrimSpace(text)
+			fields = []string{text}
+
+			if i := strings.IndexAny(text, " \t"); i > 0 {
+				fields = []string{text[:i], text[i+1:]}
+			}
+		}
+
+		if err := fn(fields); err != nil {
+			return fmt.Errorf("line %d: %w", line, err)
+		}
+	}
+
+	return scanner.Err()
+}
+
+// rangeToNetworks splits an inclusive range of addresses into the smallest
+// list of networks that covers it exactly.
+func rangeToNetworks(start, end net.IP) []*net.IPNet {
+	size := net.IPv6len
+	if start.To4() != nil && end.To4() != nil {
+		start, end, size = start.To4(), end.To4(), net.IPv4len
+	}
+
+	from := new(big.Int).SetBytes(start.To16()[net.IPv6len-size:])
+	to := new(big.Int).SetBytes(end.To16()[net.IPv6len-size:])
+	one := big.NewInt(1)
+
+	var networks []*net.IPNet
+
+	for from.Cmp(to) <= 0 {
+		// Grow the block for as long as from stays aligned and it doesn't pass to
+		bits := 0
+		for bits < 8*size && from.Bit(bits) == 0 {
+			last := new(big.Int).Lsh(one, uint(bits+1))
+			last.Add(last, from).Sub(last, one)
+
+			if last.Cmp(to) > 0 {
+				break
+			}
+
+			bits++
+		}
+
+		ip := make(net.IP, size)
+		b := from.Bytes()
+		copy(ip[size-len(b):], b)
+
+		networks = append(networks, &net.IPNet{IP: ip, Mask: net.CIDRMask(8*size-bits, 8*size)})
+
+		from.Add(from, new(big.Int).Lsh(one, uint(bits)))
+	}
+
+	return networks
+}
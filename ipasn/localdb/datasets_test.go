@@ -0,0 +1,158 @@
+// Copyright 2019 Freman/Fremnet (Shannon Wynter). All rights reserved.
+
+package localdb_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"flag"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/freman/cymru/ipasn"
+	"github.com/freman/cymru/ipasn/localdb"
+)
+
+func networks(db *localdb.DB) (nets []string) {
+	for _, rec := range db.Records() {
+		nets = append(nets, rec.Network.String())
+	}
+
+	return nets
+}
+
+func TestLoadPfx2AS(t *testing.T) {
+	t.Parallel()
+
+	db := localdb.New()
+	require.NoError(t, db.LoadPfx2AS(strings.NewReader("1.0.0.0\t24\t13335\n1.0.4.0\t22\t38803\n"+
+		"27.112.0.0\t16\t4134_4812\n45.0.0.0\t8\t64512,64513\n2001:4860::\t32\t15169\n")))
+
+	require.Equal(t, []string{"1.0.0.0/24", "1.0.4.0/22", "27.112.0.0/16", "45.0.0.0/8", "2001:4860::/32"}, networks(db))
+
+	rec, found := db.Lookup(net.ParseIP("27.112.1.1"))
+	require.True(t, found)
+	require.Equal(t, 4134, rec.ASN)
+
+	rec, found = db.Lookup(net.ParseIP("45.1.1.1"))
+	require.True(t, found)
+	require.Equal(t, 64512, rec.ASN)
+
+	require.EqualError(t, localdb.New().LoadPfx2AS(strings.NewReader("1.0.0.0\t24\n")), "line 1: expected 3 fields, got 2")
+	require.EqualError(t, localdb.New().LoadPfx2AS(strings.NewReader("1.0.0.0\t24\tx\n")), `line 1: strconv.Atoi: parsing "x": invalid syntax`)
+	require.Error(t, localdb.New().LoadPfx2AS(strings.NewReader("1.0.0.0\t33\t1\n")))
+	require.EqualError(t, localdb.New().LoadPfx2AS(strings.NewReader("1.0.0.0\t24\t\n")), "line 1: missing AS for 1.0.0.0/24")
+	require.EqualError(t, localdb.New().LoadPfx2AS(strings.NewReader("1.0.0.0\t24\t13335\n1.0.4.0\t22\t_,\n")), "line 2: missing AS for 1.0.4.0/22")
+}
+
+func TestLoadIPToASN(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+
+	gz := gzip.NewWriter(&buf)
+	_, _ = gz.Write([]byte("1.0.0.0\t1.0.0.255\t13335\tUS\tCLOUDFLARENET\n" +
+		"1.0.1.0\t1.0.3.255\t0\tNone\tNot routed\n" +
+		"1.0.4.0\t1.0.6.10\t38803\tAU\tWPL-AS-AP Wirefreebroadband Pty Ltd\n" +
+		"2001:4860::\t2001:4860:ffff:ffff:ffff:ffff:ffff:ffff\t15169\tUS\tGOOGLE\n"))
+	require.NoError(t, gz.Close())
+
+	db := localdb.New()
+	db.InsertASN(ipasn.ASNInfo{ASN: 15169, Description: "GOOGLE - Google LLC, US"})
+	require.NoError(t, db.LoadIPToASN(&buf))
+
+	require.Equal(t, []string{
+		"1.0.0.0/24",
+		"1.0.4.0/23",
+		"1.0.6.0/29",
+		"1.0.6.8/31",
+		"1.0.6.10/32",
+		"2001:4860::/32",
+	}, networks(db))
+
+	rec, found := db.Lookup(net.ParseIP("1.0.6.9"))
+	require.True(t, found)
+	require.Equal(t, 38803, rec.ASN)
+	require.Equal(t, "AU", rec.Country)
+
+	_, found = db.Lookup(net.ParseIP("1.0.2.1"))
+	require.False(t, found)
+
+	info, found := db.LookupASN(38803)
+	require.True(t, found)
+	require.Equal(t, ipasn.ASNInfo{ASN: 38803, Country: "AU", Description: "WPL-AS-AP Wirefreebroadband Pty Ltd"}, info)
+
+	// Existing descriptions are retained
+	info, _ = db.LookupASN(15169)
+	require.Equal(t, "GOOGLE - Google LLC, US", info.Description)
+
+	require.EqualError(t, localdb.New().LoadIPToASN(strings.NewReader("1.0.0.0\tnope\t1\tUS\tX\n")), "line 1: invalid range 1.0.0.0 - nope")
+	require.EqualError(t, localdb.New().LoadIPToASN(strings.NewReader("1.0.0.0\t1.0.0.255\n")), "line 1: expected 5 fields, got 2")
+}
+
+func TestRangeCoversEverything(t *testing.T) {
+	t.Parallel()
+
+	db := localdb.New()
+	require.NoError(t, db.LoadIPToASN(strings.NewReader("0.0.0.0\t255.255.255.255\t1\tZZ\tEverything\n")))
+	require.Equal(t, []string{"0.0.0.0/0"}, networks(db))
+}
+
+func TestLoadASNames(t *testing.T) {
+	t.Parallel()
+
+	db := localdb.New()
+	db.InsertASN(ipasn.ASNInfo{ASN: 13335, Country: "US", Authority: "arin"})
+
+	require.NoError(t, db.LoadASNames(strings.NewReader("# names\n13335 CLOUDFLARENET, US\nAS15169\tGOOGLE, US\n\n")))
+
+	info, found := db.LookupASN(13335)
+	require.True(t, found)
+	require.Equal(t, ipasn.ASNInfo{ASN: 13335, Country: "US", Authority: "arin", Description: "CLOUDFLARENET, US"}, info)
+
+	info, found = db.LookupASN(15169)
+	require.True(t, found)
+	require.Equal(t, "GOOGLE, US", info.Description)
+
+	require.EqualError(t, localdb.New().LoadASNames(strings.NewReader("13335\n")), "line 1: expected an AS and description")
+}
+
+func TestLoadDatasets(t *testing.T) {
+	t.Parallel()
+
+	dir, err := ioutil.TempDir("", "datasets")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	pfx2as := filepath.Join(dir, "pfx2as")
+	asnames := filepath.Join(dir, "asnames")
+	require.NoError(t, ioutil.WriteFile(pfx2as, []byte("1.0.0.0\t24\t13335\n"), 0644))
+	require.NoError(t, ioutil.WriteFile(asnames, []byte("13335 CLOUDFLARENET, US\n"), 0644))
+
+	var d localdb.Datasets
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	d.RegisterFlags(fs)
+	require.NoError(t, fs.Parse([]string{"-pfx2as", pfx2as, "-asnames", asnames}))
+	require.Equal(t, localdb.Datasets{Pfx2AS: []string{pfx2as}, ASNames: []string{asnames}}, d)
+
+	db := localdb.New()
+	require.NoError(t, db.LoadDatasets(d))
+	require.Equal(t, []string{"1.0.0.0/24"}, networks(db))
+
+	a, found := db.LookupASN(13335)
+	require.True(t, found)
+	require.Equal(t, "CLOUDFLARENET, US", a.Description)
+
+	err = localdb.New().LoadDatasets(localdb.Datasets{Pfx2AS: []string{asnames}})
+	require.EqualError(t, err, asnames+": line 1: expected 3 fields, got 1")
+
+	err = localdb.New().LoadDatasets(localdb.Datasets{MRT: []string{filepath.Join(dir, "missing")}})
+	require.True(t, os.IsNotExist(err), err)
+}
@@ -0,0 +1,189 @@
+// Copyright 2019 Freman/Fremnet (Shannon Wynter). All rights reserved.
+
+package localdb
+
+import (
+	"bytes"
+	"net"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/freman/cymru/ipasn"
+)
+
+// Record is everything the database knows about a single prefix.
+//
+// Updated has the same meaning as it does for ipasn.OriginInfo, while Seen is
+// when the record was observed, eg: the time of the RIB dump or the live
+// lookup, and is zero if that isn't known.
+type Record struct {
+	Network   *net.IPNet
+	ASN       int
+	Peers     []int
+	Country   string
+	Authority string
+	Updated   time.Time
+	Seen      time.Time
+}
+
+// DB is an in memory longest prefix match database of Records and ASN
+// descriptions. It's safe for concurrent use.
+type DB struct {
+	mu       sync.RWMutex
+	prefixes map[int]map[[net.IPv6len]byte]*Record
+	lengths  []int
+	asns     map[int]ipasn.ASNInfo
+}
+
+// New returns an empty database
+func New() *DB {
+	return &DB{
+		prefixes: make(map[int]map[[net.IPv6len]byte]*Record),
+		asns:     make(map[int]ipasn.ASNInfo),
+	}
+}
+
+// Insert adds the record to the database, replacing any existing record for
+// the same prefix.
+func (db *DB) Insert(rec Record) {
+	key, bits := prefixKey(rec.Network.IP, rec.Network.Mask)
+
+	rec.Network = &net.IPNet{IP: rec.Network.IP.Mask(rec.Network.Mask), Mask: rec.Network.Mask}
+
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	table, exists := db.prefixes[bits]
+	if !exists {
+		table = make(map[[net.IPv6len]byte]*Record)
+		db.prefixes[bits] = table
+
+		db.lengths = append(db.lengths, bits)
+		sort.Sort(sort.Reverse(sort.IntSlice(db.lengths)))
+	}
+
+	table[key] = &rec
+}
+
+// InsertASN adds the ASN description to the database, replacing any existing
+// description for the same ASN.
+func (db *DB) InsertASN(info ipasn.ASNInfo) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	db.asns[info.ASN] = info
+}
+
+// Lookup returns the record with the longest prefix containing ip.
+func (db *DB) Lookup(ip net.IP) (Record, bool) {
+	ip16 := ip.To16()
+	if ip16 == nil {
+		return Record{}, false
+	}
+
+	minBits := 0
+	if ip.To4() != nil {
+		minBits = 8 * (net.IPv6len - net.IPv4len)
+	}
+
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	for _, bits := range db.lengths {
+		if bits < minBits {
+			break
+		}
+
+		key, _ := prefixKey(ip16, net.CIDRMask(bits, 8*net.IPv6len))
+		if rec, found := db.prefixes[bits][key]; found {
+			return *rec, true
+		}
+	}
+
+	return Record{}, false
+}
+
+// LookupASN returns the description of the given ASN
+func (db *DB) LookupASN(asn int) (ipasn.ASNInfo, bool) {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	info, found := db.asns[asn]
+
+	return info, found
+}
+
+// Len returns the number of prefixes in the database
+func (db *DB) Len() int {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	n := 0
+	for _, table := range db.prefixes {
+		n += len(table)
+	}
+
+	return n
+}
+
+// Records returns every record in the database, IPv4 first, in address order.
+func (db *DB) Records() []Record {
+	db.mu.RLock()
+
+	recs := make([]Record, 0, len(db.prefixes))
+	for _, table := range db.prefixes {
+		for _, rec := range table {
+			recs = append(recs, *rec)
+		}
+	}
+
+	db.mu.RUnlock()
+
+	sort.Slice(recs, func(i, j int) bool {
+		a, b := recs[i].Network, recs[j].Network
+		if len(a.IP) != len(b.IP) {
+			return len(a.IP) < len(b.IP)
+		}
+
+		if c := bytes.Compare(a.IP, b.IP); c != 0 {
+			return c < 0
+		}
+
+		ai, _ := a.Mask.Size()
+		bi, _ := b.Mask.Size()
+
+		return ai < bi
+	})
+
+	return recs
+}
+
+// ASNs returns every ASN description in the database, in ASN order.
+func (db *DB) ASNs() []ipasn.ASNInfo {
+	db.mu.RLock()
+
+	infos := make([]ipasn.ASNInfo, 0, len(db.asns))
+	for _, info := range db.asns {
+		infos = append(infos, info)
+	}
+
+	db.mu.RUnlock()
+
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].ASN < infos[j].ASN
+	})
+
+	return infos
+}
+
+// prefixKey maps both IPv4 and IPv6 prefixes onto the IPv6 address space so
+// they can share the same tables.
+func prefixKey(ip net.IP, mask net.IPMask) (key [net.IPv6len]byte, bits int) {
+	ones, size := mask.Size()
+	bits = ones + 8*net.IPv6len - size
+
+	copy(key[:], ip.To16().Mask(net.CIDRMask(bits, 8*net.IPv6len)))
+
+	return key, bits
+}
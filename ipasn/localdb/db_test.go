@@ -0,0 +1,96 @@
+// Copyright 2019 Freman/Fremnet (Shannon Wynter). All rights reserved.
+
+package localdb_test
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/freman/cymru/ipasn"
+	"github.com/freman/cymru/ipasn/localdb"
+)
+
+func mustCIDR(s string) *net.IPNet {
+	_, n, err := net.ParseCIDR(s)
+	if err != nil {
+		panic(err)
+	}
+
+	return n
+}
+
+func testDB() *localdb.DB {
+	db := localdb.New()
+
+	db.Insert(localdb.Record{Network: mustCIDR("216.90.108.0/24"), ASN: 23028, Peers: []int{701, 1239}, Country: "US", Authority: "arin", Updated: time.Date(1998, 9, 25, 0, 0, 0, 0, time.UTC), Seen: time.Date(2019, 12, 1, 0, 0, 0, 0, time.UTC)})
+	db.Insert(localdb.Record{Network: mustCIDR("216.0.0.0/8"), ASN: 1, Country: "US", Authority: "arin"})
+	db.Insert(localdb.Record{Network: mustCIDR("2001:4860::/32"), ASN: 15169, Country: "US", Authority: "arin", Updated: time.Date(2005, 3, 14, 0, 0, 0, 0, time.UTC)})
+	db.Insert(localdb.Record{Network: mustCIDR("::/0"), ASN: 2})
+	db.InsertASN(ipasn.ASNInfo{ASN: 23028, Country: "US", Authority: "arin", Updated: time.Date(2002, 1, 4, 0, 0, 0, 0, time.UTC), Description: "TEAM-CYMRU - Team Cymru Inc., US"})
+
+	return db
+}
+
+func TestLookup(t *testing.T) {
+	t.Parallel()
+
+	db := testDB()
+	require.Equal(t, 4, db.Len())
+
+	tests := []struct {
+		ip       string
+		expected int
+	}{
+		{"216.90.108.31", 23028},
+		{"216.90.109.1", 1},
+		{"217.0.0.1", 0},
+		{"2001:4860:b002::68", 15169},
+		{"2002::1", 2},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.ip, func(t *testing.T) {
+			t.Parallel()
+
+			rec, found := db.Lookup(net.ParseIP(test.ip))
+			require.Equal(t, test.expected != 0, found)
+			require.Equal(t, test.expected, rec.ASN)
+		})
+	}
+}
+
+func TestInsertReplaces(t *testing.T) {
+	t.Parallel()
+
+	db := localdb.New()
+	db.Insert(localdb.Record{Network: mustCIDR("10.0.0.0/8"), ASN: 1})
+	db.Insert(localdb.Record{Network: &net.IPNet{IP: net.IPv4(10, 1, 2, 3), Mask: net.CIDRMask(8, 32)}, ASN: 2})
+
+	require.Equal(t, 1, db.Len())
+
+	rec, found := db.Lookup(net.IPv4(10, 0, 0, 1))
+	require.True(t, found)
+	require.Equal(t, 2, rec.ASN)
+	require.Equal(t, "10.0.0.0/8", rec.Network.String())
+}
+
+func TestRecords(t *testing.T) {
+	t.Parallel()
+
+	db := testDB()
+
+	var nets []string
+	for _, rec := range db.Records() {
+		nets = append(nets, rec.Network.String())
+	}
+
+	require.Equal(t, []string{"216.0.0.0/8", "216.90.108.0/24", "::/0", "2001:4860::/32"}, nets)
+
+	asns := db.ASNs()
+	require.Len(t, asns, 1)
+	require.Equal(t, 23028, asns[0].ASN)
+}
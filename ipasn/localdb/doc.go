@@ -0,0 +1,5 @@
+// Copyright 2019 Freman/Fremnet (Shannon Wynter). All rights reserved.
+
+// Package localdb implements an offline, in memory, IP-ASN database that can be loaded from BGP RIB dumps
+// and queried through an unmodified ipasn.Client by using it as the Resolver.
+package localdb
@@ -0,0 +1,87 @@
+// Copyright 2019 Freman/Fremnet (Shannon Wynter). All rights reserved.
+
+package localdb
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// Datasets names the files of each format to load into a DB, eg: from command
+// line flags, see RegisterFlags. The name - is stdin.
+type Datasets struct {
+	MRT     []string
+	Pfx2AS  []string
+	IPToASN []string
+	ASNames []string
+}
+
+// RegisterFlags registers the repeatable -mrt, -pfx2as, -iptoasn and -asnames
+// flags in fs
+func (d *Datasets) RegisterFlags(fs *flag.FlagSet) {
+	fs.Var((*fileList)(&d.MRT), "mrt", "MRT TABLE_DUMP_V2 RIB dump to load, - for stdin (repeatable)")
+	fs.Var((*fileList)(&d.Pfx2AS), "pfx2as", "CAIDA RouteViews prefix to AS file to load, - for stdin (repeatable)")
+	fs.Var((*fileList)(&d.IPToASN), "iptoasn", "iptoasn.com TSV file to load, - for stdin (repeatable)")
+	fs.Var((*fileList)(&d.ASNames), "asnames", "AS description file to load, - for stdin (repeatable)")
+}
+
+// LoadDatasets loads every file in d, MRT dumps first and AS descriptions
+// last, stopping at the first that fails.
+func (db *DB) LoadDatasets(d Datasets) error {
+	loaders := []struct {
+		files []string
+		load  func(io.Reader) error
+	}{
+		{d.MRT, db.LoadMRT},
+		{d.Pfx2AS, db.LoadPfx2AS},
+		{d.IPToASN, db.LoadIPToASN},
+		{d.ASNames, db.LoadASNames},
+	}
+
+	for _, loader := range loaders {
+		for _, name := range loader.files {
+			if err := LoadFile(name, loader.load); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// LoadFile calls load with the named file, or stdin for -, prefixing any
+// error with the name.
+func LoadFile(name string, load func(io.Reader) error) error {
+	r := io.Reader(os.Stdin)
+
+	if name != "-" {
+		fh, err := os.Open(name)
+		if err != nil {
+			return err
+		}
+		defer fh.Close()
+
+		r = fh
+	}
+
+	if err := load(r); err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+
+	return nil
+}
+
+// fileList is a repeatable flag.Value
+type fileList []string
+
+func (f *fileList) String() string {
+	return strings.Join(*f, ",")
+}
+
+func (f *fileList) Set(s string) error {
+	*f = append(*f, s)
+	return nil
+}
@@ -0,0 +1,247 @@
+// Copyright 2019 Freman/Fremnet (Shannon Wynter). All rights reserved.
+
+package localdb
+
+import (
+	"context"
+	"net"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/freman/cymru/ipasn"
+)
+
+// Hybrid answers queries from a local Backend first, falling back to live
+// lookups when the backend doesn't cover the query or its record was seen
+// longer ago than MaxAge.
+//
+// Hybrid implements ipasn.Resolver so it can sit underneath any ipasn.Client,
+// its Origin, Peer and ASN methods do exactly that using Client. Only names in
+// the Team Cymru zones, or the mirror of them Client's Provider queries, can
+// be answered locally, anything else bypasses the local tier.
+type Hybrid struct {
+	stats HybridStats
+
+	// Local is the first tier, typically a DB or Snapshot
+	Local Backend
+
+	// Client provides the live tier, its Resolver is used for the live
+	// lookups and its other settings are used by Origin, Peer and ASN.
+	// It defaults to the zero Client.
+	Client *ipasn.Client
+
+	// MaxAge is how long after being seen a local record is still used, records
+	// that were never seen are always used. Zero disables the age check.
+	MaxAge time.Duration
+
+	// WriteBack, if set, receives the answers to live lookups. Set it to the
+	// same DB as Local for those answers to be used next time.
+	WriteBack *DB
+}
+
+// HybridStats counts which tier served each query
+type HybridStats struct {
+	// Local is the number of queries answered by the local backend
+	Local uint64
+
+	// Live is the number of queries answered by live lookups
+	Live uint64
+
+	// Misses is the number of queries the local backend couldn't answer
+	Misses uint64
+
+	// Stale is the number of queries the local backend had a record for, but
+	// it was too old to use
+	Stale uint64
+
+	// Bypassed is the number of queries answered by live lookups without
+	// asking the local backend, as it can't answer them, eg: because Client's
+	// Provider isn't Cymru
+	Bypassed uint64
+
+	// Errors is the number of live lookups that failed
+	Errors uint64
+}
+
+// Stats returns a snapshot of the counters
+func (h *Hybrid) Stats() HybridStats {
+	return HybridStats{
+		Local:    atomic.LoadUint64(&h.stats.Local),
+		Live:     atomic.LoadUint64(&h.stats.Live),
+		Misses:   atomic.LoadUint64(&h.stats.Misses),
+		Stale:    atomic.LoadUint64(&h.stats.Stale),
+		Bypassed: atomic.LoadUint64(&h.stats.Bypassed),
+		Errors:   atomic.LoadUint64(&h.stats.Errors),
+	}
+}
+
+// LookupTXT implements ipasn.Resolver
+func (h *Hybrid) LookupTXT(ctx context.Context, name string) ([]string, error) {
+	zones, local := h.zones()
+
+	q, err := parseQuery(zones, name)
+	if !local {
+		err = ipasn.ErrUnsupported
+	}
+
+	if err == nil && h.Local != nil {
+		vals, seen := answer(h.Local, q)
+
+		switch {
+		case vals == nil:
+			atomic.AddUint64(&h.stats.Misses, 1)
+		case h.MaxAge > 0 && !seen.IsZero() && time.Since(seen) > h.MaxAge:
+			atomic.AddUint64(&h.stats.Stale, 1)
+		default:
+			atomic.AddUint64(&h.stats.Local, 1)
+			return vals, nil
+		}
+	}
+
+	resolver := ipasn.Resolver(net.DefaultResolver)
+	if h.Client != nil && h.Client.Resolver != nil {
+		resolver = h.Client.Resolver
+	}
+
+	vals, lerr := resolver.LookupTXT(ctx, name)
+	if lerr != nil {
+		atomic.AddUint64(&h.stats.Errors, 1)
+		return nil, lerr
+	}
+
+	if err != nil {
+		atomic.AddUint64(&h.stats.Bypassed, 1)
+	} else {
+		atomic.AddUint64(&h.stats.Live, 1)
+	}
+
+	if err == nil && h.WriteBack != nil && len(vals) > 0 {
+		h.writeBack(q, strings.Split(vals[0], " | "))
+	}
+
+	return vals, nil
+}
+
+// Origin looks up the BGP Origin ASN for ip using Client with the Hybrid as
+// its resolver
+func (h *Hybrid) Origin(ctx context.Context, ip net.IP, opts ...ipasn.CallOption) (ipasn.OriginInfo, error) {
+	return h.client().Origin(ctx, ip, opts...)
+}
+
+// Peer looks up the BGP peer ASNs for ip using Client with the Hybrid as its
+// resolver
+func (h *Hybrid) Peer(ctx context.Context, ip net.IP, opts ...ipasn.CallOption) (ipasn.PeerInfo, error) {
+	return h.client().Peer(ctx, ip, opts...)
+}
+
+// ASN looks up the AS description using Client with the Hybrid as its resolver
+func (h *Hybrid) ASN(ctx context.Context, asn int, opts ...ipasn.CallOption) (ipasn.ASNInfo, error) {
+	return h.client().ASN(ctx, asn, opts...)
+}
+
+func (h *Hybrid) client() *ipasn.Client {
+	var c ipasn.Client
+	if h.Client != nil {
+		c = *h.Client
+	}
+
+	c.Resolver = h
+
+	return &c
+}
+
+// zones returns the zones of Client's Provider, reporting false if it isn't
+// Cymru so its names and answers are in another format
+func (h *Hybrid) zones() (ipasn.Zones, bool) {
+	if h.Client == nil {
+		return ipasn.DefaultZones(), true
+	}
+
+	switch p := h.Client.Provider.(type) {
+	case nil:
+		return h.Client.Zones, true
+	case ipasn.Cymru:
+		return p.Zones, true
+	case *ipasn.Cymru:
+		return p.Zones, true
+	}
+
+	return ipasn.Zones{}, false
+}
+
+// writeBack parses a live answer into the WriteBack database, answers that
+// can't be parsed are ignored.
+func (h *Hybrid) writeBack(q ipasn.Query, fields []string) {
+	if q.Zone == "asn" {
+		if len(fields) < 5 {
+			return
+		}
+
+		asn, err := strconv.Atoi(fields[0])
+		if err != nil {
+			return
+		}
+
+		updated, _ := time.Parse(dateFormat, fields[3])
+		h.WriteBack.InsertASN(ipasn.ASNInfo{
+			ASN:         asn,
+			Country:     fields[1],
+			Authority:   fields[2],
+			Updated:     updated,
+			Description: strings.Join(fields[4:], " | "),
+		})
+
+		return
+	}
+
+	if len(fields) != 5 {
+		return
+	}
+
+	_, network, err := net.ParseCIDR(fields[1])
+	if err != nil {
+		return
+	}
+
+	// A covering prefix is a different network, so nothing is carried over
+	var rec Record
+
+	existing, found := h.WriteBack.Lookup(q.IP)
+	if found && existing.Network.String() == network.String() {
+		rec = existing
+	} else {
+		found = false
+	}
+
+	if q.Zone == "peer" {
+		// Without the origin there's nothing useful to record
+		if !found {
+			return
+		}
+
+		rec.Peers = rec.Peers[:0:0]
+
+		for _, f := range strings.Fields(fields[0]) {
+			if asn, err := strconv.Atoi(f); err == nil {
+				rec.Peers = append(rec.Peers, asn)
+			}
+		}
+	} else {
+		asn, err := strconv.Atoi(fields[0])
+		if err != nil {
+			return
+		}
+
+		rec.Network = network
+		rec.ASN = asn
+	}
+
+	rec.Country = fields[2]
+	rec.Authority = fields[3]
+	rec.Updated, _ = time.Parse(dateFormat, fields[4])
+	rec.Seen = time.Now()
+
+	h.WriteBack.Insert(rec)
+}
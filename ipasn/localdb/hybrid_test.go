@@ -0,0 +1,198 @@
+// Copyright 2019 Freman/Fremnet (Shannon Wynter). All rights reserved.
+
+package localdb_test
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/freman/cymru/ipasn"
+	"github.com/freman/cymru/ipasn/localdb"
+)
+
+type liveResolver map[string]string
+
+func (l liveResolver) LookupTXT(ctx context.Context, name string) ([]string, error) {
+	if v, ok := l[name]; ok {
+		return []string{v}, nil
+	}
+
+	return nil, errors.New("what? " + name + " not found")
+}
+
+//nolint:gochecknoglobals
+var live = liveResolver{
+	"31.108.90.216.origin.asn.cymru.com.": "23028 | 216.90.108.0/24 | US | arin | 1998-09-25",
+	"31.108.90.216.peer.asn.cymru.com.":   "3257 23352 | 216.90.108.0/24 | US | arin | 1998-09-25",
+	"1.1.1.1.origin.asn.cymru.com.":       "13335 | 1.1.1.0/24 | AU | apnic | 2011-08-11",
+	"1.1.1.1.peer.asn.cymru.com.":         "174 2914 | 1.1.1.0/24 | AU | apnic | 2011-08-11",
+	"AS13335.asn.cymru.com.":              "13335 | US | arin | 2010-07-14 | CLOUDFLARENET - Cloudflare, Inc., US",
+}
+
+func TestHybrid(t *testing.T) {
+	t.Parallel()
+
+	h := &localdb.Hybrid{
+		Local:  testDB(),
+		Client: &ipasn.Client{Resolver: live},
+	}
+
+	peer, err := h.Peer(context.TODO(), net.ParseIP("216.90.108.31"))
+	require.NoError(t, err)
+	require.Equal(t, []int{701, 1239}, peer.ASNs)
+
+	origin, err := h.Origin(context.TODO(), net.ParseIP("1.1.1.1"))
+	require.NoError(t, err)
+	require.Equal(t, 13335, origin.ASN)
+
+	_, err = h.Origin(context.TODO(), net.ParseIP("8.8.8.8"))
+	require.EqualError(t, err, "lookup 8.8.8.8.origin.asn.cymru.com.: what? 8.8.8.8.origin.asn.cymru.com. not found")
+
+	_, err = h.Origin(context.TODO(), net.ParseIP("192.168.0.1"))
+	require.Equal(t, ipasn.ErrIPIsPrivate, err)
+
+	require.Equal(t, localdb.HybridStats{Local: 1, Live: 1, Misses: 2, Errors: 1}, h.Stats())
+}
+
+func TestHybridStale(t *testing.T) {
+	t.Parallel()
+
+	h := &localdb.Hybrid{
+		Local:  testDB(),
+		Client: &ipasn.Client{Resolver: live},
+		MaxAge: 24 * time.Hour,
+	}
+
+	// Seen long ago, so served live
+	peer, err := h.Peer(context.TODO(), net.ParseIP("216.90.108.31"))
+	require.NoError(t, err)
+	require.Equal(t, []int{3257, 23352}, peer.ASNs)
+
+	// Never seen, so still served locally
+	origin, err := h.Origin(context.TODO(), net.ParseIP("2001:4860::1"))
+	require.NoError(t, err)
+	require.Equal(t, 15169, origin.ASN)
+
+	// ASN descriptions are never stale
+	asn, err := h.ASN(context.TODO(), 23028)
+	require.NoError(t, err)
+	require.Equal(t, "TEAM-CYMRU - Team Cymru Inc., US", asn.Description)
+
+	require.Equal(t, localdb.HybridStats{Local: 2, Live: 1, Stale: 1}, h.Stats())
+}
+
+func TestHybridWriteBack(t *testing.T) {
+	t.Parallel()
+
+	db := localdb.New()
+	h := &localdb.Hybrid{
+		Local:     db,
+		Client:    &ipasn.Client{Resolver: live},
+		WriteBack: db,
+	}
+
+	// Peers can't be written back without the origin
+	_, err := h.Peer(context.TODO(), net.ParseIP("1.1.1.1"))
+	require.NoError(t, err)
+	require.Equal(t, 0, db.Len())
+
+	for i := 0; i < 2; i++ {
+		origin, err := h.Origin(context.TODO(), net.ParseIP("1.1.1.1"))
+		require.NoError(t, err)
+		require.Equal(t, "13335 | 1.1.1.0/24 | AU | apnic | 2011-08-11", origin.String())
+
+		peer, err := h.Peer(context.TODO(), net.ParseIP("1.1.1.1"))
+		require.NoError(t, err)
+		require.Equal(t, []int{174, 2914}, peer.ASNs)
+
+		asn, err := h.ASN(context.TODO(), 13335)
+		require.NoError(t, err)
+		require.Equal(t, "CLOUDFLARENET - Cloudflare, Inc., US", asn.Description)
+	}
+
+	rec, found := db.Lookup(net.ParseIP("1.1.1.1"))
+	require.True(t, found)
+	require.WithinDuration(t, time.Now(), rec.Seen, time.Minute)
+	require.Equal(t, []int{174, 2914}, rec.Peers)
+
+	require.Equal(t, localdb.HybridStats{Local: 3, Live: 4, Misses: 4}, h.Stats())
+}
+
+func TestHybridWriteBackCovered(t *testing.T) {
+	t.Parallel()
+
+	_, covering, _ := net.ParseCIDR("1.0.0.0/8")
+
+	db := localdb.New()
+	db.Insert(localdb.Record{Network: covering, ASN: 100, Peers: []int{100, 200}})
+
+	h := &localdb.Hybrid{
+		Local:     localdb.New(),
+		Client:    &ipasn.Client{Resolver: live},
+		WriteBack: db,
+	}
+
+	_, err := h.Origin(context.TODO(), net.ParseIP("1.1.1.1"))
+	require.NoError(t, err)
+
+	rec, found := db.Lookup(net.ParseIP("1.1.1.1"))
+	require.True(t, found)
+	require.Equal(t, "1.1.1.0/24", rec.Network.String())
+	require.Equal(t, 13335, rec.ASN)
+	require.Empty(t, rec.Peers)
+
+	// The covering prefix is left alone
+	rec, found = db.Lookup(net.ParseIP("1.2.3.4"))
+	require.True(t, found)
+	require.Equal(t, []int{100, 200}, rec.Peers)
+}
+
+func TestHybridZones(t *testing.T) {
+	t.Parallel()
+
+	// A mirror is answered locally the same as the Team Cymru zones
+	h := &localdb.Hybrid{
+		Local:  testDB(),
+		Client: &ipasn.Client{Resolver: live, Zones: ipasn.ZonesUnder("asn.example.net")},
+	}
+
+	peer, err := h.Peer(context.TODO(), net.ParseIP("216.90.108.31"))
+	require.NoError(t, err)
+	require.Equal(t, []int{701, 1239}, peer.ASNs)
+
+	asn, err := h.ASN(context.TODO(), 23028)
+	require.NoError(t, err)
+	require.Equal(t, "TEAM-CYMRU - Team Cymru Inc., US", asn.Description)
+
+	require.Equal(t, localdb.HybridStats{Local: 2}, h.Stats())
+
+	// As are mirrors set through the Provider
+	h = &localdb.Hybrid{
+		Local:  testDB(),
+		Client: &ipasn.Client{Resolver: live, Provider: ipasn.Cymru{Zones: ipasn.ZonesUnder("asn.example.net")}},
+	}
+
+	origin, err := h.Origin(context.TODO(), net.ParseIP("2001:4860::1"))
+	require.NoError(t, err)
+	require.Equal(t, 15169, origin.ASN)
+	require.Equal(t, localdb.HybridStats{Local: 1}, h.Stats())
+
+	// Other providers can't be answered locally, so go straight to the live tier
+	h = &localdb.Hybrid{
+		Local: testDB(),
+		Client: &ipasn.Client{
+			Resolver: liveResolver{"31.108.90.216.asn.rspamd.com.": "23028|216.90.108.0/24|US|arin|"},
+			Provider: ipasn.Rspamd{},
+		},
+	}
+
+	origin, err = h.Origin(context.TODO(), net.ParseIP("216.90.108.31"))
+	require.NoError(t, err)
+	require.Equal(t, 23028, origin.ASN)
+	require.Equal(t, localdb.HybridStats{Bypassed: 1}, h.Stats())
+}
@@ -0,0 +1,21 @@
+// Copyright 2019 Freman/Fremnet (Shannon Wynter). All rights reserved.
+
+//go:build !darwin && !dragonfly && !freebsd && !linux && !netbsd && !openbsd && !solaris
+// +build !darwin,!dragonfly,!freebsd,!linux,!netbsd,!openbsd,!solaris
+
+package localdb
+
+import (
+	"io/ioutil"
+	"os"
+)
+
+// mmap falls back to reading the whole file where memory mapping isn't supported
+func mmap(fh *os.File, _ int) ([]byte, func() error, error) {
+	data, err := ioutil.ReadAll(fh)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return data, func() error { return nil }, nil
+}
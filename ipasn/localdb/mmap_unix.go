@@ -0,0 +1,22 @@
+// Copyright 2019 Freman/Fremnet (Shannon Wynter). All rights reserved.
+
+//go:build darwin || dragonfly || freebsd || linux || netbsd || openbsd || solaris
+// +build darwin dragonfly freebsd linux netbsd openbsd solaris
+
+package localdb
+
+import (
+	"os"
+	"syscall"
+)
+
+func mmap(fh *os.File, size int) ([]byte, func() error, error) {
+	data, err := syscall.Mmap(int(fh.Fd()), 0, size, syscall.PROT_READ, syscall.MAP_SHARED)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return data, func() error {
+		return syscall.Munmap(data)
+	}, nil
+}
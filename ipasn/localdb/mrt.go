@@ -0,0 +1,278 @@
+// Copyright 2019 Freman/Fremnet (Shannon Wynter). All rights reserved.
+
+package localdb
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"os"
+	"sort"
+	"time"
+)
+
+// MRT types and subtypes as defined by RFC 6396 and RFC 8050
+const (
+	mrtTableDumpV2 = 13
+
+	mrtRIBIPv4Unicast        = 2
+	mrtRIBIPv6Unicast        = 4
+	mrtRIBIPv4UnicastAddPath = 8
+	mrtRIBIPv6UnicastAddPath = 10
+
+	bgpAttrExtendedLength = 0x10
+	bgpAttrASPath         = 2
+
+	bgpASSet      = 1
+	bgpASSequence = 2
+
+	// maxMRTRecord is far larger than any real RIB record, a prefix seen by
+	// every peer of a collector is still only tens of kilobytes
+	maxMRTRecord = 16 << 20
+)
+
+// ErrMRTTruncated is returned when an MRT record is shorter than its contents claim
+var ErrMRTTruncated = errors.New("localdb: truncated MRT record")
+
+// ErrMRTTooLarge is returned when an MRT record claims to be larger than any
+// real RIB record could be
+var ErrMRTTooLarge = errors.New("localdb: MRT record too large")
+
+// LoadMRT reads an MRT TABLE_DUMP_V2 RIB dump, as published by RouteViews and
+// RIPE RIS, adding a Record for every prefix in it.
+//
+// The origin of each prefix is the most commonly seen last AS in the AS path,
+// the peers are every AS seen immediately before that origin. The Updated and
+// Seen times are the time of the dump.
+//
+// Other MRT record types are skipped, gzip and bzip2 compressed dumps are
+// decompressed automatically.
+func (db *DB) LoadMRT(r io.Reader) error {
+	br, err := decompress(r)
+	if err != nil {
+		return err
+	}
+
+	header := make([]byte, 12)
+
+	for {
+		if _, err := io.ReadFull(br, header); err != nil {
+			if err == io.EOF {
+				return nil
+			}
+
+			return err
+		}
+
+		timestamp := time.Unix(int64(binary.BigEndian.Uint32(header[0:4])), 0).UTC()
+		typ := binary.BigEndian.Uint16(header[4:6])
+		subtype := binary.BigEndian.Uint16(header[6:8])
+		length := binary.BigEndian.Uint32(header[8:12])
+
+		if typ != mrtTableDumpV2 {
+			if _, err := io.CopyN(ioutil.Discard, br, int64(length)); err != nil {
+				return ErrMRTTruncated
+			}
+
+			continue
+		}
+
+		if length > maxMRTRecord {
+			return ErrMRTTooLarge
+		}
+
+		body := make([]byte, length)
+		if _, err := io.ReadFull(br, body); err != nil {
+			return ErrMRTTruncated
+		}
+
+		switch subtype {
+		case mrtRIBIPv4Unicast:
+			err = db.loadRIB(body, net.IPv4len, false, timestamp)
+		case mrtRIBIPv6Unicast:
+			err = db.loadRIB(body, net.IPv6len, false, timestamp)
+		case mrtRIBIPv4UnicastAddPath:
+			err = db.loadRIB(body, net.IPv4len, true, timestamp)
+		case mrtRIBIPv6UnicastAddPath:
+			err = db.loadRIB(body, net.IPv6len, true, timestamp)
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// LoadMRTFile opens the named MRT file and loads it with LoadMRT.
+func (db *DB) LoadMRTFile(path string) error {
+	fh, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer fh.Close()
+
+	if err := db.LoadMRT(fh); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+
+	return nil
+}
+
+// mrtReader is a cheap and nasty bounds checked reader for MRT bodies
+type mrtReader struct {
+	buf []byte
+	err error
+}
+
+func (m *mrtReader) next(n int) []byte {
+	if m.err != nil || n > len(m.buf) {
+		m.err = ErrMRTTruncated
+		return make([]byte, n)
+	}
+
+	b := m.buf[:n]
+	m.buf = m.buf[n:]
+
+	return b
+}
+
+func (m *mrtReader) uint8() int {
+	return int(m.next(1)[0])
+}
+
+func (m *mrtReader) uint16() int {
+	return int(binary.BigEndian.Uint16(m.next(2)))
+}
+
+func (m *mrtReader) uint32() uint32 {
+	return binary.BigEndian.Uint32(m.next(4))
+}
+
+// loadRIB parses a RIB_IPV4_UNICAST or RIB_IPV6_UNICAST record
+func (db *DB) loadRIB(body []byte, addrLen int, addPath bool, timestamp time.Time) error {
+	m := &mrtReader{buf: body}
+
+	m.uint32() // sequence number
+
+	bits := m.uint8()
+	if bits > 8*addrLen {
+		return fmt.Errorf("localdb: invalid MRT prefix length %d", bits)
+	}
+
+	ip := make(net.IP, addrLen)
+	copy(ip, m.next((bits+7)/8))
+
+	origins := make(map[int]int)
+	peers := make(map[int]map[int]bool)
+
+	for entries := m.uint16(); entries > 0 && m.err == nil; entries-- {
+		m.uint16() // peer index
+		m.uint32() // originated time
+
+		if addPath {
+			m.uint32() // path identifier
+		}
+
+		path := asPath(m.next(m.uint16()))
+		if len(path) == 0 {
+			continue
+		}
+
+		origin := path[len(path)-1]
+		origins[origin]++
+
+		for i := len(path) - 2; i >= 0; i-- {
+			if path[i] != origin {
+				if peers[origin] == nil {
+					peers[origin] = make(map[int]bool)
+				}
+
+				peers[origin][path[i]] = true
+
+				break
+			}
+		}
+	}
+
+	if m.err != nil {
+		return m.err
+	}
+
+	if len(origins) == 0 {
+		return nil
+	}
+
+	origin := 0
+	for asn, seen := range origins {
+		if seen > origins[origin] || seen == origins[origin] && asn < origin {
+			origin = asn
+		}
+	}
+
+	rec := Record{
+		Network: &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, 8*addrLen)},
+		ASN:     origin,
+		Updated: timestamp,
+		Seen:    timestamp,
+	}
+
+	for peer := range peers[origin] {
+		rec.Peers = append(rec.Peers, peer)
+	}
+
+	sort.Ints(rec.Peers)
+
+	db.Insert(rec)
+
+	return nil
+}
+
+// asPath extracts the AS_PATH from a set of BGP path attributes, flattening
+// AS_SEQUENCE segments and taking the lowest AS of any AS_SET.
+//
+// TABLE_DUMP_V2 always encodes ASNs as 4 bytes.
+func asPath(attrs []byte) (path []int) {
+	m := &mrtReader{buf: attrs}
+
+	for len(m.buf) > 0 && m.err == nil {
+		flags := m.uint8()
+		typ := m.uint8()
+
+		length := 0
+		if flags&bgpAttrExtendedLength != 0 {
+			length = m.uint16()
+		} else {
+			length = m.uint8()
+		}
+
+		value := m.next(length)
+		if typ != bgpAttrASPath || m.err != nil {
+			continue
+		}
+
+		segments := &mrtReader{buf: value}
+		for len(segments.buf) > 0 && segments.err == nil {
+			segType := segments.uint8()
+			count := segments.uint8()
+
+			asns := make([]int, count)
+			for i := range asns {
+				asns[i] = int(segments.uint32())
+			}
+
+			switch {
+			case segments.err != nil:
+			case segType == bgpASSequence:
+				path = append(path, asns...)
+			case segType == bgpASSet && count > 0:
+				sort.Ints(asns)
+				path = append(path, asns[0])
+			}
+		}
+	}
+
+	return path
+}
@@ -0,0 +1,210 @@
+// Copyright 2019 Freman/Fremnet (Shannon Wynter). All rights reserved.
+
+package localdb_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/freman/cymru/ipasn/localdb"
+)
+
+const dumpTime = 1575000000
+
+// mrtRecord writes an MRT common header followed by the body
+func mrtRecord(buf *bytes.Buffer, typ, subtype uint16, body []byte) {
+	_ = binary.Write(buf, binary.BigEndian, uint32(dumpTime))
+	_ = binary.Write(buf, binary.BigEndian, typ)
+	_ = binary.Write(buf, binary.BigEndian, subtype)
+	_ = binary.Write(buf, binary.BigEndian, uint32(len(body)))
+	buf.Write(body)
+}
+
+// ribEntry builds a RIB entry with an ORIGIN and AS_PATH attribute, each
+// path is a list of segments, a leading -1 marks an AS_SET.
+func ribEntry(addPath bool, segments ...[]int) []byte {
+	var path bytes.Buffer
+
+	for _, seg := range segments {
+		typ := byte(2)
+		if seg[0] < 0 {
+			typ, seg = 1, seg[1:]
+		}
+
+		path.Write([]byte{typ, byte(len(seg))})
+
+		for _, asn := range seg {
+			_ = binary.Write(&path, binary.BigEndian, uint32(asn))
+		}
+	}
+
+	var attrs bytes.Buffer
+
+	attrs.Write([]byte{0x40, 1, 1, 0}) // ORIGIN IGP
+	attrs.Write([]byte{0x50, 2})       // AS_PATH with extended length
+	_ = binary.Write(&attrs, binary.BigEndian, uint16(path.Len()))
+	attrs.Write(path.Bytes())
+
+	var entry bytes.Buffer
+
+	_ = binary.Write(&entry, binary.BigEndian, uint16(0))
+	_ = binary.Write(&entry, binary.BigEndian, uint32(dumpTime))
+
+	if addPath {
+		_ = binary.Write(&entry, binary.BigEndian, uint32(1))
+	}
+
+	_ = binary.Write(&entry, binary.BigEndian, uint16(attrs.Len()))
+	entry.Write(attrs.Bytes())
+
+	return entry.Bytes()
+}
+
+func ribRecord(network string, entries ...[]byte) []byte {
+	_, n, _ := net.ParseCIDR(network)
+	bits, _ := n.Mask.Size()
+
+	var body bytes.Buffer
+
+	_ = binary.Write(&body, binary.BigEndian, uint32(0))
+	body.WriteByte(byte(bits))
+	body.Write(n.IP[:(bits+7)/8])
+	_ = binary.Write(&body, binary.BigEndian, uint16(len(entries)))
+
+	for _, e := range entries {
+		body.Write(e)
+	}
+
+	return body.Bytes()
+}
+
+func testDump() []byte {
+	var buf bytes.Buffer
+
+	// PEER_INDEX_TABLE, not needed but always present
+	mrtRecord(&buf, 13, 1, []byte{1, 2, 3, 4, 0, 0, 0, 0})
+
+	// Some other MRT type to be skipped
+	mrtRecord(&buf, 16, 4, []byte{1, 2, 3})
+
+	mrtRecord(&buf, 13, 2, ribRecord("1.1.1.0/24",
+		ribEntry(false, []int{3356, 13335}),
+		ribEntry(false, []int{174, 13335, 13335}),
+		ribEntry(false, []int{2914, 64512}),
+	))
+	mrtRecord(&buf, 13, 2, ribRecord("10.0.0.0/8"))
+	mrtRecord(&buf, 13, 8, ribRecord("216.90.108.0/24",
+		ribEntry(true, []int{701, 23028}),
+		ribEntry(true, []int{1239, 3549}, []int{-1, 23029, 23028}),
+	))
+	mrtRecord(&buf, 13, 4, ribRecord("2606:4700::/32",
+		ribEntry(false, []int{6939, 13335}),
+	))
+
+	return buf.Bytes()
+}
+
+func TestLoadMRT(t *testing.T) {
+	t.Parallel()
+
+	db := localdb.New()
+	require.NoError(t, db.LoadMRT(bytes.NewReader(testDump())))
+	require.Equal(t, 3, db.Len())
+
+	rec, found := db.Lookup(net.ParseIP("1.1.1.1"))
+	require.True(t, found)
+	require.Equal(t, localdb.Record{
+		Network: &net.IPNet{IP: net.IP{1, 1, 1, 0}, Mask: net.CIDRMask(24, 32)},
+		ASN:     13335,
+		Peers:   []int{174, 3356},
+		Updated: time.Unix(dumpTime, 0).UTC(),
+		Seen:    time.Unix(dumpTime, 0).UTC(),
+	}, rec)
+
+	rec, found = db.Lookup(net.ParseIP("216.90.108.31"))
+	require.True(t, found)
+	require.Equal(t, 23028, rec.ASN)
+	require.Equal(t, []int{701, 3549}, rec.Peers)
+
+	rec, found = db.Lookup(net.ParseIP("2606:4700::6810:84e5"))
+	require.True(t, found)
+	require.Equal(t, 13335, rec.ASN)
+	require.Equal(t, []int{6939}, rec.Peers)
+
+	_, found = db.Lookup(net.ParseIP("10.1.1.1"))
+	require.False(t, found)
+}
+
+func TestLoadMRTTruncated(t *testing.T) {
+	t.Parallel()
+
+	dump := testDump()
+
+	require.Equal(t, localdb.ErrMRTTruncated, localdb.New().LoadMRT(bytes.NewReader(dump[:len(dump)-3])))
+
+	var buf bytes.Buffer
+	mrtRecord(&buf, 13, 2, ribRecord("1.1.1.0/24", ribEntry(false, []int{3356, 13335}))[:12])
+	require.Equal(t, localdb.ErrMRTTruncated, localdb.New().LoadMRT(&buf))
+
+	// Skipped records are still checked
+	buf.Reset()
+	mrtRecord(&buf, 12, 1, make([]byte, 8))
+	require.Equal(t, localdb.ErrMRTTruncated, localdb.New().LoadMRT(bytes.NewReader(buf.Bytes()[:16])))
+}
+
+func TestLoadMRTTooLarge(t *testing.T) {
+	t.Parallel()
+
+	// A header claiming a 4GB body is refused before anything is allocated
+	header := []byte{0, 0, 0, 0, 0, 13, 0, 2, 0xff, 0xff, 0xff, 0xff}
+	require.Equal(t, localdb.ErrMRTTooLarge, localdb.New().LoadMRT(bytes.NewReader(header)))
+
+	// Records that aren't parsed are skipped without being held in memory
+	var buf bytes.Buffer
+	mrtRecord(&buf, 12, 1, make([]byte, 17<<20))
+	buf.Write(testDump())
+
+	db := localdb.New()
+	require.NoError(t, db.LoadMRT(&buf))
+	require.NotZero(t, db.Len())
+}
+
+func TestLoadMRTFile(t *testing.T) {
+	t.Parallel()
+
+	dir, err := ioutil.TempDir("", "localdb")
+	require.NoError(t, err)
+
+	defer os.RemoveAll(dir)
+
+	var buf bytes.Buffer
+
+	gz := gzip.NewWriter(&buf)
+	_, _ = gz.Write(testDump())
+	require.NoError(t, gz.Close())
+
+	path := filepath.Join(dir, "rib.gz")
+	require.NoError(t, ioutil.WriteFile(path, buf.Bytes(), 0600))
+
+	db := localdb.New()
+	require.NoError(t, db.LoadMRTFile(path))
+	require.Equal(t, 3, db.Len())
+
+	path = filepath.Join(dir, "rib")
+	require.NoError(t, ioutil.WriteFile(path, testDump(), 0600))
+
+	db = localdb.New()
+	require.NoError(t, db.LoadMRTFile(path))
+	require.Equal(t, 3, db.Len())
+
+	require.Error(t, db.LoadMRTFile(filepath.Join(dir, "missing")))
+}
@@ -0,0 +1,136 @@
+// Copyright 2019 Freman/Fremnet (Shannon Wynter). All rights reserved.
+
+package localdb
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/freman/cymru/ipasn"
+)
+
+const dateFormat = `2006-01-02`
+
+// LookupTXT implements ipasn.Resolver, answering queries in exactly the same
+// format as the Team Cymru DNS service so the database can be used by an
+// unmodified ipasn.Client.
+//
+// Unknown addresses and ASNs return no records which the Client reports as
+// ipasn.ErrNotFound.
+func (db *DB) LookupTXT(_ context.Context, name string) ([]string, error) {
+	return lookupTXT(db, name)
+}
+
+// Backend is a local source of records and ASN descriptions, such as DB or
+// Snapshot
+type Backend interface {
+	Lookup(ip net.IP) (Record, bool)
+	LookupASN(asn int) (ipasn.ASNInfo, bool)
+}
+
+func lookupTXT(db Backend, name string) ([]string, error) {
+	q, err := parseQuery(ipasn.DefaultZones(), name)
+	if err != nil {
+		return nil, err
+	}
+
+	vals, _ := answer(db, q)
+
+	return vals, nil
+}
+
+// answer looks up the query returning it in the Team Cymru format along with
+// the time the record was seen, ASN descriptions are never considered old.
+func answer(db Backend, q ipasn.Query) ([]string, time.Time) {
+	switch q.Zone {
+	case "asn":
+		info, found := db.LookupASN(q.ASN)
+		if !found {
+			return nil, time.Time{}
+		}
+
+		return []string{strings.Join([]string{
+			strconv.Itoa(info.ASN),
+			info.Country,
+			info.Authority,
+			formatDate(info.Updated),
+			info.Description,
+		}, " | ")}, time.Time{}
+	case "peer":
+		rec, found := db.Lookup(q.IP)
+		if !found || len(rec.Peers) == 0 {
+			return nil, time.Time{}
+		}
+
+		peers := make([]string, len(rec.Peers))
+		for i, p := range rec.Peers {
+			peers[i] = strconv.Itoa(p)
+		}
+
+		return []string{formatRecord(strings.Join(peers, " "), rec)}, rec.Seen
+	default:
+		rec, found := db.Lookup(q.IP)
+		if !found {
+			return nil, time.Time{}
+		}
+
+		return []string{formatRecord(strconv.Itoa(rec.ASN), rec)}, rec.Seen
+	}
+}
+
+func formatRecord(asns string, rec Record) string {
+	return strings.Join([]string{
+		asns,
+		rec.Network.String(),
+		rec.Country,
+		rec.Authority,
+		formatDate(rec.Updated),
+	}, " | ")
+}
+
+func formatDate(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+
+	return t.Format(dateFormat)
+}
+
+// parseQuery reverses the query names generated by ipasn.Client for the Team
+// Cymru zones, or a mirror of them in zones
+func parseQuery(zones ipasn.Zones, name string) (ipasn.Query, error) {
+	q, err := zones.ParseName(name)
+	if err != nil {
+		return q, &unsupportedQuery{name: name, err: err}
+	}
+
+	return q, nil
+}
+
+// unsupportedQuery is returned for names that aren't Team Cymru queries, it
+// matches ipasn.ErrUnsupported, and ipasn.ErrUnknownZone for names outside of
+// the zones, as neither will ever be answered
+type unsupportedQuery struct {
+	name string
+	err  error
+}
+
+func (e *unsupportedQuery) Error() string {
+	if e.err == ipasn.ErrUnknownZone {
+		return fmt.Sprintf("localdb: unsupported query %q", e.name)
+	}
+
+	return fmt.Sprintf("localdb: unsupported query %q: %v", e.name, e.err)
+}
+
+func (e *unsupportedQuery) Unwrap() error {
+	return e.err
+}
+
+func (e *unsupportedQuery) Is(target error) bool {
+	return target == ipasn.ErrUnsupported
+}
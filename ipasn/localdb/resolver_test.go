@@ -0,0 +1,91 @@
+// Copyright 2019 Freman/Fremnet (Shannon Wynter). All rights reserved.
+
+package localdb_test
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/freman/cymru/ipasn"
+)
+
+func TestResolver(t *testing.T) {
+	t.Parallel()
+
+	db := testDB()
+
+	tests := []struct {
+		name     string
+		expected []string
+		err      string
+	}{
+		{"31.108.90.216.origin.asn.cymru.com.", []string{"23028 | 216.90.108.0/24 | US | arin | 1998-09-25"}, ""},
+		{"108.90.216.origin.asn.cymru.com.", []string{"23028 | 216.90.108.0/24 | US | arin | 1998-09-25"}, ""},
+		{"1.1.1.1.origin.asn.cymru.com.", nil, ""},
+		{"31.108.90.216.peer.asn.cymru.com.", []string{"701 1239 | 216.90.108.0/24 | US | arin | 1998-09-25"}, ""},
+		{"1.109.90.216.peer.asn.cymru.com.", nil, ""},
+		{"8.6.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.2.0.0.b.0.6.8.4.1.0.0.2.origin6.asn.cymru.com.", []string{"15169 | 2001:4860::/32 | US | arin | 2005-03-14"}, ""},
+		{"0.6.8.4.1.0.0.2.ORIGIN6.ASN.CYMRU.COM", []string{"15169 | 2001:4860::/32 | US | arin | 2005-03-14"}, ""},
+		{"AS23028.asn.cymru.com.", []string{"23028 | US | arin | 2002-01-04 | TEAM-CYMRU - Team Cymru Inc., US"}, ""},
+		{"AS1.asn.cymru.com.", nil, ""},
+		{"example.com.", nil, `localdb: unsupported query "example.com."`},
+		{"asn.cymru.com.", nil, `localdb: unsupported query "asn.cymru.com."`},
+		{"1.2.3.4.5.origin.asn.cymru.com.", nil, `localdb: unsupported query "1.2.3.4.5.origin.asn.cymru.com.": expected up to 4 octets, got 5`},
+		{"x.origin6.asn.cymru.com.", nil, `localdb: unsupported query "x.origin6.asn.cymru.com.": strconv.ParseUint: parsing "x": invalid syntax`},
+		{"ASx.asn.cymru.com.", nil, `localdb: unsupported query "ASx.asn.cymru.com.": strconv.Atoi: parsing "x": invalid syntax`},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := db.LookupTXT(context.TODO(), test.name)
+			if test.err != "" {
+				require.EqualError(t, err, test.err)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, test.expected, got)
+		})
+	}
+
+	// Neither will ever be answered
+	_, err := db.LookupTXT(context.TODO(), "example.com.")
+	require.True(t, errors.Is(err, ipasn.ErrUnknownZone))
+	require.True(t, errors.Is(err, ipasn.ErrUnsupported))
+
+	_, err = db.LookupTXT(context.TODO(), "ASx.asn.cymru.com.")
+	require.False(t, errors.Is(err, ipasn.ErrUnknownZone))
+	require.True(t, errors.Is(err, ipasn.ErrUnsupported))
+}
+
+func TestResolverWithClient(t *testing.T) {
+	t.Parallel()
+
+	c := &ipasn.Client{Resolver: testDB()}
+
+	origin, err := c.Origin(context.TODO(), net.ParseIP("216.90.108.31"))
+	require.NoError(t, err)
+	require.Equal(t, "23028 | 216.90.108.0/24 | US | arin | 1998-09-25", origin.String())
+
+	peer, err := c.Peer(context.TODO(), net.ParseIP("216.90.108.31"))
+	require.NoError(t, err)
+	require.Equal(t, []int{701, 1239}, peer.ASNs)
+
+	_, err = c.Peer(context.TODO(), net.ParseIP("216.1.1.1"))
+	require.Equal(t, ipasn.ErrNotFound, err)
+
+	origin, err = c.Origin(context.TODO(), net.ParseIP("2001:4860:b002::68"), ipasn.StrictParsing())
+	require.NoError(t, err)
+	require.Equal(t, 15169, origin.ASN)
+
+	asn, err := c.ASN(context.TODO(), 23028)
+	require.NoError(t, err)
+	require.Equal(t, "TEAM-CYMRU - Team Cymru Inc., US", asn.Description)
+}
@@ -0,0 +1,516 @@
+// Copyright 2019 Freman/Fremnet (Shannon Wynter). All rights reserved.
+
+package localdb
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/freman/cymru/ipasn"
+)
+
+// The snapshot format is a fixed size header followed by fixed size tables
+// so that it can be memory mapped and searched in place, all integers are
+// big endian.
+//
+//	header      magic, version, creation time and the size of each table
+//	v4 ranges   start, end and record index of non overlapping IPv4 ranges
+//	v6 ranges   start, end and record index of non overlapping IPv6 ranges
+//	records     one per prefix, strings and peers are offsets into their tables
+//	asns        one per ASN description, sorted by ASN
+//	peers       lists of peer ASNs
+//	strings     length prefixed strings, the first is always empty
+//
+// The ranges are the result of flattening the prefixes so that the longest
+// prefix match is a binary search.
+const (
+	snapshotMagic   = "CYMRUDB\x00"
+	snapshotVersion = 1
+
+	snapshotHeaderSize = 48
+	snapshotV4Size     = 12
+	snapshotV6Size     = 36
+	snapshotRecordSize = 56
+	snapshotASNSize    = 24
+	snapshotPeerSize   = 4
+)
+
+// ErrInvalidSnapshot is returned when a snapshot is corrupt, truncated, or
+// of an unsupported version
+var ErrInvalidSnapshot = errors.New("localdb: invalid snapshot")
+
+// Snapshot is a read only database in the compact snapshot format written by
+// DB.WriteTo, it's searched in place without being loaded into memory.
+//
+// Snapshot implements ipasn.Resolver and the same Origin, Peer and ASN methods
+// as DB. It's safe for concurrent use until it's closed.
+type Snapshot struct {
+	created time.Time
+	v4      []byte
+	v6      []byte
+	records []byte
+	asns    []byte
+	peers   []byte
+	strings []byte
+	closer  func() error
+}
+
+// OpenSnapshot memory maps the named snapshot file, where supported, Close
+// must be called to release it.
+func OpenSnapshot(path string) (*Snapshot, error) {
+	fh, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer fh.Close()
+
+	st, err := fh.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	if st.Size() < snapshotHeaderSize {
+		return nil, fmt.Errorf("%s: %w", path, ErrInvalidSnapshot)
+	}
+
+	data, closer, err := mmap(fh, int(st.Size()))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	s, err := NewSnapshot(data)
+	if err != nil {
+		_ = closer()
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	s.closer = closer
+
+	return s, nil
+}
+
+// NewSnapshot uses data, which must not be modified, as a snapshot.
+func NewSnapshot(data []byte) (*Snapshot, error) {
+	if len(data) < snapshotHeaderSize || string(data[:8]) != snapshotMagic ||
+		binary.BigEndian.Uint32(data[8:12]) != snapshotVersion {
+		return nil, ErrInvalidSnapshot
+	}
+
+	s := &Snapshot{
+		created: unixTime(int64(binary.BigEndian.Uint64(data[16:24]))),
+	}
+
+	sections := []struct {
+		dst  *[]byte
+		size int
+	}{
+		{&s.v4, snapshotV4Size},
+		{&s.v6, snapshotV6Size},
+		{&s.records, snapshotRecordSize},
+		{&s.asns, snapshotASNSize},
+		{&s.peers, snapshotPeerSize},
+		{&s.strings, 1},
+	}
+
+	offset := snapshotHeaderSize
+
+	for i, section := range sections {
+		length := int(binary.BigEndian.Uint32(data[24+4*i:])) * section.size
+		if length < 0 || offset+length > len(data) {
+			return nil, ErrInvalidSnapshot
+		}
+
+		*section.dst = data[offset : offset+length]
+		offset += length
+	}
+
+	if offset != len(data) {
+		return nil, ErrInvalidSnapshot
+	}
+
+	return s, nil
+}
+
+// Close releases the memory mapping, the snapshot must not be used after
+func (s *Snapshot) Close() error {
+	if s.closer == nil {
+		return nil
+	}
+
+	return s.closer()
+}
+
+// Created returns the time the snapshot was written
+func (s *Snapshot) Created() time.Time {
+	return s.created
+}
+
+// Len returns the number of prefixes in the snapshot
+func (s *Snapshot) Len() int {
+	return len(s.records) / snapshotRecordSize
+}
+
+// Lookup returns the record with the longest prefix containing ip.
+func (s *Snapshot) Lookup(ip net.IP) (Record, bool) {
+	var (
+		ranges []byte
+		size   int
+		key    []byte
+	)
+
+	if ip4 := ip.To4(); ip4 != nil {
+		ranges, size, key = s.v4, snapshotV4Size, ip4
+	} else if ip16 := ip.To16(); ip16 != nil {
+		ranges, size, key = s.v6, snapshotV6Size, ip16
+	} else {
+		return Record{}, false
+	}
+
+	width := len(key)
+	count := len(ranges) / size
+
+	i := sort.Search(count, func(i int) bool {
+		return bytes.Compare(ranges[i*size:i*size+width], key) > 0
+	}) - 1
+
+	if i < 0 {
+		return Record{}, false
+	}
+
+	entry := ranges[i*size : (i+1)*size]
+	if bytes.Compare(key, entry[width:2*width]) > 0 {
+		return Record{}, false
+	}
+
+	return s.record(int(binary.BigEndian.Uint32(entry[2*width:]))), true
+}
+
+// LookupASN returns the description of the given ASN
+func (s *Snapshot) LookupASN(asn int) (ipasn.ASNInfo, bool) {
+	count := len(s.asns) / snapshotASNSize
+
+	i := sort.Search(count, func(i int) bool {
+		return int(binary.BigEndian.Uint32(s.asns[i*snapshotASNSize:])) >= asn
+	})
+
+	if i == count {
+		return ipasn.ASNInfo{}, false
+	}
+
+	entry := s.asns[i*snapshotASNSize : (i+1)*snapshotASNSize]
+	if int(binary.BigEndian.Uint32(entry)) != asn {
+		return ipasn.ASNInfo{}, false
+	}
+
+	return ipasn.ASNInfo{
+		ASN:         asn,
+		Country:     s.string(entry[4:]),
+		Authority:   s.string(entry[8:]),
+		Description: s.string(entry[12:]),
+		Updated:     unixTime(int64(binary.BigEndian.Uint64(entry[16:]))),
+	}, true
+}
+
+// LookupTXT implements ipasn.Resolver, see DB.LookupTXT
+func (s *Snapshot) LookupTXT(_ context.Context, name string) ([]string, error) {
+	return lookupTXT(s, name)
+}
+
+// Origin looks up the BGP Origin ASN for ip, see DB.Origin
+func (s *Snapshot) Origin(ctx context.Context, ip net.IP, opts ...ipasn.CallOption) (ipasn.OriginInfo, error) {
+	return (&ipasn.Client{Resolver: s}).Origin(ctx, ip, opts...)
+}
+
+// Peer looks up the BGP peer ASNs for ip, see DB.Peer
+func (s *Snapshot) Peer(ctx context.Context, ip net.IP, opts ...ipasn.CallOption) (ipasn.PeerInfo, error) {
+	return (&ipasn.Client{Resolver: s}).Peer(ctx, ip, opts...)
+}
+
+// ASN looks up the AS description, see DB.ASN
+func (s *Snapshot) ASN(ctx context.Context, asn int, opts ...ipasn.CallOption) (ipasn.ASNInfo, error) {
+	return (&ipasn.Client{Resolver: s}).ASN(ctx, asn, opts...)
+}
+
+func (s *Snapshot) record(i int) Record {
+	if i < 0 || i >= s.Len() {
+		return Record{}
+	}
+
+	entry := s.records[i*snapshotRecordSize : (i+1)*snapshotRecordSize]
+
+	size := net.IPv6len
+	if entry[17] == 4 {
+		size = net.IPv4len
+	}
+
+	rec := Record{
+		Network: &net.IPNet{
+			IP:   append(net.IP(nil), entry[:size]...),
+			Mask: net.CIDRMask(int(entry[16]), 8*size),
+		},
+		ASN:       int(binary.BigEndian.Uint32(entry[20:])),
+		Country:   s.string(entry[24:]),
+		Authority: s.string(entry[28:]),
+		Updated:   unixTime(int64(binary.BigEndian.Uint64(entry[32:]))),
+		Seen:      unixTime(int64(binary.BigEndian.Uint64(entry[48:]))),
+	}
+
+	offset := int(binary.BigEndian.Uint32(entry[40:]))
+	count := int(binary.BigEndian.Uint32(entry[44:]))
+
+	if count > 0 && (offset+count)*snapshotPeerSize <= len(s.peers) {
+		rec.Peers = make([]int, count)
+		for j := range rec.Peers {
+			rec.Peers[j] = int(binary.BigEndian.Uint32(s.peers[(offset+j)*snapshotPeerSize:]))
+		}
+	}
+
+	return rec
+}
+
+// string reads the string at the offset stored at the start of b
+func (s *Snapshot) string(b []byte) string {
+	offset := int(binary.BigEndian.Uint32(b))
+	if offset+2 > len(s.strings) {
+		return ""
+	}
+
+	length := int(binary.BigEndian.Uint16(s.strings[offset:]))
+	if offset+2+length > len(s.strings) {
+		return ""
+	}
+
+	return string(s.strings[offset+2 : offset+2+length])
+}
+
+// WriteTo writes the database to w in the snapshot format, implementing
+// io.WriterTo.
+func (db *DB) WriteTo(w io.Writer) (int64, error) {
+	recs := db.Records()
+	asns := db.ASNs()
+
+	var (
+		v4, v6, records, asnTable, peers bytes.Buffer
+		strs                             = newStringTable()
+		v4Spans, v6Spans                 []span
+		nPeers                           int
+	)
+
+	for i, rec := range recs {
+		ones, bits := rec.Network.Mask.Size()
+		s := span{record: uint32(i)}
+		copy(s.start[:], rec.Network.IP.To16())
+		copy(s.end[:], s.start[:])
+
+		for b := ones + 8*net.IPv6len - bits; b < 8*net.IPv6len; b++ {
+			s.end[b/8] |= 0x80 >> uint(b%8)
+		}
+
+		var entry [snapshotRecordSize]byte
+
+		if bits == 8*net.IPv4len {
+			v4Spans = append(v4Spans, s)
+			entry[17] = 4
+		} else {
+			v6Spans = append(v6Spans, s)
+			entry[17] = 6
+		}
+
+		copy(entry[:], rec.Network.IP)
+		entry[16] = byte(ones)
+		binary.BigEndian.PutUint32(entry[20:], uint32(rec.ASN))
+		binary.BigEndian.PutUint32(entry[24:], strs.add(rec.Country))
+		binary.BigEndian.PutUint32(entry[28:], strs.add(rec.Authority))
+		binary.BigEndian.PutUint64(entry[32:], uint64(unixSeconds(rec.Updated)))
+		binary.BigEndian.PutUint32(entry[40:], uint32(nPeers))
+		binary.BigEndian.PutUint32(entry[44:], uint32(len(rec.Peers)))
+		binary.BigEndian.PutUint64(entry[48:], uint64(unixSeconds(rec.Seen)))
+		records.Write(entry[:])
+
+		for _, p := range rec.Peers {
+			_ = binary.Write(&peers, binary.BigEndian, uint32(p))
+		}
+
+		nPeers += len(rec.Peers)
+	}
+
+	for _, s := range flatten(v4Spans) {
+		v4.Write(s.start[12:])
+		v4.Write(s.end[12:])
+		_ = binary.Write(&v4, binary.BigEndian, s.record)
+	}
+
+	for _, s := range flatten(v6Spans) {
+		v6.Write(s.start[:])
+		v6.Write(s.end[:])
+		_ = binary.Write(&v6, binary.BigEndian, s.record)
+	}
+
+	for _, info := range asns {
+		var entry [snapshotASNSize]byte
+
+		binary.BigEndian.PutUint32(entry[0:], uint32(info.ASN))
+		binary.BigEndian.PutUint32(entry[4:], strs.add(info.Country))
+		binary.BigEndian.PutUint32(entry[8:], strs.add(info.Authority))
+		binary.BigEndian.PutUint32(entry[12:], strs.add(info.Description))
+		binary.BigEndian.PutUint64(entry[16:], uint64(unixSeconds(info.Updated)))
+		asnTable.Write(entry[:])
+	}
+
+	var header [snapshotHeaderSize]byte
+
+	copy(header[:], snapshotMagic)
+	binary.BigEndian.PutUint32(header[8:], snapshotVersion)
+	binary.BigEndian.PutUint64(header[16:], uint64(time.Now().Unix()))
+
+	sections := []struct {
+		buf  *bytes.Buffer
+		size int
+	}{
+		{&v4, snapshotV4Size},
+		{&v6, snapshotV6Size},
+		{&records, snapshotRecordSize},
+		{&asnTable, snapshotASNSize},
+		{&peers, snapshotPeerSize},
+		{&strs.buf, 1},
+	}
+
+	for i, section := range sections {
+		binary.BigEndian.PutUint32(header[24+4*i:], uint32(section.buf.Len()/section.size))
+	}
+
+	n, err := w.Write(header[:])
+	total := int64(n)
+
+	for _, section := range sections {
+		if err != nil {
+			return total, err
+		}
+
+		var m int64
+		m, err = section.buf.WriteTo(w)
+		total += m
+	}
+
+	return total, err
+}
+
+// span is an inclusive range of addresses, IPv4 addresses are IPv4 mapped
+type span struct {
+	start  [net.IPv6len]byte
+	end    [net.IPv6len]byte
+	record uint32
+}
+
+// flatten turns a list of possibly nested prefix spans, sorted by start with
+// the outermost first, into non overlapping spans where the innermost
+// prefix wins.
+func flatten(prefixes []span) []span {
+	var (
+		out      []span
+		stack    []span
+		cursor   [net.IPv6len]byte
+		overflow bool
+	)
+
+	emit := func(from, to [net.IPv6len]byte, record uint32) {
+		if !overflow && bytes.Compare(from[:], to[:]) <= 0 {
+			out = append(out, span{start: from, end: to, record: record})
+		}
+	}
+
+	pop := func() {
+		top := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+
+		emit(cursor, top.end, top.record)
+
+		cursor, overflow = top.end, true
+		for i := len(cursor) - 1; i >= 0 && overflow; i-- {
+			cursor[i]++
+			overflow = cursor[i] == 0
+		}
+	}
+
+	for _, p := range prefixes {
+		for len(stack) > 0 && bytes.Compare(stack[len(stack)-1].end[:], p.start[:]) < 0 {
+			pop()
+		}
+
+		if len(stack) > 0 && bytes.Compare(cursor[:], p.start[:]) < 0 {
+			before := p.start
+			for i := len(before) - 1; i >= 0; i-- {
+				before[i]--
+				if before[i] != 0xff {
+					break
+				}
+			}
+
+			emit(cursor, before, stack[len(stack)-1].record)
+		}
+
+		cursor, overflow = p.start, false
+		stack = append(stack, p)
+	}
+
+	for len(stack) > 0 {
+		pop()
+	}
+
+	return out
+}
+
+// stringTable deduplicates strings written to a snapshot
+type stringTable struct {
+	buf     bytes.Buffer
+	offsets map[string]uint32
+}
+
+func newStringTable() *stringTable {
+	t := &stringTable{offsets: make(map[string]uint32)}
+	t.add("")
+
+	return t
+}
+
+func (t *stringTable) add(s string) uint32 {
+	if len(s) > 0xffff {
+		s = s[:0xffff]
+	}
+
+	if offset, exists := t.offsets[s]; exists {
+		return offset
+	}
+
+	offset := uint32(t.buf.Len())
+	t.offsets[s] = offset
+
+	_ = binary.Write(&t.buf, binary.BigEndian, uint16(len(s)))
+	t.buf.WriteString(s)
+
+	return offset
+}
+
+func unixSeconds(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+
+	return t.Unix()
+}
+
+func unixTime(s int64) time.Time {
+	if s == 0 {
+		return time.Time{}
+	}
+
+	return time.Unix(s, 0).UTC()
+}
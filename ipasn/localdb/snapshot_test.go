@@ -0,0 +1,166 @@
+// Copyright 2019 Freman/Fremnet (Shannon Wynter). All rights reserved.
+
+package localdb_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/freman/cymru/ipasn"
+	"github.com/freman/cymru/ipasn/localdb"
+)
+
+func snapshotOf(t *testing.T, db *localdb.DB) *localdb.Snapshot {
+	var buf bytes.Buffer
+
+	n, err := db.WriteTo(&buf)
+	require.NoError(t, err)
+	require.EqualValues(t, buf.Len(), n)
+
+	s, err := localdb.NewSnapshot(buf.Bytes())
+	require.NoError(t, err)
+
+	return s
+}
+
+func TestSnapshotMatchesDB(t *testing.T) {
+	t.Parallel()
+
+	db := testDB()
+	db.Insert(localdb.Record{Network: mustCIDR("216.90.108.128/25"), ASN: 3})
+	db.Insert(localdb.Record{Network: mustCIDR("216.90.108.128/26"), ASN: 4})
+	db.Insert(localdb.Record{Network: mustCIDR("216.90.108.255/32"), ASN: 5})
+	db.Insert(localdb.Record{Network: mustCIDR("255.255.255.0/24"), ASN: 6})
+	db.Insert(localdb.Record{Network: mustCIDR("ffff::/16"), ASN: 7})
+	db.Insert(localdb.Record{Network: mustCIDR("ffff:ffff:ffff:ffff:ffff:ffff:ffff:ff00/120"), ASN: 8})
+
+	s := snapshotOf(t, db)
+	require.Equal(t, db.Len(), s.Len())
+	require.WithinDuration(t, time.Now(), s.Created(), time.Minute)
+
+	ips := []string{
+		"0.0.0.1", "215.255.255.255", "216.0.0.0", "216.90.108.0", "216.90.108.31", "216.90.108.127",
+		"216.90.108.128", "216.90.108.191", "216.90.108.192", "216.90.108.254", "216.90.108.255",
+		"216.90.109.0", "216.255.255.255", "217.0.0.0", "255.255.255.255",
+		"::", "2001:4860::1", "2001:4861::", "fffe::", "ffff::", "ffff:ffff:ffff:ffff:ffff:ffff:ffff:feff",
+		"ffff:ffff:ffff:ffff:ffff:ffff:ffff:ffff",
+	}
+
+	for _, ip := range ips {
+		ip := ip
+		t.Run(ip, func(t *testing.T) {
+			t.Parallel()
+
+			expected, expectedFound := db.Lookup(net.ParseIP(ip))
+			got, found := s.Lookup(net.ParseIP(ip))
+			require.Equal(t, expectedFound, found)
+			require.Equal(t, expected, got)
+		})
+	}
+
+	for _, asn := range []int{1, 23028, 99999} {
+		expected, expectedFound := db.LookupASN(asn)
+		got, found := s.LookupASN(asn)
+		require.Equal(t, expectedFound, found)
+		require.Equal(t, expected, got)
+	}
+}
+
+func TestSnapshotClientSurface(t *testing.T) {
+	t.Parallel()
+
+	s := snapshotOf(t, testDB())
+
+	origin, err := s.Origin(context.TODO(), net.ParseIP("216.90.108.31"))
+	require.NoError(t, err)
+	require.Equal(t, "23028 | 216.90.108.0/24 | US | arin | 1998-09-25", origin.String())
+
+	peer, err := s.Peer(context.TODO(), net.ParseIP("216.90.108.31"))
+	require.NoError(t, err)
+	require.Equal(t, []int{701, 1239}, peer.ASNs)
+
+	asn, err := s.ASN(context.TODO(), 23028)
+	require.NoError(t, err)
+	require.Equal(t, "TEAM-CYMRU - Team Cymru Inc., US", asn.Description)
+
+	_, err = (&ipasn.Client{Resolver: s}).ASN(context.TODO(), 1)
+	require.Equal(t, ipasn.ErrNotFound, err)
+}
+
+func TestEmptySnapshot(t *testing.T) {
+	t.Parallel()
+
+	s := snapshotOf(t, localdb.New())
+	require.Equal(t, 0, s.Len())
+
+	_, found := s.Lookup(net.ParseIP("1.1.1.1"))
+	require.False(t, found)
+
+	_, found = s.LookupASN(1)
+	require.False(t, found)
+}
+
+func TestInvalidSnapshot(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+
+	_, err := testDB().WriteTo(&buf)
+	require.NoError(t, err)
+
+	data := buf.Bytes()
+
+	for _, bad := range [][]byte{
+		nil,
+		data[:20],
+		data[:len(data)-1],
+		append(append([]byte(nil), data...), 0),
+		append([]byte("NOTCYMRU"), data[8:]...),
+	} {
+		_, err := localdb.NewSnapshot(bad)
+		require.Equal(t, localdb.ErrInvalidSnapshot, err)
+	}
+}
+
+func TestOpenSnapshot(t *testing.T) {
+	t.Parallel()
+
+	dir, err := ioutil.TempDir("", "localdb")
+	require.NoError(t, err)
+
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "ipasn.db")
+
+	fh, err := os.Create(path)
+	require.NoError(t, err)
+
+	_, err = testDB().WriteTo(fh)
+	require.NoError(t, err)
+	require.NoError(t, fh.Close())
+
+	s, err := localdb.OpenSnapshot(path)
+	require.NoError(t, err)
+
+	rec, found := s.Lookup(net.ParseIP("2001:4860::1"))
+	require.True(t, found)
+	require.Equal(t, 15169, rec.ASN)
+	require.NoError(t, s.Close())
+
+	require.NoError(t, ioutil.WriteFile(path, []byte("short"), 0600))
+
+	_, err = localdb.OpenSnapshot(path)
+	require.True(t, errors.Is(err, localdb.ErrInvalidSnapshot))
+
+	_, err = localdb.OpenSnapshot(filepath.Join(dir, "missing"))
+	require.Error(t, err)
+}
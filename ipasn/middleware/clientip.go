@@ -0,0 +1,81 @@
+// Copyright 2019 Freman/Fremnet (Shannon Wynter). All rights reserved.
+
+package middleware
+
+import (
+	"net"
+	"net/http"
+	"strings"
+)
+
+// ClientIP returns the address of the client of r. If the request came from a
+// trusted proxy the Forwarded, or failing that X-Forwarded-For, header is
+// walked from the nearest hop back, skipping trusted proxies, to the first
+// address that isn't one.
+func (m *Middleware) ClientIP(r *http.Request) net.IP {
+	ip := parseHost(r.RemoteAddr)
+	if ip == nil || m.TrustedProxies == nil || !m.TrustedProxies.Contains(ip) {
+		return ip
+	}
+
+	hops := forwardedFor(r.Header)
+
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop := parseHost(hops[i])
+		if hop == nil {
+			// An obfuscated or unknown hop, there's no telling what's past it
+			return ip
+		}
+
+		ip = hop
+
+		if !m.TrustedProxies.Contains(ip) {
+			break
+		}
+	}
+
+	return ip
+}
+
+// forwardedFor returns the addresses from the Forwarded header (RFC 7239), or
+// X-Forwarded-For if there isn't one, in order from client to nearest proxy
+func forwardedFor(h http.Header) []string {
+	var hops []string
+
+	if values := h["Forwarded"]; len(values) > 0 {
+		for _, value := range values {
+			for _, element := range strings.Split(value, ",") {
+				hop := ""
+
+				for _, pair := range strings.Split(element, ";") {
+					kv := strings.SplitN(strings.TrimSpace(pair), "=", 2)
+					if len(kv) == 2 && strings.EqualFold(kv[0], "for") {
+						hop = strings.Trim(kv[1], `"`)
+					}
+				}
+
+				hops = append(hops, hop)
+			}
+		}
+
+		return hops
+	}
+
+	for _, value := range h["X-Forwarded-For"] {
+		for _, hop := range strings.Split(value, ",") {
+			hops = append(hops, strings.TrimSpace(hop))
+		}
+	}
+
+	return hops
+}
+
+// parseHost parses an address that may have a port and brackets, eg:
+// 192.0.2.1, 192.0.2.1:80, [2001:db8::1] and [2001:db8::1]:80
+func parseHost(s string) net.IP {
+	if host, _, err := net.SplitHostPort(s); err == nil {
+		s = host
+	}
+
+	return net.ParseIP(strings.Trim(s, "[]"))
+}
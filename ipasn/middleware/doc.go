@@ -0,0 +1,5 @@
+// Copyright 2019 Freman/Fremnet (Shannon Wynter). All rights reserved.
+
+// Package middleware implements net/http middleware that looks up the origin ASN of each client and
+// stores it in the request context, eg: for logging or rate limiting by AS.
+package middleware
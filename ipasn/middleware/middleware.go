@@ -0,0 +1,111 @@
+// Copyright 2019 Freman/Fremnet (Shannon Wynter). All rights reserved.
+
+package middleware
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/freman/cymru/ipasn"
+)
+
+// Info is what's known about the client of a request, Err is set if the
+// lookup failed in which case Origin and ASN may be empty.
+type Info struct {
+	IP     net.IP
+	Origin ipasn.OriginInfo
+	ASN    ipasn.ASNInfo
+	Err    error
+}
+
+type contextKey struct{}
+
+// NewContext returns a copy of ctx carrying info
+func NewContext(ctx context.Context, info Info) context.Context {
+	return context.WithValue(ctx, contextKey{}, info)
+}
+
+// FromContext returns the Info stored by the middleware, if any
+func FromContext(ctx context.Context) (Info, bool) {
+	info, ok := ctx.Value(contextKey{}).(Info)
+	return info, ok
+}
+
+// Middleware looks up the origin ASN, and its description, of the client of
+// each request storing the result in the request context, see FromContext.
+//
+// Lookups that fail or take too long don't fail the request, the handler is
+// called regardless with Info.Err set.
+//
+// The zero value is ready to use, with a Client that caches answers for an
+// hour, a 100ms timeout and no trusted proxies.
+type Middleware struct {
+	// Client does the lookups, it should have a Cache
+	Client *ipasn.Client
+
+	// TrustedProxies are the networks of proxies whose X-Forwarded-For and
+	// Forwarded headers are believed, eg: ipasn.DefaultPrivateNetworks()
+	TrustedProxies ipasn.NetworkFilter
+
+	// Timeout limits the lookups, it defaults to 100ms
+	Timeout time.Duration
+
+	once   sync.Once
+	client *ipasn.Client
+}
+
+// Handler wraps next using a zero value Middleware
+func Handler(next http.Handler) http.Handler {
+	return new(Middleware).Handler(next)
+}
+
+// Handler wraps next, storing the Info in the context of every request
+func (m *Middleware) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		info := m.Lookup(r.Context(), m.ClientIP(r))
+		next.ServeHTTP(w, r.WithContext(NewContext(r.Context(), info)))
+	})
+}
+
+// Lookup returns the Info for ip, bounded by the Timeout
+func (m *Middleware) Lookup(ctx context.Context, ip net.IP) Info {
+	info := Info{IP: ip}
+	if ip == nil {
+		info.Err = ipasn.ErrIPIsUnspecified
+		return info
+	}
+
+	timeout := m.Timeout
+	if timeout <= 0 {
+		timeout = 100 * time.Millisecond
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	client := m.getClient()
+
+	info.Origin, info.Err = client.Origin(ctx, ip)
+	if info.Err != nil {
+		return info
+	}
+
+	// The origin is still useful without a description
+	info.ASN, _ = client.ASN(ctx, info.Origin.ASN)
+
+	return info
+}
+
+func (m *Middleware) getClient() *ipasn.Client {
+	m.once.Do(func() {
+		m.client = m.Client
+		if m.client == nil {
+			m.client = ipasn.NewClient(ipasn.WithCache(ipasn.NewMemoryCache(time.Hour, 100000)))
+		}
+	})
+
+	return m.client
+}
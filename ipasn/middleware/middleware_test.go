@@ -0,0 +1,109 @@
+// Copyright 2019 Freman/Fremnet (Shannon Wynter). All rights reserved.
+
+package middleware_test
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/freman/cymru/ipasn"
+	"github.com/freman/cymru/ipasn/ipasntest"
+	"github.com/freman/cymru/ipasn/middleware"
+)
+
+func TestMiddleware(t *testing.T) {
+	t.Parallel()
+
+	resolver := ipasntest.NewResolver(ipasntest.Golden())
+	m := &middleware.Middleware{
+		Client:         ipasn.NewClient(ipasn.WithResolver(resolver)),
+		TrustedProxies: ipasn.DefaultPrivateNetworks(),
+	}
+
+	var got middleware.Info
+
+	handler := m.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var ok bool
+		got, ok = middleware.FromContext(r.Context())
+		require.True(t, ok)
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.RemoteAddr = "216.90.108.31:1234"
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	require.NoError(t, got.Err)
+	require.Equal(t, "216.90.108.31", got.IP.String())
+	require.Equal(t, 23028, got.Origin.ASN)
+	require.Equal(t, "TEAM-CYMRU - Team Cymru Inc., US", got.ASN.Description)
+
+	// Lookups failing still reach the handler
+	req.RemoteAddr = "192.168.0.1:1234"
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+	require.Equal(t, ipasn.ErrIPIsPrivate, got.Err)
+
+	req.RemoteAddr = "[2001:4860::1]:1234"
+	resolver.SetLatency(time.Hour)
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+	require.True(t, errors.Is(got.Err, context.DeadlineExceeded))
+	require.Equal(t, "2001:4860::1", got.IP.String())
+
+	_, ok := middleware.FromContext(context.Background())
+	require.False(t, ok)
+}
+
+func TestClientIP(t *testing.T) {
+	t.Parallel()
+
+	m := &middleware.Middleware{TrustedProxies: ipasn.Networks{mustCIDR("10.0.0.0/8"), mustCIDR("2001:db8::/32")}}
+
+	tests := []struct {
+		name     string
+		remote   string
+		header   http.Header
+		expected string
+	}{
+		{"direct", "203.0.113.1:1234", nil, "203.0.113.1"},
+		{"untrusted proxy", "203.0.113.1:1234", http.Header{"X-Forwarded-For": {"198.51.100.1"}}, "203.0.113.1"},
+		{"trusted proxy", "10.0.0.1:1234", http.Header{"X-Forwarded-For": {"198.51.100.1"}}, "198.51.100.1"},
+		{"spoofed", "10.0.0.1:1234", http.Header{"X-Forwarded-For": {"1.1.1.1, 198.51.100.1, 10.0.0.2"}}, "198.51.100.1"},
+		{"multiple headers", "10.0.0.1:1234", http.Header{"X-Forwarded-For": {"198.51.100.1", "10.0.0.3, 10.0.0.2"}}, "198.51.100.1"},
+		{"all trusted", "10.0.0.1:1234", http.Header{"X-Forwarded-For": {"10.0.0.3, 10.0.0.2"}}, "10.0.0.3"},
+		{"no header", "10.0.0.1:1234", nil, "10.0.0.1"},
+		{"forwarded", "[2001:db8::1]:1234", http.Header{"Forwarded": {`for=192.0.2.60;proto=http, For="[2001:db8:cafe::17]:4711"`}}, "192.0.2.60"},
+		{"forwarded preferred", "10.0.0.1:1234", http.Header{"Forwarded": {"for=192.0.2.60"}, "X-Forwarded-For": {"198.51.100.1"}}, "192.0.2.60"},
+		{"obfuscated", "10.0.0.1:1234", http.Header{"Forwarded": {`for=192.0.2.60, for=_hidden, for=10.0.0.2`}}, "10.0.0.2"},
+		{"garbage", "10.0.0.1:1234", http.Header{"X-Forwarded-For": {"nonsense"}}, "10.0.0.1"},
+		{"no port", "203.0.113.1", nil, "203.0.113.1"},
+		{"unix socket", "@", nil, "<nil>"},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.RemoteAddr = test.remote
+			req.Header = test.header
+
+			require.Equal(t, test.expected, m.ClientIP(req).String())
+		})
+	}
+}
+
+func mustCIDR(s string) *net.IPNet {
+	_, n, err := net.ParseCIDR(s)
+	if err != nil {
+		panic(err)
+	}
+
+	return n
+}
@@ -2,7 +2,10 @@
 
 package ipasn
 
-import "net"
+import (
+	"fmt"
+	"net"
+)
 
 // NetworkFilter interface is based on *IP.Net->Contains magically permits
 // the building of larger, or recursive networks.
@@ -53,3 +56,160 @@ func (a alwaysTheSameAnswer) Contains(_ net.IP) bool {
 func NoPrivateNetworks() NetworkFilter {
 	return alwaysTheSameAnswer(false)
 }
+
+// Explain reports which rule in the list contained ip, if any.
+func (n Networks) Explain(ip net.IP) Decision {
+	for _, r := range n {
+		if d := Explain(r, ip); d.Contains {
+			return d
+		}
+	}
+
+	return Decision{}
+}
+
+func (a alwaysTheSameAnswer) String() string {
+	if a {
+		return "all networks"
+	}
+
+	return "no networks"
+}
+
+type exceptFilter struct {
+	include NetworkFilter
+	exclude NetworkFilter
+}
+
+// Except returns a NetworkFilter that contains an ip if include contains it
+// and exclude doesn't, eg: private networks except one routed publicly.
+func Except(include, exclude NetworkFilter) NetworkFilter {
+	return exceptFilter{include: include, exclude: exclude}
+}
+
+func (e exceptFilter) Contains(ip net.IP) bool {
+	return e.include.Contains(ip) && !e.exclude.Contains(ip)
+}
+
+func (e exceptFilter) Explain(ip net.IP) Decision {
+	d := Explain(e.include, ip)
+	if !d.Contains {
+		return d
+	}
+
+	if x := Explain(e.exclude, ip); x.Contains {
+		return Decision{Rule: x.Rule, Excluded: true}
+	}
+
+	return d
+}
+
+func (e exceptFilter) String() string {
+	return fmt.Sprintf("%v except %v", e.include, e.exclude)
+}
+
+type intersectFilter []NetworkFilter
+
+// Intersect returns a NetworkFilter that only contains an ip if every one of
+// the given filters contains it.
+func Intersect(filters ...NetworkFilter) NetworkFilter {
+	return intersectFilter(filters)
+}
+
+func (i intersectFilter) Contains(ip net.IP) bool {
+	for _, r := range i {
+		if !r.Contains(ip) {
+			return false
+		}
+	}
+
+	return len(i) > 0
+}
+
+func (i intersectFilter) Explain(ip net.IP) (d Decision) {
+	for _, r := range i {
+		if d = Explain(r, ip); !d.Contains {
+			if d.Rule == nil {
+				d.Rule = r
+			}
+
+			return d
+		}
+	}
+
+	return d
+}
+
+func (i intersectFilter) String() string {
+	return fmt.Sprintf("intersect%v", []NetworkFilter(i))
+}
+
+type notFilter struct {
+	NetworkFilter
+}
+
+// Not returns a NetworkFilter that contains every ip that the given filter
+// doesn't.
+func Not(f NetworkFilter) NetworkFilter {
+	return notFilter{f}
+}
+
+func (n notFilter) Contains(ip net.IP) bool {
+	return !n.NetworkFilter.Contains(ip)
+}
+
+func (n notFilter) Explain(ip net.IP) Decision {
+	if d := Explain(n.NetworkFilter, ip); d.Contains {
+		return Decision{Rule: d.Rule, Excluded: true}
+	}
+
+	return Decision{Contains: true, Rule: n}
+}
+
+func (n notFilter) String() string {
+	return fmt.Sprintf("not %v", n.NetworkFilter)
+}
+
+// Decision describes the outcome of a NetworkFilter for a given ip.
+type Decision struct {
+	// Contains is the same answer Contains would have given.
+	Contains bool
+
+	// Rule is the filter that decided the outcome, it's nil if nothing
+	// matched at all.
+	Rule NetworkFilter
+
+	// Excluded is true if Rule matched the ip but, by way of Except or Not,
+	// caused it to be left out.
+	Excluded bool
+}
+
+func (d Decision) String() string {
+	switch {
+	case d.Rule == nil:
+		return "not contained by any rule"
+	case d.Excluded:
+		return fmt.Sprintf("excluded by %v", d.Rule)
+	case d.Contains:
+		return fmt.Sprintf("contained by %v", d.Rule)
+	}
+
+	return fmt.Sprintf("not contained by %v", d.Rule)
+}
+
+// Explainer is implemented by NetworkFilters that can describe how they came
+// to their decision.
+type Explainer interface {
+	Explain(ip net.IP) Decision
+}
+
+// Explain reports which rule of the given filter decided whether or not it
+// contains ip, filters that don't implement Explainer are treated as a
+// single rule.
+func Explain(f NetworkFilter, ip net.IP) Decision {
+	if e, ok := f.(Explainer); ok {
+		return e.Explain(ip)
+	}
+
+	return Decision{Contains: f.Contains(ip), Rule: f}
+}
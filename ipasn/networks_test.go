@@ -3,6 +3,7 @@
 package ipasn_test
 
 import (
+	"fmt"
 	"net"
 	"testing"
 
@@ -50,3 +51,47 @@ func TestNoNetworks(t *testing.T) {
 		})
 	}
 }
+
+func TestNetworkAlgebra(t *testing.T) {
+	t.Parallel()
+
+	lab := &net.IPNet{IP: net.IP{10, 99, 0, 0}, Mask: net.IPMask{255, 255, 0, 0}}
+	ten := &net.IPNet{IP: net.IP{10, 0, 0, 0}, Mask: net.IPMask{255, 0, 0, 0}}
+	upper := &net.IPNet{IP: net.IP{10, 128, 0, 0}, Mask: net.IPMask{255, 128, 0, 0}}
+	eight := &net.IPNet{IP: net.IP{8, 8, 8, 0}, Mask: net.IPMask{255, 255, 255, 0}}
+
+	filter := ipasn.Except(ipasn.DefaultPrivateNetworks(), lab)
+
+	tests := []struct {
+		filter   ipasn.NetworkFilter
+		ip       net.IP
+		expected bool
+		explain  string
+	}{
+		{filter, net.IPv4(10, 1, 2, 3), true, "contained by 10.0.0.0/8"},
+		{filter, net.IPv4(10, 99, 2, 3), false, "excluded by 10.99.0.0/16"},
+		{filter, net.IPv4(8, 8, 8, 8), false, "not contained by any rule"},
+		{ipasn.Except(ipasn.DefaultPrivateNetworks(), ipasn.Networks{eight}), net.IPv4(8, 8, 8, 8), false, "not contained by any rule"},
+		{ipasn.Intersect(ten, upper), net.IPv4(10, 200, 0, 1), true, "contained by 10.128.0.0/9"},
+		{ipasn.Intersect(ten, upper), net.IPv4(10, 1, 0, 1), false, "not contained by 10.128.0.0/9"},
+		{ipasn.Intersect(), net.IPv4(10, 1, 0, 1), false, "not contained by any rule"},
+		{ipasn.Not(ten), net.IPv4(10, 1, 0, 1), false, "excluded by 10.0.0.0/8"},
+		{ipasn.Not(ten), net.IPv4(8, 8, 8, 8), true, "contained by not 10.0.0.0/8"},
+		{ipasn.Not(ipasn.NoPrivateNetworks()), net.IPv4(8, 8, 8, 8), true, "contained by not no networks"},
+		{ipasn.Networks{ipasn.Not(filter)}, net.IPv4(10, 99, 2, 3), true, "contained by not [" +
+			"0.0.0.0/8 10.0.0.0/8 100.64.0.0/10 127.0.0.0/8 172.16.0.0/12 192.0.0.0/24 192.0.2.0/24 " +
+			"192.88.99.0/24 192.168.0.0/16 198.18.0.0/15 198.51.100.0/24 203.0.113.0/24] except 10.99.0.0/16"},
+	}
+
+	for i, test := range tests {
+		i, test := i, test
+		t.Run(fmt.Sprintf("case_%d", i), func(t *testing.T) {
+			t.Parallel()
+			require.Equal(t, test.expected, test.filter.Contains(test.ip))
+
+			d := ipasn.Explain(test.filter, test.ip)
+			require.Equal(t, test.expected, d.Contains)
+			require.Equal(t, test.explain, d.String())
+		})
+	}
+}
@@ -0,0 +1,96 @@
+// Copyright 2019 Freman/Fremnet (Shannon Wynter). All rights reserved.
+
+package ipasn
+
+import (
+	"context"
+	"net"
+	"time"
+)
+
+// CacheStatus is whether a lookup was answered from the Client's Cache
+type CacheStatus int
+
+// The cache statuses of a lookup
+const (
+	// CacheNone means the Cache wasn't consulted, either there isn't one, it
+	// was bypassed or the query was rejected before getting that far
+	CacheNone CacheStatus = iota
+	CacheHit
+	CacheMiss
+)
+
+func (s CacheStatus) String() string {
+	switch s {
+	case CacheHit:
+		return "hit"
+	case CacheMiss:
+		return "miss"
+	}
+
+	return "none"
+}
+
+// LookupEvent is passed to Client.OnLookup after every call to Origin, Peer
+// or ASN.
+type LookupEvent struct {
+	// Zone is the zone queried, one of origin, origin6, peer or asn
+	Zone string
+
+	// Name is the DNS query name, it's empty if the query was rejected
+	// before being made, eg: with ErrIPIsPrivate
+	Name string
+
+	Cache    CacheStatus
+	Duration time.Duration
+	Err      error
+
+	// ASN and Network are the result of a successful lookup, the origin ASN
+	// and prefix of an origin lookup, the prefix of a peer lookup or the ASN
+	// of an asn lookup
+	ASN     int
+	Network *net.IPNet
+}
+
+// zoneOf returns the zone an IP is looked up in
+func zoneOf(ip net.IP, zone string) string {
+	if zone == "origin" && ip.To4() == nil {
+		return "origin6"
+	}
+
+	return zone
+}
+
+// Tracer is told about the start of every lookup, it returns the context
+// the lookup continues with, eg: carrying a span, which is passed to the
+// Resolver, and a function to call with the finished LookupEvent.
+type Tracer interface {
+	StartLookup(ctx context.Context, ev LookupEvent) (context.Context, func(LookupEvent))
+}
+
+// trace starts the lookup with the Tracer, if there is one
+func (c *Client) trace(ctx context.Context, ev LookupEvent) (context.Context, func(LookupEvent)) {
+	if c.Tracer == nil {
+		return ctx, nil
+	}
+
+	return c.Tracer.StartLookup(ctx, ev)
+}
+
+// observe finishes the event and passes it to OnLookup and the Tracer
+func (c *Client) observe(ev *LookupEvent, start time.Time, finish func(LookupEvent), err error) {
+	if c.OnLookup == nil && finish == nil {
+		return
+	}
+
+	ev.Duration = time.Since(start)
+	ev.Err = err
+
+	if c.OnLookup != nil {
+		c.OnLookup(*ev)
+	}
+
+	if finish != nil {
+		finish(*ev)
+	}
+}
@@ -0,0 +1,189 @@
+// Copyright 2019 Freman/Fremnet (Shannon Wynter). All rights reserved.
+
+package ipasn
+
+import "time"
+
+// Option configures a Client created with NewClient
+type Option func(*Client)
+
+// WithResolver sets the Resolver used by the Client
+func WithResolver(r Resolver) Option {
+	return func(c *Client) {
+		c.Resolver = r
+	}
+}
+
+// WithPrivateNetworks sets the NetworkFilter used to block queries for
+// private networks
+func WithPrivateNetworks(f NetworkFilter) Option {
+	return func(c *Client) {
+		c.PrivateNetworks = f
+	}
+}
+
+// WithCache sets the Cache used to store DNS results
+func WithCache(cache Cache) Option {
+	return func(c *Client) {
+		c.Cache = cache
+	}
+}
+
+// WithStrictParsing causes the Client to return ErrMalformed rather than
+// partially populated results when a DNS result can't be parsed
+func WithStrictParsing() Option {
+	return func(c *Client) {
+		c.Strict = true
+	}
+}
+
+// WithZones sets the zones queried by the Client, eg: ZonesUnder of a mirror
+func WithZones(z Zones) Option {
+	return func(c *Client) {
+		c.Zones = z
+	}
+}
+
+// WithProvider sets the Provider queried by the Client, eg: RouteViews
+func WithProvider(p Provider) Option {
+	return func(c *Client) {
+		c.Provider = p
+	}
+}
+
+// WithFallbacks sets the Providers tried, in order, when the Client's own
+// Provider fails
+func WithFallbacks(p ...Provider) Option {
+	return func(c *Client) {
+		c.Fallbacks = p
+	}
+}
+
+// WithOnLookup sets the function called after every lookup, see LookupEvent
+func WithOnLookup(f func(LookupEvent)) Option {
+	return func(c *Client) {
+		c.OnLookup = f
+	}
+}
+
+// WithTracer sets the Tracer that wraps every lookup
+func WithTracer(t Tracer) Option {
+	return func(c *Client) {
+		c.Tracer = t
+	}
+}
+
+// NewClient returns a Client configured with the given options, any that
+// aren't given fall back to the same defaults as the zero Client.
+func NewClient(opts ...Option) *Client {
+	c := &Client{}
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return c
+}
+
+// CallOption alters the behaviour of a single call to Origin, Peer or ASN
+// without changing the Client
+type CallOption func(*callOptions)
+
+type callOptions struct {
+	resolver    Resolver
+	skipFilter  bool
+	bypassCache bool
+	strict      bool
+	providers   []Provider
+	ttl         *time.Duration
+}
+
+// SkipFilter disables the private network check for this call, the
+// unspecified, loopback and multicast checks still apply
+func SkipFilter() CallOption {
+	return func(o *callOptions) {
+		o.skipFilter = true
+	}
+}
+
+// BypassCache neither reads from nor writes to the Client's Cache for this call
+func BypassCache() CallOption {
+	return func(o *callOptions) {
+		o.bypassCache = true
+	}
+}
+
+// UseResolver uses the given Resolver for this call instead of the Client's,
+// it also bypasses the Client's Cache as that holds the answers of the
+// Client's Resolver
+func UseResolver(r Resolver) CallOption {
+	return func(o *callOptions) {
+		o.resolver = r
+		o.bypassCache = true
+	}
+}
+
+// UseProvider queries only the given Provider for this call, without the
+// Client's fallbacks, eg: to cross check the Client's Provider
+func UseProvider(p Provider) CallOption {
+	return func(o *callOptions) {
+		o.providers = []Provider{p}
+	}
+}
+
+// ReportTTL sets *d to how much longer the answer will be cached for, if the
+// Client's Cache is a TTLCache, eg: for the max-age of an HTTP response. It's
+// left alone if the answer wasn't cached.
+func ReportTTL(d *time.Duration) CallOption {
+	return func(o *callOptions) {
+		o.ttl = d
+	}
+}
+
+// StrictParsing returns ErrMalformed for this call if the DNS result can't
+// be parsed
+func StrictParsing() CallOption {
+	return func(o *callOptions) {
+		o.strict = true
+	}
+}
+
+// callOptions combines the Client's configuration with the per call options
+func (c *Client) callOptions(opts []CallOption) callOptions {
+	o := callOptions{
+		resolver: c.Resolver,
+		strict:   c.Strict,
+	}
+
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	if o.resolver == nil {
+		o.resolver = defaultResolver
+	}
+
+	if o.providers == nil {
+		o.providers = append([]Provider{c.provider()}, c.Fallbacks...)
+	}
+
+	return o
+}
+
+// provider returns the Client's Provider, falling back to Cymru in its Zones
+func (c *Client) provider() Provider {
+	if c.Provider == nil {
+		return Cymru{Zones: c.Zones}
+	}
+
+	return c.Provider
+}
+
+// parsed decides what to make of err from a Provider's parse method, partial
+// results are good enough unless parsing is strict
+func (o callOptions) parsed(err error) error {
+	if err == ErrMalformed && !o.strict {
+		return nil
+	}
+
+	return err
+}
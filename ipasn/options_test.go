@@ -0,0 +1,273 @@
+// Copyright 2019 Freman/Fremnet (Shannon Wynter). All rights reserved.
+
+package ipasn_test
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/freman/cymru/ipasn"
+)
+
+//nolint:gochecknoglobals
+var malformedResolver = mockResolver(func(ctx context.Context, name string) ([]string, error) {
+	switch name {
+	case "31.108.90.216.origin.asn.cymru.com.":
+		return []string{"23028 | 216.90.108.0/24 | US | arin | yesterday"}, nil
+	case "31.108.90.216.peer.asn.cymru.com.":
+		return []string{"701 x | 216.90.108.0/24 | US | arin | 1998-09-25"}, nil
+	case "AS23028.asn.cymru.com.":
+		return []string{"23028 | US | arin"}, nil
+	}
+	return nil, errors.New("what? " + name + " not found")
+})
+
+func TestStrictParsing(t *testing.T) {
+	t.Parallel()
+
+	ip := net.IPv4(216, 90, 108, 31)
+
+	lenient := ipasn.NewClient(ipasn.WithResolver(malformedResolver))
+
+	o, err := lenient.Origin(context.TODO(), ip)
+	require.NoError(t, err)
+	require.Equal(t, 23028, o.ASN)
+	require.True(t, o.Updated.IsZero())
+
+	_, err = lenient.Origin(context.TODO(), ip, ipasn.StrictParsing())
+	require.Equal(t, ipasn.ErrMalformed, err)
+
+	strict := ipasn.NewClient(ipasn.WithResolver(malformedResolver), ipasn.WithStrictParsing())
+
+	o, err = strict.Origin(context.TODO(), ip)
+	require.Equal(t, ipasn.ErrMalformed, err)
+	require.Equal(t, ipasn.OriginInfo{}, o)
+
+	p, err := strict.Peer(context.TODO(), ip)
+	require.Equal(t, ipasn.ErrMalformed, err)
+	require.Equal(t, ipasn.PeerInfo{}, p)
+
+	a, err := strict.ASN(context.TODO(), 23028)
+	require.Equal(t, ipasn.ErrMalformed, err)
+	require.Equal(t, ipasn.ASNInfo{}, a)
+}
+
+func TestCallOptions(t *testing.T) {
+	t.Parallel()
+
+	var lookups int32
+
+	counting := mockResolver(func(ctx context.Context, name string) ([]string, error) {
+		atomic.AddInt32(&lookups, 1)
+		return resolver(ctx, name)
+	})
+
+	c := ipasn.NewClient(
+		ipasn.WithResolver(counting),
+		ipasn.WithCache(ipasn.NewMemoryCache(time.Minute, 0)),
+		ipasn.WithPrivateNetworks(ipasn.Networks{&net.IPNet{IP: net.IP{216, 90, 108, 0}, Mask: net.IPMask{255, 255, 255, 0}}}),
+	)
+
+	ip := net.IPv4(216, 90, 108, 31)
+
+	_, err := c.Origin(context.TODO(), ip)
+	require.Equal(t, ipasn.ErrIPIsPrivate, err)
+
+	o, err := c.Origin(context.TODO(), ip, ipasn.SkipFilter())
+	require.NoError(t, err)
+	require.Equal(t, 23028, o.ASN)
+	require.EqualValues(t, 1, atomic.LoadInt32(&lookups))
+
+	// Served from cache
+	_, err = c.Origin(context.TODO(), ip, ipasn.SkipFilter())
+	require.NoError(t, err)
+	require.EqualValues(t, 1, atomic.LoadInt32(&lookups))
+
+	_, err = c.Origin(context.TODO(), ip, ipasn.SkipFilter(), ipasn.BypassCache())
+	require.NoError(t, err)
+	require.EqualValues(t, 2, atomic.LoadInt32(&lookups))
+
+	// Loopback is never skipped
+	_, err = c.Origin(context.TODO(), net.IPv4(127, 0, 0, 1), ipasn.SkipFilter())
+	require.Equal(t, ipasn.ErrIPIsLoopback, err)
+
+	a, err := c.ASN(context.TODO(), 1234, ipasn.UseResolver(resolver))
+	require.NoError(t, err)
+	require.Equal(t, "FORTUM-AS | Fortum, FI", a.Description)
+	require.EqualValues(t, 2, atomic.LoadInt32(&lookups))
+
+	// Another resolver neither reads nor writes the cache
+	other := mockResolver(func(ctx context.Context, name string) ([]string, error) {
+		return []string{"64512 | 216.90.108.0/24 | US | arin | 1998-09-25"}, nil
+	})
+
+	o, err = c.Origin(context.TODO(), ip, ipasn.SkipFilter(), ipasn.UseResolver(other))
+	require.NoError(t, err)
+	require.Equal(t, 64512, o.ASN)
+
+	o, err = c.Origin(context.TODO(), ip, ipasn.SkipFilter())
+	require.NoError(t, err)
+	require.Equal(t, 23028, o.ASN)
+	require.EqualValues(t, 2, atomic.LoadInt32(&lookups))
+}
+
+func TestReportTTL(t *testing.T) {
+	t.Parallel()
+
+	c := ipasn.NewClient(
+		ipasn.WithResolver(resolver),
+		ipasn.WithCache(ipasn.NewMemoryCache(time.Minute, 0)),
+	)
+
+	ttl := time.Duration(-1)
+
+	_, err := c.ASN(context.TODO(), 1234, ipasn.ReportTTL(&ttl))
+	require.NoError(t, err)
+	require.True(t, ttl > 50*time.Second && ttl <= time.Minute, ttl)
+
+	// Left alone when the answer isn't cached
+	ttl = -1
+
+	_, err = c.ASN(context.TODO(), 1234, ipasn.ReportTTL(&ttl), ipasn.BypassCache())
+	require.NoError(t, err)
+	require.Equal(t, time.Duration(-1), ttl)
+}
+
+// TestConcurrentZeroClient is mostly useful with the race detector, the zero
+// Client used to fill in its own defaults on first use.
+func TestConcurrentZeroClient(t *testing.T) {
+	t.Parallel()
+
+	c := &ipasn.Client{}
+
+	var wg sync.WaitGroup
+
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			_, err := c.Origin(context.TODO(), net.IPv4(192, 168, 0, 1), ipasn.UseResolver(resolver))
+			require.Equal(t, ipasn.ErrIPIsPrivate, err)
+
+			_, err = c.ASN(context.TODO(), 23028, ipasn.UseResolver(resolver))
+			require.NoError(t, err)
+		}()
+	}
+
+	wg.Wait()
+}
+
+func TestOnLookup(t *testing.T) {
+	t.Parallel()
+
+	var (
+		mu     sync.Mutex
+		events []ipasn.LookupEvent
+	)
+
+	client := ipasn.NewClient(
+		ipasn.WithResolver(resolver),
+		ipasn.WithCache(ipasn.NewMemoryCache(time.Minute, 0)),
+		ipasn.WithOnLookup(func(ev ipasn.LookupEvent) {
+			mu.Lock()
+			events = append(events, ev)
+			mu.Unlock()
+		}),
+	)
+
+	ctx := context.TODO()
+	_, _ = client.Origin(ctx, net.IPv4(216, 90, 108, 31))
+	_, _ = client.Origin(ctx, net.IPv4(216, 90, 108, 31))
+	_, _ = client.Origin(ctx, net.ParseIP("2001:4860:b002::68"), ipasn.BypassCache())
+	_, _ = client.Peer(ctx, net.IPv4(8, 8, 8, 8))
+	_, _ = client.Origin(ctx, net.IPv4(10, 0, 0, 1))
+	_, _ = client.ASN(ctx, 23028)
+
+	_, v4 := parseCIDR("216.90.108.0/24")
+	_, v6 := parseCIDR("2001:4860::/32")
+
+	for i := range events {
+		events[i].Duration = 0
+	}
+
+	require.Equal(t, []ipasn.LookupEvent{
+		{Zone: "origin", Name: "31.108.90.216.origin.asn.cymru.com.", Cache: ipasn.CacheMiss, ASN: 23028, Network: v4},
+		{Zone: "origin", Name: "31.108.90.216.origin.asn.cymru.com.", Cache: ipasn.CacheHit, ASN: 23028, Network: v4},
+		{Zone: "origin6", Name: "8.6.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.2.0.0.b.0.6.8.4.1.0.0.2.origin6.asn.cymru.com.", Cache: ipasn.CacheNone, ASN: 15169, Network: v6},
+		{Zone: "peer", Name: "8.8.8.8.peer.asn.cymru.com.", Cache: ipasn.CacheMiss, Err: ipasn.ErrNotFound},
+		{Zone: "origin", Err: ipasn.ErrIPIsPrivate},
+		{Zone: "asn", Name: "AS23028.asn.cymru.com.", Cache: ipasn.CacheMiss, ASN: 23028},
+	}, events)
+}
+
+type ctxKey struct{}
+
+type testTracer struct {
+	mu     sync.Mutex
+	starts []ipasn.LookupEvent
+	ends   []ipasn.LookupEvent
+}
+
+func (tt *testTracer) StartLookup(ctx context.Context, ev ipasn.LookupEvent) (context.Context, func(ipasn.LookupEvent)) {
+	tt.mu.Lock()
+	tt.starts = append(tt.starts, ev)
+	tt.mu.Unlock()
+
+	return context.WithValue(ctx, ctxKey{}, ev.Zone), func(ev ipasn.LookupEvent) {
+		tt.mu.Lock()
+		tt.ends = append(tt.ends, ev)
+		tt.mu.Unlock()
+	}
+}
+
+func TestTracer(t *testing.T) {
+	t.Parallel()
+
+	var zones []interface{}
+
+	tracer := &testTracer{}
+	client := ipasn.NewClient(
+		ipasn.WithTracer(tracer),
+		ipasn.WithResolver(mockResolver(func(ctx context.Context, name string) ([]string, error) {
+			zones = append(zones, ctx.Value(ctxKey{}))
+			return resolver(ctx, name)
+		})),
+	)
+
+	_, err := client.Peer(context.TODO(), net.IPv4(216, 90, 108, 31))
+	require.NoError(t, err)
+
+	_, err = client.ASN(context.TODO(), 911)
+	require.Equal(t, ipasn.ErrNotFound, err)
+
+	_, err = client.Origin(context.TODO(), net.IPv4(127, 0, 0, 1))
+	require.Equal(t, ipasn.ErrIPIsLoopback, err)
+
+	_, v4 := parseCIDR("216.90.108.0/24")
+
+	require.Equal(t, []interface{}{"peer", "asn"}, zones)
+	require.Equal(t, []ipasn.LookupEvent{{Zone: "peer"}, {Zone: "asn"}, {Zone: "origin"}}, tracer.starts)
+	require.Len(t, tracer.ends, 3)
+	require.Equal(t, v4, tracer.ends[0].Network)
+	require.Equal(t, "AS911.asn.cymru.com.", tracer.ends[1].Name)
+	require.Equal(t, ipasn.ErrNotFound, tracer.ends[1].Err)
+	require.Equal(t, ipasn.ErrIPIsLoopback, tracer.ends[2].Err)
+}
+
+func parseCIDR(s string) (net.IP, *net.IPNet) {
+	ip, network, err := net.ParseCIDR(s)
+	if err != nil {
+		panic(err)
+	}
+
+	return ip, network
+}
@@ -0,0 +1,63 @@
+// Copyright 2019 Freman/Fremnet (Shannon Wynter). All rights reserved.
+
+package policy
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+
+	"github.com/freman/cymru/ipasn"
+	"github.com/freman/cymru/ipasn/middleware"
+)
+
+// errNoInfo is used when Handler isn't wrapped by middleware.Middleware
+var errNoInfo = errors.New("policy: no client information in the request context")
+
+// Lookup returns the attributes of ip, looking up its origin and the
+// description of the ASN
+func Lookup(ctx context.Context, client *ipasn.Client, ip net.IP) Attributes {
+	a := Attributes{Key: ip.String()}
+
+	a.Origin, a.Err = client.Origin(ctx, ip)
+	if a.Err != nil {
+		return a
+	}
+
+	a.ASN, _ = client.ASN(ctx, a.Origin.ASN)
+
+	return a
+}
+
+// Check looks up ip and decides what to do with it, eg: in a net.Listener
+// accept loop
+func (p *Policy) Check(ctx context.Context, client *ipasn.Client, ip net.IP) Decision {
+	return p.Evaluate(Lookup(ctx, client, ip))
+}
+
+// Handler enforces the policy on requests, responding 403 Forbidden to those
+// denied and 429 Too Many Requests to those rate limited. It uses the client
+// information stored by middleware.Middleware so must be wrapped by it, eg:
+//
+//	m.Handler(p.Handler(next))
+func (p *Policy) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		a := Attributes{Path: r.URL.Path, Err: errNoInfo}
+
+		if info, ok := middleware.FromContext(r.Context()); ok {
+			a.Origin, a.ASN, a.Key, a.Err = info.Origin, info.ASN, info.IP.String(), info.Err
+		}
+
+		d := p.Evaluate(a)
+
+		switch {
+		case d.Limited:
+			http.Error(w, http.StatusText(http.StatusTooManyRequests), http.StatusTooManyRequests)
+		case !d.Allowed:
+			http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+		default:
+			next.ServeHTTP(w, r)
+		}
+	})
+}
@@ -0,0 +1,73 @@
+// Copyright 2019 Freman/Fremnet (Shannon Wynter). All rights reserved.
+
+package policy_test
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/freman/cymru/ipasn"
+	"github.com/freman/cymru/ipasn/ipasntest"
+	"github.com/freman/cymru/ipasn/middleware"
+	"github.com/freman/cymru/ipasn/policy"
+)
+
+func TestCheck(t *testing.T) {
+	t.Parallel()
+
+	client := ipasn.NewClient(ipasn.WithResolver(ipasntest.NewResolver(ipasntest.Golden())))
+	p := &policy.Policy{Rules: []policy.Rule{{Name: "cymru", Descriptions: []string{"team cymru"}, Action: policy.Deny}}}
+
+	require.Equal(t, "denied by cymru", p.Check(context.TODO(), client, net.ParseIP("216.90.108.31")).String())
+	require.Equal(t, "allowed by default", p.Check(context.TODO(), client, net.ParseIP("2001:4860::1")).String())
+	require.Equal(t, "allowed on filtered address", p.Check(context.TODO(), client, net.ParseIP("192.168.0.1")).String())
+}
+
+func TestHandler(t *testing.T) {
+	t.Parallel()
+
+	m := &middleware.Middleware{Client: ipasn.NewClient(ipasn.WithResolver(ipasntest.NewResolver(ipasntest.Golden())))}
+	p := &policy.Policy{
+		Rules: []policy.Rule{
+			{Name: "cymru signups", ASNs: []int{23028}, Paths: []string{"/signup"}, Action: policy.Deny},
+			{Name: "google", ASNs: []int{15169}, Action: policy.Limit, Rate: 1},
+		},
+		OnError: policy.Deny,
+	}
+
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+
+	tests := []struct {
+		name     string
+		handler  http.Handler
+		remote   string
+		path     string
+		expected []int
+	}{
+		{"allowed", m.Handler(p.Handler(ok)), "216.90.108.31:1", "/", []int{200, 200}},
+		{"denied", m.Handler(p.Handler(ok)), "216.90.108.31:1", "/signup", []int{403, 403}},
+		{"limited", m.Handler(p.Handler(ok)), "[2001:4860::1]:1", "/", []int{200, 429}},
+		{"lookup failed", m.Handler(p.Handler(ok)), "1.1.1.1:1", "/", []int{403}},
+		{"filtered", m.Handler(p.Handler(ok)), "192.168.0.1:1", "/", []int{200}},
+		{"no middleware", p.Handler(ok), "216.90.108.31:1", "/", []int{403}},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			for _, expected := range test.expected {
+				req := httptest.NewRequest(http.MethodGet, test.path, nil)
+				req.RemoteAddr = test.remote
+
+				rec := httptest.NewRecorder()
+				test.handler.ServeHTTP(rec, req)
+				require.Equal(t, expected, rec.Code)
+			}
+		})
+	}
+}
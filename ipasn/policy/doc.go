@@ -0,0 +1,5 @@
+// Copyright 2019 Freman/Fremnet (Shannon Wynter). All rights reserved.
+
+// Package policy implements access control and rate limiting rules over the origin ASN, country,
+// registry and AS description of clients, for use with net/http or net.Listener accept loops.
+package policy
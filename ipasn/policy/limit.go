@@ -0,0 +1,112 @@
+// Copyright 2019 Freman/Fremnet (Shannon Wynter). All rights reserved.
+
+package policy
+
+import (
+	"strconv"
+	"time"
+)
+
+// maxBuckets is how many rate limit buckets are kept before full ones, or
+// failing that the least recently used, are discarded
+const maxBuckets = 10000
+
+type bucketKey struct {
+	rule int
+	key  string
+}
+
+// bucket is a token bucket
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// take removes a token from the client's bucket for the rule reporting
+// whether there was one
+func (p *Policy) take(i int, r *Rule, a Attributes, now time.Time) bool {
+	key := bucketKey{rule: i}
+
+	switch r.Per {
+	case "client":
+		key.key = a.Key
+	case "rule":
+	default:
+		key.key = strconv.Itoa(a.Origin.ASN)
+	}
+
+	burst := r.burst()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.buckets == nil {
+		p.buckets = make(map[bucketKey]*bucket)
+	}
+
+	b, found := p.buckets[key]
+	if !found {
+		if len(p.buckets) >= maxBuckets {
+			p.prune(now)
+		}
+
+		b = &bucket{tokens: burst, last: now}
+		p.buckets[key] = b
+	}
+
+	b.tokens += now.Sub(b.last).Seconds() * r.Rate
+	if b.tokens > burst {
+		b.tokens = burst
+	}
+
+	b.last = now
+
+	if b.tokens < 1 {
+		return false
+	}
+
+	b.tokens--
+
+	return true
+}
+
+// prune discards the buckets that would have refilled by now, or the least
+// recently used if none have, so there's always room for another
+func (p *Policy) prune(now time.Time) {
+	var (
+		oldest    bucketKey
+		oldestAt  time.Time
+		hasOldest bool
+	)
+
+	for key, b := range p.buckets {
+		r := &p.Rules[key.rule]
+
+		if b.tokens+now.Sub(b.last).Seconds()*r.Rate >= r.burst() {
+			delete(p.buckets, key)
+			continue
+		}
+
+		if !hasOldest || b.last.Before(oldestAt) {
+			oldest, oldestAt, hasOldest = key, b.last, true
+		}
+	}
+
+	if len(p.buckets) >= maxBuckets && hasOldest {
+		delete(p.buckets, oldest)
+	}
+}
+
+// burst is the size of the bucket, which is at least one
+func (r *Rule) burst() float64 {
+	burst := float64(r.Burst)
+	if burst <= 0 {
+		burst = r.Rate
+	}
+
+	if burst < 1 {
+		burst = 1
+	}
+
+	return burst
+}
@@ -0,0 +1,96 @@
+// Copyright 2019 Freman/Fremnet (Shannon Wynter). All rights reserved.
+
+package policy
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/freman/cymru/ipasn"
+)
+
+func TestLimit(t *testing.T) {
+	t.Parallel()
+
+	p := &Policy{Rules: []Rule{
+		{Name: "per asn", ASNs: []int{1, 2}, Action: Limit, Rate: 2, Burst: 3},
+		{Name: "per client", ASNs: []int{3}, Action: Limit, Rate: 0.5, Per: "client"},
+	}}
+	require.NoError(t, p.Validate())
+
+	now := time.Unix(0, 0)
+	allowed := func(asn int, key string) bool {
+		return p.evaluate(Attributes{Origin: ipasn.OriginInfo{ASN: asn}, Key: key}, now).Allowed
+	}
+
+	// The burst is available straight away, and each ASN has its own
+	for i := 0; i < 3; i++ {
+		require.True(t, allowed(1, ""))
+		require.True(t, allowed(2, ""))
+	}
+
+	require.False(t, allowed(1, ""))
+
+	// Refilled at the rate
+	now = now.Add(500 * time.Millisecond)
+	require.True(t, allowed(1, ""))
+	require.False(t, allowed(1, ""))
+
+	// Never more than the burst
+	now = now.Add(time.Hour)
+	for i := 0; i < 3; i++ {
+		require.True(t, allowed(1, ""))
+	}
+
+	require.False(t, allowed(1, ""))
+
+	// A burst of at least one even when the rate is slower
+	require.True(t, allowed(3, "a"))
+	require.True(t, allowed(3, "b"))
+	require.False(t, allowed(3, "a"))
+
+	now = now.Add(2 * time.Second)
+	require.True(t, allowed(3, "a"))
+}
+
+func TestLimitPrune(t *testing.T) {
+	t.Parallel()
+
+	p := &Policy{Rules: []Rule{{Action: Limit, Rate: 1, Per: "client"}}}
+	now := time.Unix(0, 0)
+
+	for i := 0; i < maxBuckets; i++ {
+		p.evaluate(Attributes{Key: strconv.Itoa(i)}, now)
+	}
+
+	require.Len(t, p.buckets, maxBuckets)
+
+	// Everyone has refilled so they can go
+	now = now.Add(time.Second)
+	p.evaluate(Attributes{Key: "new"}, now)
+	require.Len(t, p.buckets, 1)
+}
+
+func TestLimitPruneFull(t *testing.T) {
+	t.Parallel()
+
+	p := &Policy{Rules: []Rule{{Action: Limit, Rate: 0.001, Per: "client"}}}
+	now := time.Unix(0, 0)
+
+	for i := 0; i < maxBuckets; i++ {
+		p.evaluate(Attributes{Key: strconv.Itoa(i)}, now.Add(time.Duration(i)*time.Millisecond))
+	}
+
+	// Nobody has refilled, so the least recently used makes room
+	now = now.Add(time.Minute)
+	require.True(t, p.evaluate(Attributes{Key: "new"}, now).Allowed)
+	require.Len(t, p.buckets, maxBuckets)
+	require.NotContains(t, p.buckets, bucketKey{key: "0"})
+	require.Contains(t, p.buckets, bucketKey{key: "1"})
+
+	// Existing buckets keep their state
+	require.False(t, p.evaluate(Attributes{Key: "1"}, now).Allowed)
+}
@@ -0,0 +1,187 @@
+// Copyright 2019 Freman/Fremnet (Shannon Wynter). All rights reserved.
+
+package policy
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/freman/cymru/ipasn"
+)
+
+// Listener is a net.Listener that looks up the remote address of each
+// connection and closes those the Policy doesn't allow before Accept returns
+// them. Rate limits apply to connections.
+//
+// Lookups happen concurrently so a slow one doesn't hold up the others, and
+// connections without an IP address, eg: on unix sockets, are always allowed.
+type Listener struct {
+	net.Listener
+
+	// Policy decides which connections are allowed
+	Policy *Policy
+
+	// Client does the lookups, it defaults to a Client that caches answers
+	// for an hour
+	Client *ipasn.Client
+
+	// Timeout limits each lookup, it defaults to a second
+	Timeout time.Duration
+
+	// FailClosed closes connections whose lookups time out, otherwise they're
+	// accepted. Peers the Client filters without a lookup, eg: loopback or
+	// private addresses of a local proxy, are decided by Policy.OnFiltered
+	// and other lookup failures by Policy.OnError.
+	FailClosed bool
+
+	// OnReject, if set, is called with each connection before it's closed
+	OnReject func(conn net.Conn, d Decision)
+
+	once      sync.Once
+	closeOnce sync.Once
+	conns     chan net.Conn
+	temp      chan error
+	done      chan struct{}
+	stopped   chan struct{}
+	err       error
+}
+
+// NewListener returns a Listener enforcing p on the connections accepted by l
+func NewListener(l net.Listener, p *Policy) *Listener {
+	return &Listener{Listener: l, Policy: p}
+}
+
+// errListenerClosed is returned by Accept after Close
+var errListenerClosed = errors.New("policy: listener closed")
+
+// Accept waits for and returns the next allowed connection
+func (l *Listener) Accept() (net.Conn, error) {
+	l.once.Do(l.start)
+
+	select {
+	case conn := <-l.conns:
+		return conn, nil
+	case err := <-l.temp:
+		return nil, err
+	case <-l.stopped:
+		return nil, l.err
+	}
+}
+
+// Close closes the underlying listener, connections still being looked up are
+// closed
+func (l *Listener) Close() error {
+	l.once.Do(l.start)
+
+	err := errListenerClosed
+
+	l.closeOnce.Do(func() {
+		close(l.done)
+		err = l.Listener.Close()
+	})
+
+	return err
+}
+
+func (l *Listener) start() {
+	l.conns = make(chan net.Conn)
+	l.temp = make(chan error)
+	l.done = make(chan struct{})
+	l.stopped = make(chan struct{})
+
+	go l.acceptLoop()
+}
+
+func (l *Listener) acceptLoop() {
+	defer close(l.stopped)
+
+	client := l.Client
+	if client == nil {
+		client = ipasn.NewClient(ipasn.WithCache(ipasn.NewMemoryCache(time.Hour, 100000)))
+	}
+
+	for {
+		conn, err := l.Listener.Accept()
+		if err != nil {
+			if ne, ok := err.(net.Error); ok && ne.Temporary() {
+				select {
+				case l.temp <- err:
+					continue
+				case <-l.done:
+				}
+			}
+
+			select {
+			case <-l.done:
+				err = errListenerClosed
+			default:
+			}
+
+			l.err = err
+
+			return
+		}
+
+		go l.check(client, conn)
+	}
+}
+
+// check hands the connection to Accept if it's allowed, otherwise closes it
+func (l *Listener) check(client *ipasn.Client, conn net.Conn) {
+	if d, allowed := l.decide(client, conn); !allowed {
+		if l.OnReject != nil {
+			l.OnReject(conn, d)
+		}
+
+		conn.Close()
+
+		return
+	}
+
+	select {
+	case l.conns <- conn:
+	case <-l.done:
+		conn.Close()
+	}
+}
+
+func (l *Listener) decide(client *ipasn.Client, conn net.Conn) (Decision, bool) {
+	var ip net.IP
+
+	switch addr := conn.RemoteAddr().(type) {
+	case *net.TCPAddr:
+		ip = addr.IP
+	case *net.UDPAddr:
+		ip = addr.IP
+	case *net.IPAddr:
+		ip = addr.IP
+	default:
+		return Decision{Allowed: true}, true
+	}
+
+	timeout := l.Timeout
+	if timeout <= 0 {
+		timeout = time.Second
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	a := Lookup(ctx, client, ip)
+
+	if a.Err != nil && ctx.Err() == context.DeadlineExceeded {
+		d := without(ReasonTimeout, Allow)
+		if l.FailClosed {
+			d = without(ReasonTimeout, Deny)
+		}
+
+		return d, d.Allowed
+	}
+
+	d := l.Policy.Evaluate(a)
+
+	return d, d.Allowed
+}
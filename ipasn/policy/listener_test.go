@@ -0,0 +1,198 @@
+// Copyright 2019 Freman/Fremnet (Shannon Wynter). All rights reserved.
+
+package policy_test
+
+import (
+	"errors"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/freman/cymru/ipasn"
+	"github.com/freman/cymru/ipasn/ipasntest"
+	"github.com/freman/cymru/ipasn/policy"
+)
+
+// fakeListener hands out connections that appear to come from anywhere
+type fakeListener struct {
+	conns  chan net.Conn
+	closed chan struct{}
+	once   sync.Once
+}
+
+func newFakeListener() *fakeListener {
+	return &fakeListener{conns: make(chan net.Conn), closed: make(chan struct{})}
+}
+
+func (f *fakeListener) Accept() (net.Conn, error) {
+	select {
+	case conn := <-f.conns:
+		return conn, nil
+	case <-f.closed:
+		return nil, errors.New("closed")
+	}
+}
+
+func (f *fakeListener) Close() error {
+	f.once.Do(func() { close(f.closed) })
+	return nil
+}
+
+func (f *fakeListener) Addr() net.Addr {
+	return &net.TCPAddr{IP: net.IPv4(127, 0, 0, 1)}
+}
+
+// dial returns the client side of a connection from addr
+func (f *fakeListener) dial(addr net.Addr) net.Conn {
+	server, client := net.Pipe()
+	f.conns <- fakeConn{server, addr}
+
+	return client
+}
+
+type fakeConn struct {
+	net.Conn
+	remote net.Addr
+}
+
+func (f fakeConn) RemoteAddr() net.Addr {
+	return f.remote
+}
+
+func TestListener(t *testing.T) {
+	t.Parallel()
+
+	resolver := ipasntest.NewResolver(ipasntest.Golden())
+	fake := newFakeListener()
+
+	var (
+		mu       sync.Mutex
+		rejected []string
+	)
+
+	l := policy.NewListener(fake, &policy.Policy{
+		Rules:      []policy.Rule{{Name: "google", ASNs: []int{15169}, Action: policy.Deny}},
+		OnError:    policy.Deny,
+		OnFiltered: policy.Deny,
+	})
+	l.Client = ipasn.NewClient(ipasn.WithResolver(resolver))
+	l.Timeout = 50 * time.Millisecond
+	l.FailClosed = true
+	l.OnReject = func(conn net.Conn, d policy.Decision) {
+		mu.Lock()
+		rejected = append(rejected, conn.RemoteAddr().String()+" "+d.String())
+		mu.Unlock()
+	}
+
+	accepted := make(chan net.Conn)
+
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				close(accepted)
+				return
+			}
+
+			accepted <- conn
+		}
+	}()
+
+	denied := fake.dial(&net.TCPAddr{IP: net.ParseIP("2001:4860::1"), Port: 1})
+	private := fake.dial(&net.TCPAddr{IP: net.ParseIP("10.0.0.1"), Port: 2})
+	allowed := fake.dial(&net.TCPAddr{IP: net.ParseIP("216.90.108.31"), Port: 3})
+	unix := fake.dial(&net.UnixAddr{Name: "@", Net: "unix"})
+
+	// Lookups are concurrent so connections aren't necessarily in order
+	var got []string
+
+	for len(got) < 2 {
+		select {
+		case conn := <-accepted:
+			got = append(got, conn.RemoteAddr().String())
+			conn.Close()
+		case <-time.After(5 * time.Second):
+			t.Fatal("no connection accepted")
+		}
+	}
+
+	require.ElementsMatch(t, []string{"216.90.108.31:3", "@"}, got)
+
+	// Rejected connections are closed
+	for _, conn := range []net.Conn{denied, private} {
+		_, err := conn.Read(make([]byte, 1))
+		require.Error(t, err)
+	}
+
+	// Timeouts fail closed
+	resolver.SetLatency(time.Hour)
+	slow := fake.dial(&net.TCPAddr{IP: net.ParseIP("216.90.108.32"), Port: 4})
+
+	_, err := slow.Read(make([]byte, 1))
+	require.Error(t, err)
+
+	mu.Lock()
+	require.ElementsMatch(t, []string{
+		"[2001:4860::1]:1 denied by google",
+		"10.0.0.1:2 denied on filtered address",
+		"216.90.108.32:4 denied on timeout",
+	}, rejected)
+	mu.Unlock()
+
+	require.NoError(t, l.Close())
+
+	select {
+	case _, ok := <-accepted:
+		require.False(t, ok)
+	case <-time.After(5 * time.Second):
+		t.Fatal("Accept didn't return after Close")
+	}
+
+	_, err = l.Accept()
+	require.Error(t, err)
+	require.Error(t, l.Close())
+
+	allowed.Close()
+	unix.Close()
+}
+
+func TestListenerFailOpen(t *testing.T) {
+	t.Parallel()
+
+	resolver := ipasntest.NewResolver(ipasntest.Golden())
+	resolver.SetLatency(time.Hour)
+
+	fake := newFakeListener()
+	l := policy.NewListener(fake, &policy.Policy{OnError: policy.Deny})
+	l.Client = ipasn.NewClient(ipasn.WithResolver(resolver))
+	l.Timeout = 10 * time.Millisecond
+
+	defer l.Close()
+
+	go fake.dial(&net.TCPAddr{IP: net.ParseIP("216.90.108.31"), Port: 1})
+
+	conn, err := l.Accept()
+	require.NoError(t, err)
+	require.Equal(t, "216.90.108.31:1", conn.RemoteAddr().String())
+}
+
+func TestListenerFiltered(t *testing.T) {
+	t.Parallel()
+
+	fake := newFakeListener()
+	l := policy.NewListener(fake, &policy.Policy{OnError: policy.Deny})
+	l.Client = ipasn.NewClient(ipasn.WithResolver(ipasntest.NewResolver(ipasntest.Golden())))
+	l.FailClosed = true
+
+	defer l.Close()
+
+	// A local proxy isn't a failed lookup
+	go fake.dial(&net.TCPAddr{IP: net.ParseIP("127.0.0.1"), Port: 1})
+
+	conn, err := l.Accept()
+	require.NoError(t, err)
+	require.Equal(t, "127.0.0.1:1", conn.RemoteAddr().String())
+}
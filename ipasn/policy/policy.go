@@ -0,0 +1,289 @@
+// Copyright 2019 Freman/Fremnet (Shannon Wynter). All rights reserved.
+
+package policy
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/freman/cymru/ipasn"
+)
+
+// Action is what a rule does with the clients it matches
+type Action string
+
+// Actions
+const (
+	Allow Action = "allow"
+	Deny  Action = "deny"
+	// Limit allows clients until they exceed the rule's Rate
+	Limit Action = "limit"
+)
+
+// Attributes describe a client, Err is set if the lookup failed
+type Attributes struct {
+	Origin ipasn.OriginInfo
+	ASN    ipasn.ASNInfo
+	// Path is the request path, it's empty outside of net/http
+	Path string
+	// Key identifies the client for rate limits that are per client, eg:
+	// the IP address
+	Key string
+	Err error
+}
+
+// Rule matches clients by any combination of attributes, a client must match
+// every attribute given and at least one value of each. A rule without any
+// attributes matches everyone.
+type Rule struct {
+	Name string `json:"name"`
+
+	ASNs       []int    `json:"asns,omitempty"`
+	Countries  []string `json:"countries,omitempty"`
+	Registries []string `json:"registries,omitempty"`
+	// Descriptions match any part of the AS description, ignoring case,
+	// eg: hosting
+	Descriptions []string `json:"descriptions,omitempty"`
+	// Paths match the start of the request path, rules with paths never
+	// match outside of net/http
+	Paths []string `json:"paths,omitempty"`
+
+	Action Action `json:"action"`
+
+	// Rate and Burst configure the Limit action, Rate is per second and
+	// Burst defaults to Rate
+	Rate  float64 `json:"rate,omitempty"`
+	Burst int     `json:"burst,omitempty"`
+	// Per is what the limit is counted by, one of asn (the default), client
+	// or rule
+	Per string `json:"per,omitempty"`
+}
+
+// Matches reports whether the rule matches the client
+func (r *Rule) Matches(a Attributes) bool {
+	return matchInts(r.ASNs, a.Origin.ASN) &&
+		matchStrings(r.Countries, a.Origin.Country, strings.EqualFold) &&
+		matchStrings(r.Registries, a.Origin.Authority, strings.EqualFold) &&
+		matchStrings(r.Descriptions, a.ASN.Description, containsFold) &&
+		matchStrings(r.Paths, a.Path, hasPrefix)
+}
+
+// Policy is an ordered list of rules, the first rule matching a client
+// decides. It's safe for concurrent use once loaded.
+type Policy struct {
+	Rules []Rule `json:"rules"`
+
+	// Default is the action when no rule matches, it defaults to Allow
+	Default Action `json:"default,omitempty"`
+
+	// OnError is the action when the client couldn't be looked up, it
+	// defaults to Allow
+	OnError Action `json:"on_error,omitempty"`
+
+	// OnFiltered is the action when the client's address is filtered by the
+	// Client, eg: loopback or private addresses such as a local reverse proxy,
+	// so was never looked up, it defaults to Allow
+	OnFiltered Action `json:"on_filtered,omitempty"`
+
+	mu      sync.Mutex
+	buckets map[bucketKey]*bucket
+}
+
+// Reasons for decisions that no rule made
+const (
+	ReasonError    = "error"
+	ReasonFiltered = "filtered address"
+	ReasonTimeout  = "timeout"
+)
+
+// Decision is the outcome of evaluating a client
+type Decision struct {
+	Allowed bool
+	Action  Action
+	// Rule is the name of the rule that decided, empty for Default, OnError
+	// and OnFiltered
+	Rule string
+	// Reason is why no rule decided, one of ReasonError, ReasonFiltered or
+	// ReasonTimeout, it's empty when Rule or Default did
+	Reason string
+	// Limited is set when a Limit rule's rate was exceeded
+	Limited bool
+}
+
+func (d Decision) String() string {
+	verb := "denied"
+
+	switch {
+	case d.Limited:
+		verb = "rate limited"
+	case d.Allowed:
+		verb = "allowed"
+	}
+
+	switch {
+	case d.Rule != "":
+		return verb + " by " + d.Rule
+	case d.Reason != "":
+		return verb + " on " + d.Reason
+	}
+
+	return verb + " by default"
+}
+
+// Load reads a policy in JSON, eg:
+//
+//	{
+//	  "rules": [
+//	    {"name": "hosting signups", "descriptions": ["hosting"], "paths": ["/signup"], "action": "deny"},
+//	    {"name": "crawlers", "asns": [15169], "action": "limit", "rate": 10}
+//	  ],
+//	  "default": "allow"
+//	}
+func Load(r io.Reader) (*Policy, error) {
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+
+	p := new(Policy)
+	if err := dec.Decode(p); err != nil {
+		return nil, fmt.Errorf("policy: %w", err)
+	}
+
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+
+	return p, nil
+}
+
+// LoadFile reads a policy from a JSON file, see Load
+func LoadFile(path string) (*Policy, error) {
+	fh, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer fh.Close()
+
+	return Load(fh)
+}
+
+// Validate checks the actions and rate limits make sense
+func (p *Policy) Validate() error {
+	for _, action := range []Action{p.Default, p.OnError, p.OnFiltered} {
+		if action != "" && action != Allow && action != Deny {
+			return fmt.Errorf("policy: default actions must be allow or deny, not %q", action)
+		}
+	}
+
+	for i, r := range p.Rules {
+		name := r.Name
+		if name == "" {
+			name = fmt.Sprintf("#%d", i+1)
+		}
+
+		switch r.Action {
+		case Allow, Deny:
+		case Limit:
+			if r.Rate <= 0 {
+				return fmt.Errorf("policy: rule %s needs a rate", name)
+			}
+
+			if r.Per != "" && r.Per != "asn" && r.Per != "client" && r.Per != "rule" {
+				return fmt.Errorf("policy: rule %s has unknown per %q", name, r.Per)
+			}
+		default:
+			return fmt.Errorf("policy: rule %s has unknown action %q", name, r.Action)
+		}
+	}
+
+	return nil
+}
+
+// Evaluate decides what to do with the client, counting it against the rate
+// limit of the rule that matches
+func (p *Policy) Evaluate(a Attributes) Decision {
+	return p.evaluate(a, time.Now())
+}
+
+func (p *Policy) evaluate(a Attributes, now time.Time) Decision {
+	switch {
+	case errors.Is(a.Err, ipasn.ErrFiltered):
+		return without(ReasonFiltered, p.OnFiltered)
+	case a.Err != nil:
+		return without(ReasonError, p.OnError)
+	}
+
+	for i := range p.Rules {
+		r := &p.Rules[i]
+		if !r.Matches(a) {
+			continue
+		}
+
+		d := decide(r.Name, r.Action)
+
+		if r.Action == Limit && !p.take(i, r, a, now) {
+			d.Allowed, d.Limited = false, true
+		}
+
+		return d
+	}
+
+	return decide("", p.Default)
+}
+
+func decide(rule string, action Action) Decision {
+	if action == "" {
+		action = Allow
+	}
+
+	return Decision{Allowed: action != Deny, Action: action, Rule: rule}
+}
+
+// without is the decision when there was nothing for the rules to go on
+func without(reason string, action Action) Decision {
+	d := decide("", action)
+	d.Reason = reason
+
+	return d
+}
+
+func matchInts(want []int, got int) bool {
+	if len(want) == 0 {
+		return true
+	}
+
+	for _, w := range want {
+		if w == got {
+			return true
+		}
+	}
+
+	return false
+}
+
+func matchStrings(want []string, got string, match func(got, want string) bool) bool {
+	if len(want) == 0 {
+		return true
+	}
+
+	for _, w := range want {
+		if match(got, w) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func containsFold(s, substr string) bool {
+	return strings.Contains(strings.ToLower(s), strings.ToLower(substr))
+}
+
+func hasPrefix(path, prefix string) bool {
+	return path != "" && strings.HasPrefix(path, prefix)
+}
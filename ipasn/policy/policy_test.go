@@ -0,0 +1,100 @@
+// Copyright 2019 Freman/Fremnet (Shannon Wynter). All rights reserved.
+
+package policy_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/freman/cymru/ipasn"
+	"github.com/freman/cymru/ipasn/policy"
+)
+
+const testPolicy = `{
+  "rules": [
+    {"name": "hosting signups", "descriptions": ["Hosting"], "paths": ["/signup"], "action": "deny"},
+    {"name": "partner", "asns": [23028], "action": "allow"},
+    {"name": "embargo", "countries": ["kp", "IR"], "action": "deny"},
+    {"name": "lacnic", "registries": ["lacnic"], "action": "limit", "rate": 1, "per": "rule"}
+  ],
+  "default": "allow",
+  "on_error": "deny"
+}`
+
+func TestLoad(t *testing.T) {
+	t.Parallel()
+
+	p, err := policy.Load(strings.NewReader(testPolicy))
+	require.NoError(t, err)
+	require.Len(t, p.Rules, 4)
+
+	bad := []string{
+		`{"rules": [{"action": "maybe"}]}`,
+		`{"rules": [{"action": "limit"}]}`,
+		`{"rules": [{"action": "limit", "rate": 1, "per": "moon"}]}`,
+		`{"default": "limit"}`,
+		`{"on_filtered": "limit"}`,
+		`{"rulez": []}`,
+		`nonsense`,
+	}
+
+	for _, b := range bad {
+		_, err := policy.Load(strings.NewReader(b))
+		require.Error(t, err, b)
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	t.Parallel()
+
+	p, err := policy.Load(strings.NewReader(testPolicy))
+	require.NoError(t, err)
+
+	hosting := policy.Attributes{
+		Origin: ipasn.OriginInfo{ASN: 64500, Country: "US", Authority: "arin"},
+		ASN:    ipasn.ASNInfo{Description: "EXAMPLE - Example Hosting Ltd, US"},
+	}
+
+	tests := []struct {
+		name     string
+		attrs    policy.Attributes
+		expected string
+	}{
+		{"hosting elsewhere", hosting, "allowed by default"},
+		{"hosting signup", func() policy.Attributes { a := hosting; a.Path = "/signup/form"; return a }(), "denied by hosting signups"},
+		{"partner", policy.Attributes{Origin: ipasn.OriginInfo{ASN: 23028, Country: "IR"}}, "allowed by partner"},
+		{"embargo", policy.Attributes{Origin: ipasn.OriginInfo{ASN: 1, Country: "ir"}}, "denied by embargo"},
+		{"error", policy.Attributes{Err: errors.New("broken")}, "denied on error"},
+		{"filtered", policy.Attributes{Err: ipasn.ErrIPIsLoopback}, "allowed on filtered address"},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			require.Equal(t, test.expected, p.Evaluate(test.attrs).String())
+		})
+	}
+
+	lacnic := policy.Attributes{Origin: ipasn.OriginInfo{ASN: 2, Authority: "lacnic"}}
+	require.Equal(t, policy.Decision{Allowed: true, Action: policy.Limit, Rule: "lacnic"}, p.Evaluate(lacnic))
+	require.Equal(t, policy.Decision{Action: policy.Limit, Rule: "lacnic", Limited: true}, p.Evaluate(lacnic))
+	require.Equal(t, "rate limited by lacnic", p.Evaluate(lacnic).String())
+}
+
+func TestZeroPolicy(t *testing.T) {
+	t.Parallel()
+
+	var p policy.Policy
+
+	require.True(t, p.Evaluate(policy.Attributes{}).Allowed)
+	require.True(t, p.Evaluate(policy.Attributes{Err: errors.New("broken")}).Allowed)
+	require.True(t, p.Evaluate(policy.Attributes{Err: ipasn.ErrIPIsPrivate}).Allowed)
+
+	p.OnFiltered = policy.Deny
+	require.Equal(t, "denied on filtered address", p.Evaluate(policy.Attributes{Err: ipasn.ErrIPIsPrivate}).String())
+}
@@ -0,0 +1,132 @@
+// Copyright 2019 Freman/Fremnet (Shannon Wynter). All rights reserved.
+
+package ipasn
+
+import (
+	"fmt"
+	"net"
+	"strings"
+)
+
+// Provider describes a DNS based IP to ASN service, what names the Client
+// queries and how it parses the TXT records that come back. Cymru is the
+// default, RouteViews and Rspamd are also built in.
+//
+// The name methods return an empty string if the provider can't answer that
+// kind of lookup, which the Client reports as ErrUnsupported.
+//
+// The parse methods are only called with at least one record, they return
+// ErrMalformed along with whatever they could make sense of if the records
+// can't be parsed, the Client decides whether to use the partial result. Any
+// other error, eg: ErrNotFound, is returned as is.
+type Provider interface {
+	// Name identifies the provider, eg: cymru
+	Name() string
+
+	OriginName(ip net.IP) string
+	PeerName(ip net.IP) string
+	ASNName(asn int) string
+
+	ParseOrigin(ip net.IP, txts []string) (OriginInfo, error)
+	ParsePeer(ip net.IP, txts []string) (PeerInfo, error)
+	ParseASN(asn int, txts []string) (ASNInfo, error)
+}
+
+// ParseProviders returns the built in providers named in list, separated by
+// commas, eg: cymru,routeviews,rspamd for a Client's Provider and Fallbacks.
+// Cymru queries zones.
+func ParseProviders(list string, zones Zones) ([]Provider, error) {
+	var providers []Provider
+
+	for _, name := range strings.Split(list, ",") {
+		switch strings.ToLower(strings.TrimSpace(name)) {
+		case "cymru":
+			providers = append(providers, Cymru{Zones: zones})
+		case "routeviews":
+			providers = append(providers, RouteViews{})
+		case "rspamd":
+			providers = append(providers, Rspamd{})
+		default:
+			return nil, fmt.Errorf("unknown provider %q", name)
+		}
+	}
+
+	return providers, nil
+}
+
+// Cymru is the Provider for the Team Cymru IP-ASN mapping service, or a
+// mirror of it if Zones is set
+type Cymru struct {
+	Zones Zones
+}
+
+// Name implements Provider
+func (Cymru) Name() string {
+	return "cymru"
+}
+
+// OriginName implements Provider
+func (p Cymru) OriginName(ip net.IP) string {
+	return p.Zones.OriginName(ip)
+}
+
+// PeerName implements Provider
+func (p Cymru) PeerName(ip net.IP) string {
+	return p.Zones.PeerName(ip)
+}
+
+// ASNName implements Provider
+func (p Cymru) ASNName(asn int) string {
+	return p.Zones.ASNName(asn)
+}
+
+// ParseOrigin implements Provider
+func (Cymru) ParseOrigin(_ net.IP, txts []string) (o OriginInfo, err error) {
+	dat := strings.Split(txts[0], " | ")
+	if len(dat) != 5 {
+		return o, ErrMalformed
+	}
+
+	var p recordParser
+	o.ASN = p.atoi(dat[0])
+	o.Network = p.cidr(dat[1])
+	o.Country = dat[2]
+	o.Authority = dat[3]
+	o.Updated = p.date(dat[4])
+
+	return o, p.malformed()
+}
+
+// ParsePeer implements Provider
+func (Cymru) ParsePeer(_ net.IP, txts []string) (pi PeerInfo, err error) {
+	dat := strings.Split(txts[0], " | ")
+	if len(dat) != 5 {
+		return pi, ErrMalformed
+	}
+
+	var p recordParser
+	pi.ASNs = p.asnList(dat[0])
+	pi.Network = p.cidr(dat[1])
+	pi.Country = dat[2]
+	pi.Authority = dat[3]
+	pi.Updated = p.date(dat[4])
+
+	return pi, p.malformed()
+}
+
+// ParseASN implements Provider
+func (Cymru) ParseASN(_ int, txts []string) (a ASNInfo, err error) {
+	dat := strings.Split(txts[0], " | ")
+	if len(dat) < 5 {
+		return a, ErrMalformed
+	}
+
+	var p recordParser
+	a.ASN = p.atoi(dat[0])
+	a.Country = dat[1]
+	a.Authority = dat[2]
+	a.Updated = p.date(dat[3])
+	a.Description = strings.Join(dat[4:], " | ")
+
+	return a, p.malformed()
+}
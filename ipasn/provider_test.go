@@ -0,0 +1,168 @@
+// Copyright 2019 Freman/Fremnet (Shannon Wynter). All rights reserved.
+
+package ipasn_test
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/freman/cymru/ipasn"
+)
+
+// answers is a resolver answering from a map, names without an entry fail
+func answers(m map[string][]string) mockResolver {
+	return func(ctx context.Context, name string) ([]string, error) {
+		if txts, ok := m[name]; ok {
+			return txts, nil
+		}
+
+		return nil, &net.DNSError{Err: "no such host", Name: name, IsNotFound: true}
+	}
+}
+
+func TestRouteViews(t *testing.T) {
+	t.Parallel()
+
+	c := ipasn.NewClient(
+		ipasn.WithProvider(ipasn.RouteViews{}),
+		ipasn.WithResolver(answers(map[string][]string{
+			// As joined by net.Resolver
+			"1.1.1.1.asn.routeviews.org.":    {"133351.1.1.024"},
+			"1.1.1.1.aspath.routeviews.org.": {"174 3356 13335 133351.1.1.024"},
+			// As quoted by other resolvers
+			"31.108.90.216.asn.routeviews.org.":    {`"23028" "216.90.108.0" "24"`},
+			"31.108.90.216.aspath.routeviews.org.": {`"3356 23028" "216.90.108.0" "24"`},
+			"1.0.0.203.asn.routeviews.org.":        {"42949672950.0.0.00"},
+			"2.0.0.203.asn.routeviews.org.":        {"nonsense"},
+		})),
+	)
+
+	v4 := net.ParseIP("1.1.1.1")
+
+	o, err := c.Origin(context.TODO(), v4)
+	require.NoError(t, err)
+	require.Equal(t, 13335, o.ASN)
+	require.Equal(t, "1.1.1.0/24", o.Network.String())
+
+	p, err := c.Peer(context.TODO(), v4)
+	require.NoError(t, err)
+	require.Equal(t, []int{3356}, p.ASNs)
+	require.Equal(t, "1.1.1.0/24", p.Network.String())
+
+	o, err = c.Origin(context.TODO(), net.ParseIP("216.90.108.31"))
+	require.NoError(t, err)
+	require.Equal(t, 23028, o.ASN)
+	require.Equal(t, "216.90.108.0/24", o.Network.String())
+
+	p, err = c.Peer(context.TODO(), net.ParseIP("216.90.108.31"))
+	require.NoError(t, err)
+	require.Equal(t, []int{3356}, p.ASNs)
+
+	_, err = c.Origin(context.TODO(), net.ParseIP("203.0.0.1"))
+	require.Equal(t, ipasn.ErrNotFound, err)
+
+	_, err = c.Origin(context.TODO(), net.ParseIP("203.0.0.2"), ipasn.StrictParsing())
+	require.Equal(t, ipasn.ErrMalformed, err)
+
+	_, err = c.Origin(context.TODO(), net.ParseIP("2001:4860::1"))
+	require.Equal(t, ipasn.ErrUnsupported, err)
+
+	_, err = c.ASN(context.TODO(), 13335)
+	require.Equal(t, ipasn.ErrUnsupported, err)
+}
+
+func TestRspamd(t *testing.T) {
+	t.Parallel()
+
+	c := ipasn.NewClient(
+		ipasn.WithProvider(ipasn.Rspamd{}),
+		ipasn.WithResolver(answers(map[string][]string{
+			"8.8.8.8.asn.rspamd.com.": {"15169|8.8.8.0/24|US|arin|"},
+			"1.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.6.8.4.1.0.0.2.asn6.rspamd.com.": {"15169|2001:4860::/32|US|arin|"},
+		})),
+	)
+
+	o, err := c.Origin(context.TODO(), net.ParseIP("8.8.8.8"))
+	require.NoError(t, err)
+	require.Equal(t, ipasn.OriginInfo{ASN: 15169, Network: o.Network, Country: "US", Authority: "arin"}, o)
+	require.Equal(t, "8.8.8.0/24", o.Network.String())
+
+	o, err = c.Origin(context.TODO(), net.ParseIP("2001:4860::1"))
+	require.NoError(t, err)
+	require.Equal(t, "2001:4860::/32", o.Network.String())
+
+	_, err = c.Peer(context.TODO(), net.ParseIP("8.8.8.8"))
+	require.Equal(t, ipasn.ErrUnsupported, err)
+
+	_, err = c.ASN(context.TODO(), 15169)
+	require.Equal(t, ipasn.ErrUnsupported, err)
+}
+
+func TestFallbacks(t *testing.T) {
+	t.Parallel()
+
+	broken := errors.New("broken")
+
+	c := ipasn.NewClient(
+		ipasn.WithFallbacks(ipasn.Rspamd{}, ipasn.RouteViews{}),
+		ipasn.WithResolver(mockResolver(func(ctx context.Context, name string) ([]string, error) {
+			switch name {
+			case "1.1.1.1.asn.routeviews.org.":
+				return []string{"133351.1.1.024"}, nil
+			case "AS13335.asn.cymru.com.":
+				return []string{"13335 | US | arin | 2010-07-14 | CLOUDFLARENET, US"}, nil
+			}
+
+			return nil, broken
+		})),
+	)
+
+	// Cymru and rspamd fail, RouteViews answers
+	o, err := c.Origin(context.TODO(), net.ParseIP("1.1.1.1"))
+	require.NoError(t, err)
+	require.Equal(t, 13335, o.ASN)
+
+	// Only Cymru has AS descriptions
+	a, err := c.ASN(context.TODO(), 13335)
+	require.NoError(t, err)
+	require.Equal(t, "CLOUDFLARENET, US", a.Description)
+
+	// The last error is returned when they all fail
+	_, err = c.Peer(context.TODO(), net.ParseIP("1.1.1.1"))
+	require.True(t, errors.Is(err, broken))
+
+	_, err = c.ASN(context.TODO(), 1)
+	require.Equal(t, ipasn.ErrUnsupported, err)
+
+	// UseProvider skips the fallbacks, eg: to cross check them
+	_, err = c.Origin(context.TODO(), net.ParseIP("1.1.1.1"), ipasn.UseProvider(ipasn.Cymru{}))
+	require.True(t, errors.Is(err, broken))
+
+	// Filtered addresses aren't looked up at all
+	_, err = c.Origin(context.TODO(), net.ParseIP("10.0.0.1"))
+	require.Equal(t, ipasn.ErrIPIsPrivate, err)
+
+	// Nor is anything after the caller gives up
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err = c.Origin(ctx, net.ParseIP("1.1.1.1"))
+	require.True(t, errors.Is(err, broken))
+}
+
+func TestParseProviders(t *testing.T) {
+	t.Parallel()
+
+	zones := ipasn.ZonesUnder("asn.example.internal")
+
+	providers, err := ipasn.ParseProviders("cymru, RouteViews,rspamd", zones)
+	require.NoError(t, err)
+	require.Equal(t, []ipasn.Provider{ipasn.Cymru{Zones: zones}, ipasn.RouteViews{}, ipasn.Rspamd{}}, providers)
+
+	_, err = ipasn.ParseProviders("cymru,bogus", zones)
+	require.EqualError(t, err, `unknown provider "bogus"`)
+}
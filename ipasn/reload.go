@@ -0,0 +1,233 @@
+// Copyright 2019 Freman/Fremnet (Shannon Wynter). All rights reserved.
+
+package ipasn
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// NetworkSource is something that can produce a list of networks, such as a
+// file on disk or an HTTP endpoint.
+//
+// Load should return changed as false (and a nil Networks) if the source knows
+// that nothing has changed since the last successful load.
+type NetworkSource interface {
+	Load(ctx context.Context) (nets Networks, changed bool, err error)
+}
+
+// ReloadEvent is passed to ReloadingNetworks.OnReload after every reload attempt.
+type ReloadEvent struct {
+	Time     time.Time
+	Changed  bool
+	Networks int
+	Err      error
+}
+
+// ReloadingNetworks is a NetworkFilter backed by a NetworkSource that can be
+// reloaded at any time without interrupting concurrent calls to Contains.
+//
+// Until the first successful reload it contains nothing.
+type ReloadingNetworks struct {
+	Source NetworkSource
+
+	// Interval is how often Watch polls the source, it defaults to a minute.
+	Interval time.Duration
+
+	// OnReload, if set, is called after every reload attempt, successful or not.
+	OnReload func(ReloadEvent)
+
+	mu      sync.Mutex
+	current atomic.Value
+}
+
+// Contains reports whether the most recently loaded networks include ip.
+func (r *ReloadingNetworks) Contains(ip net.IP) bool {
+	nets, _ := r.current.Load().(Networks)
+	return nets.Contains(ip)
+}
+
+// Reload checks the source and, if it has changed, atomically swaps in the new
+// list of networks. On error the previous list is retained.
+func (r *ReloadingNetworks) Reload(ctx context.Context) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	nets, changed, err := r.Source.Load(ctx)
+	if err == nil && changed {
+		r.current.Store(nets)
+	}
+
+	if r.OnReload != nil {
+		current, _ := r.current.Load().(Networks)
+		r.OnReload(ReloadEvent{
+			Time:     time.Now(),
+			Changed:  err == nil && changed,
+			Networks: len(current),
+			Err:      err,
+		})
+	}
+
+	return err
+}
+
+// Watch reloads the source every Interval until the context is cancelled.
+// Errors are reported via OnReload.
+func (r *ReloadingNetworks) Watch(ctx context.Context) {
+	interval := r.Interval
+	if interval <= 0 {
+		interval = time.Minute
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			_ = r.Reload(ctx)
+		}
+	}
+}
+
+// FileNetworkSource loads networks from a file, only re-reading it when the
+// modification time or size changes.
+type FileNetworkSource struct {
+	Path string
+
+	modTime time.Time
+	size    int64
+}
+
+// Load implements NetworkSource
+func (f *FileNetworkSource) Load(_ context.Context) (Networks, bool, error) {
+	fh, err := os.Open(f.Path)
+	if err != nil {
+		return nil, false, err
+	}
+	defer fh.Close()
+
+	st, err := fh.Stat()
+	if err != nil {
+		return nil, false, err
+	}
+
+	if st.ModTime().Equal(f.modTime) && st.Size() == f.size {
+		return nil, false, nil
+	}
+
+	nets, err := ParseNetworks(fh)
+	if err != nil {
+		return nil, false, fmt.Errorf("%s: %w", f.Path, err)
+	}
+
+	f.modTime, f.size = st.ModTime(), st.Size()
+
+	return nets, true, nil
+}
+
+// URLNetworkSource loads networks from an HTTP endpoint, using the ETag
+// returned by the server to avoid re-reading an unchanged list.
+type URLNetworkSource struct {
+	URL string
+
+	// Client is used to make the requests, it defaults to http.DefaultClient.
+	Client *http.Client
+
+	etag string
+}
+
+// Load implements NetworkSource
+func (u *URLNetworkSource) Load(ctx context.Context) (Networks, bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.URL, nil)
+	if err != nil {
+		return nil, false, err
+	}
+
+	if u.etag != "" {
+		req.Header.Set("If-None-Match", u.etag)
+	}
+
+	client := u.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, false, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusNotModified:
+		return nil, false, nil
+	case http.StatusOK:
+	default:
+		return nil, false, fmt.Errorf("%s: unexpected status %s", u.URL, resp.Status)
+	}
+
+	nets, err := ParseNetworks(resp.Body)
+	if err != nil {
+		return nil, false, fmt.Errorf("%s: %w", u.URL, err)
+	}
+
+	u.etag = resp.Header.Get("ETag")
+
+	return nets, true, nil
+}
+
+// ParseNetworks reads one network per line in CIDR notation, or as a bare IP
+// address. Blank lines and anything following a # are ignored.
+func ParseNetworks(r io.Reader) (Networks, error) {
+	var nets Networks
+
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := scanner.Text()
+		if i := strings.IndexByte(text, '#'); i >= 0 {
+			text = text[:i]
+		}
+
+		text = strings.TrimSpace(text)
+		if text == "" {
+			continue
+		}
+
+		if !strings.Contains(text, "/") {
+			ip := net.ParseIP(text)
+			if ip == nil {
+				return nil, fmt.Errorf("line %d: invalid address %q", line, text)
+			}
+
+			bits := 8 * net.IPv6len
+			if ip4 := ip.To4(); ip4 != nil {
+				ip, bits = ip4, 8*net.IPv4len
+			}
+
+			nets = append(nets, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+
+			continue
+		}
+
+		_, n, err := net.ParseCIDR(text)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+
+		nets = append(nets, n)
+	}
+
+	return nets, scanner.Err()
+}
@@ -0,0 +1,148 @@
+// Copyright 2019 Freman/Fremnet (Shannon Wynter). All rights reserved.
+
+package ipasn_test
+
+import (
+	"context"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/freman/cymru/ipasn"
+)
+
+func TestParseNetworks(t *testing.T) {
+	t.Parallel()
+
+	nets, err := ipasn.ParseNetworks(strings.NewReader("# blocked\n10.0.0.0/8\n\n 192.0.2.1 # one host\n2001:db8::/32\n"))
+	require.NoError(t, err)
+	require.Len(t, nets, 3)
+	require.True(t, nets.Contains(net.IPv4(10, 1, 2, 3)))
+	require.True(t, nets.Contains(net.IPv4(192, 0, 2, 1)))
+	require.False(t, nets.Contains(net.IPv4(192, 0, 2, 2)))
+	require.True(t, nets.Contains(net.ParseIP("2001:db8::1")))
+
+	_, err = ipasn.ParseNetworks(strings.NewReader("10.0.0.0/8\nbogus\n"))
+	require.EqualError(t, err, `line 2: invalid address "bogus"`)
+}
+
+func TestReloadingNetworksFile(t *testing.T) {
+	t.Parallel()
+
+	dir, err := ioutil.TempDir("", "ipasn")
+	require.NoError(t, err)
+
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "blocked.txt")
+	require.NoError(t, ioutil.WriteFile(path, []byte("10.0.0.0/8\n"), 0600))
+
+	var events []ipasn.ReloadEvent
+
+	r := &ipasn.ReloadingNetworks{
+		Source:   &ipasn.FileNetworkSource{Path: path},
+		OnReload: func(e ipasn.ReloadEvent) { events = append(events, e) },
+	}
+
+	require.False(t, r.Contains(net.IPv4(10, 0, 0, 1)))
+	require.NoError(t, r.Reload(context.TODO()))
+	require.True(t, r.Contains(net.IPv4(10, 0, 0, 1)))
+
+	// Unchanged file, nothing happens
+	require.NoError(t, r.Reload(context.TODO()))
+
+	require.NoError(t, ioutil.WriteFile(path, []byte("172.16.0.0/12\n192.168.0.0/16\n"), 0600))
+	require.NoError(t, os.Chtimes(path, time.Now(), time.Now().Add(time.Second)))
+	require.NoError(t, r.Reload(context.TODO()))
+	require.False(t, r.Contains(net.IPv4(10, 0, 0, 1)))
+	require.True(t, r.Contains(net.IPv4(192, 168, 1, 1)))
+
+	// Broken file, previous networks are retained
+	require.NoError(t, ioutil.WriteFile(path, []byte("nope\n"), 0600))
+	require.NoError(t, os.Chtimes(path, time.Now(), time.Now().Add(2*time.Second)))
+	require.Error(t, r.Reload(context.TODO()))
+	require.True(t, r.Contains(net.IPv4(192, 168, 1, 1)))
+
+	require.Len(t, events, 4)
+	require.True(t, events[0].Changed)
+	require.Equal(t, 1, events[0].Networks)
+	require.False(t, events[1].Changed)
+	require.True(t, events[2].Changed)
+	require.Equal(t, 2, events[2].Networks)
+	require.False(t, events[3].Changed)
+	require.Error(t, events[3].Err)
+}
+
+func TestReloadingNetworksURL(t *testing.T) {
+	t.Parallel()
+
+	var (
+		requests int32
+		body     atomic.Value
+	)
+
+	body.Store("10.0.0.0/8\n")
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+
+		b := body.Load().(string)
+		if b == "" {
+			http.NotFound(w, r)
+			return
+		}
+
+		etag := `"` + strings.TrimSpace(b) + `"`
+
+		if r.Header.Get("If-None-Match") == etag {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+
+		w.Header().Set("ETag", etag)
+		_, _ = w.Write([]byte(b))
+	}))
+	defer ts.Close()
+
+	r := &ipasn.ReloadingNetworks{
+		Source:   &ipasn.URLNetworkSource{URL: ts.URL},
+		Interval: time.Millisecond,
+	}
+
+	require.NoError(t, r.Reload(context.TODO()))
+	require.True(t, r.Contains(net.IPv4(10, 0, 0, 1)))
+
+	body.Store("192.168.0.0/16\n")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+
+	go func() {
+		r.Watch(ctx)
+		close(done)
+	}()
+
+	for deadline := time.Now().Add(time.Second); !r.Contains(net.IPv4(192, 168, 0, 1)); {
+		require.True(t, time.Now().Before(deadline), "networks were not reloaded")
+		time.Sleep(time.Millisecond)
+	}
+
+	cancel()
+	<-done
+
+	require.False(t, r.Contains(net.IPv4(10, 0, 0, 1)))
+	require.True(t, atomic.LoadInt32(&requests) >= 2)
+
+	body.Store("")
+	require.Error(t, r.Reload(context.TODO()))
+	require.True(t, r.Contains(net.IPv4(192, 168, 0, 1)))
+}
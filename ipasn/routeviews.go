@@ -0,0 +1,170 @@
+// Copyright 2019 Freman/Fremnet (Shannon Wynter). All rights reserved.
+
+package ipasn
+
+import (
+	"net"
+	"strconv"
+	"strings"
+)
+
+// RouteViewsZone and RouteViewsPathZone are the RouteViews origin and AS path
+// zones
+const (
+	RouteViewsZone     = "asn.routeviews.org."
+	RouteViewsPathZone = "aspath.routeviews.org."
+)
+
+// routeViewsUnknown is the ASN RouteViews answers with for unrouted addresses
+const routeViewsUnknown = "4294967295"
+
+// RouteViews is the Provider for the University of Oregon RouteViews zones,
+// which only cover IPv4. Origin lookups query Zone and Peer lookups query
+// PathZone, taking the hop before the origin in the AS path as the peer. It
+// has no AS descriptions, nor countries, registries or dates.
+//
+// RouteViews answers with three strings in one record, eg: "13335" "1.1.1.0"
+// "24", which net.Resolver joins without a separator, eg: 133351.1.1.024. The
+// record is split again by finding the network that contains the address.
+type RouteViews struct {
+	// Zone and PathZone default to RouteViewsZone and RouteViewsPathZone
+	Zone     string
+	PathZone string
+}
+
+// Name implements Provider
+func (RouteViews) Name() string {
+	return "routeviews"
+}
+
+// OriginName implements Provider
+func (p RouteViews) OriginName(ip net.IP) string {
+	return routeViewsName(ip, p.Zone, RouteViewsZone)
+}
+
+// PeerName implements Provider
+func (p RouteViews) PeerName(ip net.IP) string {
+	return routeViewsName(ip, p.PathZone, RouteViewsPathZone)
+}
+
+// ASNName implements Provider, RouteViews doesn't have AS descriptions
+func (RouteViews) ASNName(int) string {
+	return ""
+}
+
+// ParseOrigin implements Provider
+func (RouteViews) ParseOrigin(ip net.IP, txts []string) (o OriginInfo, err error) {
+	path, network, err := splitRouteViews(ip, txts[0])
+	if err != nil {
+		return o, err
+	}
+
+	o.Network = network
+	o.ASN, err = strconv.Atoi(path[len(path)-1])
+	if err != nil {
+		return o, ErrMalformed
+	}
+
+	return o, nil
+}
+
+// ParsePeer implements Provider
+func (RouteViews) ParsePeer(ip net.IP, txts []string) (pi PeerInfo, err error) {
+	path, network, err := splitRouteViews(ip, txts[0])
+	if err != nil {
+		return pi, err
+	}
+
+	pi.Network = network
+	pi.ASNs = []int{}
+
+	var p recordParser
+
+	origin := p.atoi(path[len(path)-1])
+
+	// Skip the origin prepending itself
+	for i := len(path) - 2; i >= 0; i-- {
+		if asn := p.atoi(path[i]); asn != origin {
+			pi.ASNs = append(pi.ASNs, asn)
+			break
+		}
+	}
+
+	return pi, p.malformed()
+}
+
+// ParseASN implements Provider
+func (RouteViews) ParseASN(int, []string) (ASNInfo, error) {
+	return ASNInfo{}, ErrUnsupported
+}
+
+func routeViewsName(ip net.IP, zone, fallback string) string {
+	if ip.To4() == nil {
+		return ""
+	}
+
+	if zone == "" {
+		zone = fallback
+	}
+
+	return ReverseName(ip) + "." + fqdn(zone)
+}
+
+// splitRouteViews takes apart a RouteViews record into the AS path, which is
+// just the origin for the origin zone, and the network. It accepts the
+// strings quoted, separated by spaces, or joined together.
+func splitRouteViews(ip net.IP, txt string) ([]string, *net.IPNet, error) {
+	fields := strings.Fields(strings.Replace(txt, `"`, " ", -1))
+	if len(fields) == 0 {
+		return nil, nil, ErrMalformed
+	}
+
+	if len(fields) >= 3 {
+		if network := routeViewsNetwork(ip, fields[len(fields)-2], fields[len(fields)-1]); network != nil {
+			path, err := routeViewsPath(fields[:len(fields)-2])
+			return path, network, err
+		}
+	}
+
+	// The last field is the origin, network and length joined together
+	last := fields[len(fields)-1]
+
+	for i := 1; i < len(last); i++ {
+		for j := i + 1; j < len(last); j++ {
+			if network := routeViewsNetwork(ip, last[i:j], last[j:]); network != nil {
+				path, err := routeViewsPath(append(fields[:len(fields)-1:len(fields)-1], last[:i]))
+				return path, network, err
+			}
+		}
+	}
+
+	return nil, nil, ErrMalformed
+}
+
+// routeViewsPath checks path isn't RouteViews' way of saying there's no route
+func routeViewsPath(path []string) ([]string, error) {
+	if len(path) == 0 {
+		return nil, ErrMalformed
+	}
+
+	if path[len(path)-1] == routeViewsUnknown {
+		return nil, ErrNotFound
+	}
+
+	return path, nil
+}
+
+// routeViewsNetwork returns the network made of addr and length if it's an
+// IPv4 network in canonical form containing ip, otherwise nil
+func routeViewsNetwork(ip net.IP, addr, length string) *net.IPNet {
+	if length == "" || (len(length) > 1 && length[0] == '0') {
+		return nil
+	}
+
+	_, network, err := net.ParseCIDR(addr + "/" + length)
+	if err != nil || network.IP.To4() == nil || !network.IP.Equal(net.ParseIP(addr)) || !network.Contains(ip) {
+		return nil
+	}
+
+	return network
+}
@@ -0,0 +1,90 @@
+// Copyright 2019 Freman/Fremnet (Shannon Wynter). All rights reserved.
+
+package ipasn
+
+import (
+	"net"
+	"strings"
+)
+
+// RspamdZone and RspamdZone6 are the rspamd IPv4 and IPv6 zones
+const (
+	RspamdZone  = "asn.rspamd.com."
+	RspamdZone6 = "asn6.rspamd.com."
+)
+
+// Rspamd is the Provider for the zones used by rspamd's asn module, which
+// answer origin lookups only, eg: 15169|8.8.8.0/24|US|arin|
+type Rspamd struct {
+	// Zone and Zone6 are queried for IPv4 and IPv6 addresses respectively,
+	// they default to RspamdZone and RspamdZone6
+	Zone  string
+	Zone6 string
+}
+
+// Name implements Provider
+func (Rspamd) Name() string {
+	return "rspamd"
+}
+
+// OriginName implements Provider
+func (p Rspamd) OriginName(ip net.IP) string {
+	zone, fallback := p.Zone, RspamdZone
+	if ip.To4() == nil {
+		zone, fallback = p.Zone6, RspamdZone6
+	}
+
+	if zone == "" {
+		zone = fallback
+	}
+
+	name := ReverseName(ip)
+	if name == "" {
+		return ""
+	}
+
+	return name + "." + fqdn(zone)
+}
+
+// PeerName implements Provider, rspamd doesn't have peers
+func (Rspamd) PeerName(net.IP) string {
+	return ""
+}
+
+// ASNName implements Provider, rspamd doesn't have AS descriptions
+func (Rspamd) ASNName(int) string {
+	return ""
+}
+
+// ParseOrigin implements Provider
+func (Rspamd) ParseOrigin(_ net.IP, txts []string) (o OriginInfo, err error) {
+	dat := strings.Split(txts[0], "|")
+	if len(dat) < 3 {
+		return o, ErrMalformed
+	}
+
+	for i := range dat {
+		dat[i] = strings.TrimSpace(dat[i])
+	}
+
+	var p recordParser
+	o.ASN = p.atoi(dat[0])
+	o.Network = p.cidr(dat[1])
+	o.Country = dat[2]
+
+	if len(dat) > 3 {
+		o.Authority = dat[3]
+	}
+
+	return o, p.malformed()
+}
+
+// ParsePeer implements Provider
+func (Rspamd) ParsePeer(net.IP, []string) (PeerInfo, error) {
+	return PeerInfo{}, ErrUnsupported
+}
+
+// ParseASN implements Provider
+func (Rspamd) ParseASN(int, []string) (ASNInfo, error) {
+	return ASNInfo{}, ErrUnsupported
+}
@@ -0,0 +1,5 @@
+// Copyright 2019 Freman/Fremnet (Shannon Wynter). All rights reserved.
+
+// Package whoisserver implements a stand in for the whois.cymru.com whois service, including bulk mode,
+// backed by a local dataset so that whois based tooling can be tested without network access.
+package whoisserver
@@ -0,0 +1,173 @@
+// Copyright 2019 Freman/Fremnet (Shannon Wynter). All rights reserved.
+
+package whoisserver
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/freman/cymru/ipasn/localdb"
+)
+
+const dateFormat = `2006-01-02`
+
+// options are the columns selected by flags or bulk mode commands
+type options struct {
+	header    bool
+	prefix    bool
+	country   bool
+	registry  bool
+	allocated bool
+	asName    bool
+}
+
+func defaultOptions() options {
+	return options{header: true, asName: true}
+}
+
+// apply handles a flag such as -v, or the equivalent bulk mode command such
+// as verbose, reporting whether it was recognised
+func (o *options) apply(flag string) bool {
+	switch strings.ToLower(flag) {
+	case "-v", "verbose":
+		o.prefix, o.country, o.registry, o.allocated, o.asName = true, true, true, true, true
+	case "-p", "prefix":
+		o.prefix = true
+	case "noprefix":
+		o.prefix = false
+	case "-c", "countrycode":
+		o.country = true
+	case "nocountrycode":
+		o.country = false
+	case "-r", "registry":
+		o.registry = true
+	case "noregistry":
+		o.registry = false
+	case "-u", "allocdate":
+		o.allocated = true
+	case "noallocdate":
+		o.allocated = false
+	case "-a", "asname":
+		o.asName = true
+	case "-n", "noasname":
+		o.asName = false
+	case "header":
+		o.header = true
+	case "-f", "noheader":
+		o.header = false
+	default:
+		return false
+	}
+
+	return true
+}
+
+// applyFlags handles flags that may be combined, eg: -pc
+func (o *options) applyFlags(flags string) bool {
+	for _, f := range flags[1:] {
+		if !o.apply("-" + string(f)) {
+			return false
+		}
+	}
+
+	return len(flags) > 1
+}
+
+// row is a single line of output, unset columns are left out
+type row struct {
+	as        string
+	ip        string
+	prefix    string
+	country   string
+	registry  string
+	allocated string
+	asName    string
+}
+
+func (o options) ipHeader() string {
+	return o.format(true, row{"AS", "IP", "BGP Prefix", "CC", "Registry", "Allocated", "AS Name"})
+}
+
+func (o options) asnHeader() string {
+	return o.format(false, row{"AS", "", "", "CC", "Registry", "Allocated", "AS Name"})
+}
+
+// format lays out the row in the same fixed width columns as whois.cymru.com
+func (o options) format(isIP bool, r row) string {
+	cols := []string{fmt.Sprintf("%-7s", r.as)}
+
+	if isIP {
+		cols = append(cols, fmt.Sprintf("%-16s", r.ip))
+
+		if o.prefix {
+			cols = append(cols, fmt.Sprintf("%-19s", r.prefix))
+		}
+	}
+
+	if o.country {
+		cols = append(cols, fmt.Sprintf("%-2s", r.country))
+	}
+
+	if o.registry {
+		cols = append(cols, fmt.Sprintf("%-8s", r.registry))
+	}
+
+	if o.allocated {
+		cols = append(cols, fmt.Sprintf("%-10s", r.allocated))
+	}
+
+	if o.asName {
+		cols = append(cols, r.asName)
+	}
+
+	return strings.TrimRight(strings.Join(cols, " | "), " ") + "\n"
+}
+
+// answerIP formats the record for ip, or a row of NA if there isn't one
+func (o options) answerIP(backend localdb.Backend, ip net.IP) string {
+	rec, found := backend.Lookup(ip)
+	if !found {
+		return o.format(true, row{"NA", ip.String(), "NA", "", "", "", "NA"})
+	}
+
+	info, _ := backend.LookupASN(rec.ASN)
+
+	return o.format(true, row{
+		strconv.Itoa(rec.ASN),
+		ip.String(),
+		rec.Network.String(),
+		rec.Country,
+		rec.Authority,
+		formatDate(rec.Updated),
+		info.Description,
+	})
+}
+
+// answerASN formats the description of asn, or a row of NA if there isn't one
+func (o options) answerASN(backend localdb.Backend, asn int) string {
+	info, found := backend.LookupASN(asn)
+	if !found {
+		return o.format(false, row{strconv.Itoa(asn), "", "", "", "", "", "NA"})
+	}
+
+	return o.format(false, row{
+		strconv.Itoa(asn),
+		"",
+		"",
+		info.Country,
+		info.Authority,
+		formatDate(info.Updated),
+		info.Description,
+	})
+}
+
+func formatDate(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+
+	return t.Format(dateFormat)
+}
@@ -0,0 +1,234 @@
+// Copyright 2019 Freman/Fremnet (Shannon Wynter). All rights reserved.
+
+package whoisserver
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/freman/cymru/ipasn/localdb"
+)
+
+// Server is a whois server that speaks the same query syntax, and replies in
+// the same format, as whois.cymru.com, using a localdb.Backend as its dataset.
+//
+// Like httptest.Server it's intended for tests, see NewServer.
+type Server struct {
+	// Addr is the address the server is listening on, in the form host:port
+	Addr string
+
+	// Listener is the listener used by Start, it can be replaced between
+	// NewUnstartedServer and Start
+	Listener net.Listener
+
+	// Backend answers the queries
+	Backend localdb.Backend
+
+	// Now provides the time shown in the bulk mode banner, it defaults to
+	// time.Now
+	Now func() time.Time
+
+	mu     sync.Mutex
+	conns  map[net.Conn]struct{}
+	wg     sync.WaitGroup
+	closed bool
+}
+
+// NewServer starts and returns a new Server on a random loopback port, the
+// caller should call Close when finished.
+func NewServer(backend localdb.Backend) *Server {
+	s := NewUnstartedServer(backend)
+	s.Start()
+
+	return s
+}
+
+// NewUnstartedServer returns a new Server that doesn't start until Start is
+// called, so that it can be configured first.
+func NewUnstartedServer(backend localdb.Backend) *Server {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		if l, err = net.Listen("tcp6", "[::1]:0"); err != nil {
+			panic(fmt.Sprintf("whoisserver: failed to listen on a port: %v", err))
+		}
+	}
+
+	return &Server{
+		Listener: l,
+		Backend:  backend,
+	}
+}
+
+// Start starts serving on the Listener
+func (s *Server) Start() {
+	s.Addr = s.Listener.Addr().String()
+	s.conns = make(map[net.Conn]struct{})
+
+	s.wg.Add(1)
+
+	go func() {
+		defer s.wg.Done()
+		_ = s.Serve(s.Listener)
+	}()
+}
+
+// Serve accepts connections on l until it's closed, it can be used to run a
+// Server without NewServer.
+func (s *Server) Serve(l net.Listener) error {
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			if ne, ok := err.(net.Error); ok && ne.Temporary() {
+				continue
+			}
+
+			return err
+		}
+
+		s.mu.Lock()
+		if s.closed {
+			s.mu.Unlock()
+			conn.Close()
+
+			return nil
+		}
+
+		if s.conns == nil {
+			s.conns = make(map[net.Conn]struct{})
+		}
+
+		s.conns[conn] = struct{}{}
+		s.mu.Unlock()
+
+		s.wg.Add(1)
+
+		go s.serveConn(conn)
+	}
+}
+
+// Close shuts down the server and blocks until all connections are finished
+func (s *Server) Close() {
+	s.mu.Lock()
+
+	if s.closed {
+		s.mu.Unlock()
+		return
+	}
+
+	s.closed = true
+
+	if s.Listener != nil {
+		s.Listener.Close()
+	}
+
+	for conn := range s.conns {
+		conn.Close()
+	}
+
+	s.mu.Unlock()
+
+	s.wg.Wait()
+}
+
+func (s *Server) serveConn(conn net.Conn) {
+	defer func() {
+		s.mu.Lock()
+		delete(s.conns, conn)
+		s.mu.Unlock()
+
+		conn.Close()
+		s.wg.Done()
+	}()
+
+	_ = conn.SetDeadline(time.Now().Add(time.Minute))
+
+	scanner := bufio.NewScanner(conn)
+	w := bufio.NewWriter(conn)
+
+	defer w.Flush()
+
+	if !scanner.Scan() {
+		return
+	}
+
+	line := strings.TrimSpace(scanner.Text())
+	if !strings.EqualFold(line, "begin") {
+		opts := defaultOptions()
+		out, header := s.query(&opts, line, 1)
+
+		if header != "" && opts.header {
+			w.WriteString(header)
+		}
+
+		w.WriteString(out)
+
+		return
+	}
+
+	now := time.Now
+	if s.Now != nil {
+		now = s.Now
+	}
+
+	fmt.Fprintf(w, "Bulk mode; whois.cymru.com [%s]\n", now().UTC().Format("2006-01-02 15:04:05 -0700"))
+
+	opts := defaultOptions()
+	headerDone := false
+
+	for lineNo := 2; scanner.Scan(); lineNo++ {
+		line := strings.TrimSpace(scanner.Text())
+
+		switch {
+		case line == "" || strings.HasPrefix(line, "#"):
+			continue
+		case strings.EqualFold(line, "end"):
+			return
+		case opts.apply(line):
+			continue
+		}
+
+		out, header := s.query(&opts, line, lineNo)
+
+		if header != "" && opts.header && !headerDone {
+			w.WriteString(header)
+
+			headerDone = true
+		}
+
+		w.WriteString(out)
+	}
+}
+
+// query answers a single line of the form [flags] IP|ASN [anything else],
+// returning the answer and the header that belongs above it. Flags alter
+// opts for the rest of the session.
+func (s *Server) query(opts *options, line string, lineNo int) (out string, header string) {
+	for _, token := range strings.Fields(line) {
+		if strings.HasPrefix(token, "-") {
+			if !opts.applyFlags(token) {
+				return fmt.Sprintf("Error: unknown flag %s on line %d.\n", token, lineNo), ""
+			}
+
+			continue
+		}
+
+		if ip := net.ParseIP(token); ip != nil {
+			return opts.answerIP(s.Backend, ip), opts.ipHeader()
+		}
+
+		if len(token) > 2 && strings.EqualFold(token[:2], "AS") {
+			if asn, err := strconv.Atoi(token[2:]); err == nil {
+				return opts.answerASN(s.Backend, asn), opts.asnHeader()
+			}
+		}
+
+		break
+	}
+
+	return fmt.Sprintf("Error: no ASN or IP match on line %d.\n", lineNo), ""
+}
@@ -0,0 +1,161 @@
+// Copyright 2019 Freman/Fremnet (Shannon Wynter). All rights reserved.
+
+package whoisserver_test
+
+import (
+	"io/ioutil"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/freman/cymru/ipasn"
+	"github.com/freman/cymru/ipasn/localdb"
+	"github.com/freman/cymru/ipasn/whoisserver"
+)
+
+func testServer() *whoisserver.Server {
+	db := localdb.New()
+
+	_, network, _ := net.ParseCIDR("216.90.108.0/24")
+	db.Insert(localdb.Record{Network: network, ASN: 23028, Country: "US", Authority: "arin", Updated: time.Date(1998, 9, 25, 0, 0, 0, 0, time.UTC)})
+	db.InsertASN(ipasn.ASNInfo{ASN: 23028, Country: "US", Authority: "arin", Updated: time.Date(2002, 1, 4, 0, 0, 0, 0, time.UTC), Description: "TEAM-CYMRU - Team Cymru Inc., US"})
+
+	s := whoisserver.NewUnstartedServer(db)
+	s.Now = func() time.Time { return time.Date(2019, 12, 1, 12, 30, 0, 0, time.UTC) }
+	s.Start()
+
+	return s
+}
+
+func whois(t *testing.T, addr, query string) string {
+	conn, err := net.Dial("tcp", addr)
+	require.NoError(t, err)
+
+	defer conn.Close()
+
+	_, err = conn.Write([]byte(query))
+	require.NoError(t, err)
+
+	// Closing our side is how the server knows there's no more
+	require.NoError(t, conn.(*net.TCPConn).CloseWrite())
+
+	b, err := ioutil.ReadAll(conn)
+	require.NoError(t, err)
+
+	return string(b)
+}
+
+func TestServer(t *testing.T) {
+	t.Parallel()
+
+	s := testServer()
+	defer s.Close()
+
+	tests := []struct {
+		name     string
+		query    string
+		expected string
+	}{
+		{
+			name:  "single",
+			query: " 216.90.108.31\n",
+			expected: "AS      | IP               | AS Name\n" +
+				"23028   | 216.90.108.31    | TEAM-CYMRU - Team Cymru Inc., US\n",
+		},
+		{
+			name:  "single verbose",
+			query: " -v 216.90.108.31 2019-12-01\n",
+			expected: "AS      | IP               | BGP Prefix          | CC | Registry | Allocated  | AS Name\n" +
+				"23028   | 216.90.108.31    | 216.90.108.0/24     | US | arin     | 1998-09-25 | TEAM-CYMRU - Team Cymru Inc., US\n",
+		},
+		{
+			name:  "single asn",
+			query: "-v as23028\n",
+			expected: "AS      | CC | Registry | Allocated  | AS Name\n" +
+				"23028   | US | arin     | 2002-01-04 | TEAM-CYMRU - Team Cymru Inc., US\n",
+		},
+		{
+			name:     "single no header",
+			query:    "-fpn 10.0.0.1\n",
+			expected: "NA      | 10.0.0.1         | NA\n",
+		},
+		{
+			name:     "single invalid",
+			query:    "example.com\n",
+			expected: "Error: no ASN or IP match on line 1.\n",
+		},
+		{
+			name:     "single unknown flag",
+			query:    "-x 10.0.0.1\n",
+			expected: "Error: unknown flag -x on line 1.\n",
+		},
+		{
+			name:  "bulk",
+			query: "begin\nverbose\n216.90.108.31\n\nAS23028\nnonsense\nend\n",
+			expected: "Bulk mode; whois.cymru.com [2019-12-01 12:30:00 +0000]\n" +
+				"AS      | IP               | BGP Prefix          | CC | Registry | Allocated  | AS Name\n" +
+				"23028   | 216.90.108.31    | 216.90.108.0/24     | US | arin     | 1998-09-25 | TEAM-CYMRU - Team Cymru Inc., US\n" +
+				"23028   | US | arin     | 2002-01-04 | TEAM-CYMRU - Team Cymru Inc., US\n" +
+				"Error: no ASN or IP match on line 6.\n",
+		},
+		{
+			name:  "bulk commands",
+			query: "BEGIN\nnoheader\ncountrycode\nnoasname\n216.90.108.31\nprefix\nnocountrycode\n216.90.108.32\nend\n",
+			expected: "Bulk mode; whois.cymru.com [2019-12-01 12:30:00 +0000]\n" +
+				"23028   | 216.90.108.31    | US\n" +
+				"23028   | 216.90.108.32    | 216.90.108.0/24\n",
+		},
+		{
+			name:     "bulk without end",
+			query:    "begin\n",
+			expected: "Bulk mode; whois.cymru.com [2019-12-01 12:30:00 +0000]\n",
+		},
+	}
+
+	// Grouped so the server isn't closed until the parallel subtests finish
+	t.Run("group", func(t *testing.T) {
+		for _, test := range tests {
+			test := test
+			t.Run(test.name, func(t *testing.T) {
+				t.Parallel()
+
+				require.Equal(t, test.expected, whois(t, s.Addr, test.query))
+			})
+		}
+	})
+}
+
+func TestServerClose(t *testing.T) {
+	t.Parallel()
+
+	s := testServer()
+
+	conn, err := net.Dial("tcp", s.Addr)
+	require.NoError(t, err)
+
+	defer conn.Close()
+
+	_, err = conn.Write([]byte("begin\n"))
+	require.NoError(t, err)
+
+	// An idle bulk session mustn't hold up Close
+	done := make(chan struct{})
+
+	go func() {
+		s.Close()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Close didn't return")
+	}
+
+	s.Close()
+
+	_, err = net.Dial("tcp", s.Addr)
+	require.Error(t, err)
+}
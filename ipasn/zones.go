@@ -0,0 +1,263 @@
+// Copyright 2019 Freman/Fremnet (Shannon Wynter). All rights reserved.
+
+package ipasn
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+)
+
+// DefaultZone is the parent of the Team Cymru zones
+const DefaultZone = "asn.cymru.com."
+
+// Zones are the DNS zones a Client queries, any that are empty fall back to
+// those of DefaultZones. Names are fully qualified, the trailing dot is
+// added if it's missing.
+type Zones struct {
+	// Origin and Origin6 are queried for the origin of IPv4 and IPv6
+	// addresses respectively, eg: 31.108.90.216.origin.asn.cymru.com.
+	Origin  string
+	Origin6 string
+
+	// Peer is queried for the peers of both IPv4 and IPv6 addresses
+	Peer string
+
+	// ASN is queried for the description of ASNs, eg: AS23028.asn.cymru.com.
+	ASN string
+}
+
+// Query is a query name taken apart by Zones.ParseName
+type Query struct {
+	// Zone is which zone the name is in, one of origin, origin6, peer or asn
+	Zone string
+
+	// IP is the address of origin and peer queries, the missing octets, or
+	// nibbles, of truncated names are zero
+	IP net.IP
+
+	// ASN is the ASN of asn queries
+	ASN int
+}
+
+// DefaultZones returns the Team Cymru zones
+func DefaultZones() Zones {
+	return ZonesUnder(DefaultZone)
+}
+
+// ZonesUnder returns zones laid out the same way as the Team Cymru ones under
+// zone, ie: origin.zone, origin6.zone, peer.zone and zone itself, eg: for an
+// internal mirror.
+func ZonesUnder(zone string) Zones {
+	zone = fqdn(zone)
+
+	return Zones{
+		Origin:  "origin." + zone,
+		Origin6: "origin6." + zone,
+		Peer:    "peer." + zone,
+		ASN:     zone,
+	}
+}
+
+// withDefaults fills in the empty zones from DefaultZones
+func (z Zones) withDefaults() Zones {
+	def := DefaultZones()
+
+	for _, zone := range []struct {
+		name *string
+		def  string
+	}{
+		{&z.Origin, def.Origin},
+		{&z.Origin6, def.Origin6},
+		{&z.Peer, def.Peer},
+		{&z.ASN, def.ASN},
+	} {
+		if *zone.name == "" {
+			*zone.name = zone.def
+		} else {
+			*zone.name = fqdn(*zone.name)
+		}
+	}
+
+	return z
+}
+
+// OriginName returns the name queried for the origin of ip, or an empty
+// string if ip isn't valid
+func (z Zones) OriginName(ip net.IP) string {
+	z = z.withDefaults()
+
+	if ip.To4() == nil {
+		return reverseIn(ip, z.Origin6)
+	}
+
+	return reverseIn(ip, z.Origin)
+}
+
+// PeerName returns the name queried for the peers of ip, or an empty string
+// if ip isn't valid
+func (z Zones) PeerName(ip net.IP) string {
+	return reverseIn(ip, z.withDefaults().Peer)
+}
+
+// reverseIn returns the ReverseName of ip in zone, or an empty string if
+// there isn't one
+func reverseIn(ip net.IP, zone string) string {
+	name := ReverseName(ip)
+	if name == "" {
+		return ""
+	}
+
+	return name + "." + zone
+}
+
+// ASNName returns the name queried for the description of asn
+func (z Zones) ASNName(asn int) string {
+	return "AS" + strconv.Itoa(asn) + "." + z.withDefaults().ASN
+}
+
+// Name returns the name queried for q, it's the inverse of ParseName
+func (z Zones) Name(q Query) string {
+	switch q.Zone {
+	case "asn":
+		return z.ASNName(q.ASN)
+	case "peer":
+		return z.PeerName(q.IP)
+	}
+
+	return z.OriginName(q.IP)
+}
+
+// ReverseName returns the octets of an IPv4 address, or the nibbles of an
+// IPv6 address, in reverse order separated by dots, eg: 31.108.90.216 for
+// 216.90.108.31. It returns an empty string if ip isn't valid.
+func ReverseName(ip net.IP) string {
+	const hexDigit = "0123456789abcdef"
+
+	if ip4 := ip.To4(); ip4 != nil {
+		return strings.Join([]string{
+			strconv.Itoa(int(ip4[3])),
+			strconv.Itoa(int(ip4[2])),
+			strconv.Itoa(int(ip4[1])),
+			strconv.Itoa(int(ip4[0])),
+		}, ".")
+	}
+
+	if len(ip) != net.IPv6len {
+		return ""
+	}
+
+	buf := make([]byte, 0, len(ip)*4-1)
+
+	for i := len(ip) - 1; i >= 0; i-- {
+		if i != len(ip)-1 {
+			buf = append(buf, '.')
+		}
+
+		buf = append(buf, hexDigit[ip[i]&0xF], '.', hexDigit[ip[i]>>4])
+	}
+
+	return string(buf)
+}
+
+// ParseName takes apart a name made by OriginName, PeerName or ASNName, it
+// also accepts truncated names, eg: 108.90.216.origin.asn.cymru.com. is
+// 216.90.108.0, and is case insensitive.
+//
+// It returns ErrUnknownZone if the name isn't in any of the zones, or why it
+// couldn't be parsed if it is.
+func (z Zones) ParseName(name string) (q Query, err error) {
+	z = z.withDefaults()
+	lower := fqdn(strings.ToLower(name))
+
+	zones := []struct {
+		zone, suffix string
+	}{
+		{"origin", z.Origin},
+		{"origin6", z.Origin6},
+		{"peer", z.Peer},
+		{"asn", z.ASN},
+	}
+
+	for _, zone := range zones {
+		suffix := strings.ToLower(zone.suffix)
+		if !strings.HasSuffix(lower, "."+suffix) {
+			continue
+		}
+
+		labels := strings.Split(strings.TrimSuffix(lower, "."+suffix), ".")
+		q.Zone = zone.zone
+
+		switch {
+		case zone.zone == "asn":
+			if len(labels) != 1 || !strings.HasPrefix(labels[0], "as") {
+				return q, ErrUnknownZone
+			}
+
+			q.ASN, err = strconv.Atoi(labels[0][2:])
+		case zone.zone == "origin", zone.zone == "peer" && len(labels) <= net.IPv4len:
+			q.IP, err = parseReversedIPv4(labels)
+		default:
+			q.IP, err = parseReversedIPv6(labels)
+		}
+
+		return q, err
+	}
+
+	return q, ErrUnknownZone
+}
+
+// parseReversedIPv4 handles reversed, and possibly truncated, dotted quads
+// eg: 108.90.216 is 216.90.108.0
+func parseReversedIPv4(labels []string) (net.IP, error) {
+	if len(labels) == 0 || len(labels) > net.IPv4len {
+		return nil, fmt.Errorf("expected up to %d octets, got %d", net.IPv4len, len(labels))
+	}
+
+	ip := make(net.IP, net.IPv4len)
+
+	for i, label := range labels {
+		octet, err := strconv.ParseUint(label, 10, 8)
+		if err != nil {
+			return nil, err
+		}
+
+		ip[len(labels)-1-i] = byte(octet)
+	}
+
+	return ip, nil
+}
+
+// parseReversedIPv6 handles reversed, and possibly truncated, nibbles
+func parseReversedIPv6(labels []string) (net.IP, error) {
+	if len(labels) == 0 || len(labels) > 2*net.IPv6len {
+		return nil, fmt.Errorf("expected up to %d nibbles, got %d", 2*net.IPv6len, len(labels))
+	}
+
+	ip := make(net.IP, net.IPv6len)
+
+	for i, label := range labels {
+		nibble, err := strconv.ParseUint(label, 16, 4)
+		if err != nil {
+			return nil, err
+		}
+
+		pos := len(labels) - 1 - i
+		if pos%2 == 0 {
+			nibble <<= 4
+		}
+
+		ip[pos/2] |= byte(nibble)
+	}
+
+	return ip, nil
+}
+
+func fqdn(name string) string {
+	if strings.HasSuffix(name, ".") {
+		return name
+	}
+
+	return name + "."
+}
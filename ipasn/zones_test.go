@@ -0,0 +1,128 @@
+// Copyright 2019 Freman/Fremnet (Shannon Wynter). All rights reserved.
+
+package ipasn_test
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/freman/cymru/ipasn"
+)
+
+func TestZonesNames(t *testing.T) {
+	t.Parallel()
+
+	v4 := net.ParseIP("216.90.108.31")
+	v6 := net.ParseIP("2001:4860:b002::68")
+
+	def := ipasn.DefaultZones()
+	require.Equal(t, ipasn.Zones{
+		Origin:  "origin.asn.cymru.com.",
+		Origin6: "origin6.asn.cymru.com.",
+		Peer:    "peer.asn.cymru.com.",
+		ASN:     "asn.cymru.com.",
+	}, def)
+
+	require.Equal(t, "31.108.90.216.origin.asn.cymru.com.", def.OriginName(v4))
+	require.Equal(t, "31.108.90.216.origin.asn.cymru.com.", def.OriginName(v4.To4()))
+	require.Equal(t, "8.6.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.2.0.0.b.0.6.8.4.1.0.0.2.origin6.asn.cymru.com.", def.OriginName(v6))
+	require.Equal(t, "31.108.90.216.peer.asn.cymru.com.", def.PeerName(v4))
+	require.Equal(t, "8.6.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.2.0.0.b.0.6.8.4.1.0.0.2.peer.asn.cymru.com.", def.PeerName(v6))
+	require.Equal(t, "AS23028.asn.cymru.com.", def.ASNName(23028))
+
+	// The zero value is the default
+	var zero ipasn.Zones
+	require.Equal(t, def.OriginName(v6), zero.OriginName(v6))
+
+	mirror := ipasn.ZonesUnder("asn.example.internal")
+	require.Equal(t, "31.108.90.216.origin.asn.example.internal.", mirror.OriginName(v4))
+	require.Equal(t, "AS23028.asn.example.internal.", mirror.ASNName(23028))
+
+	partial := ipasn.Zones{ASN: "names.example.internal"}
+	require.Equal(t, "AS23028.names.example.internal.", partial.ASNName(23028))
+	require.Equal(t, "31.108.90.216.peer.asn.cymru.com.", partial.PeerName(v4))
+
+	require.Equal(t, "31.108.90.216", ipasn.ReverseName(v4))
+	require.Equal(t, "", ipasn.ReverseName(nil))
+
+	// Never a malformed name for an invalid address
+	for _, ip := range []net.IP{nil, {1, 2, 3}} {
+		require.Equal(t, "", def.OriginName(ip))
+		require.Equal(t, "", def.PeerName(ip))
+		require.Equal(t, "", ipasn.Cymru{}.OriginName(ip))
+		require.Equal(t, "", ipasn.Cymru{}.PeerName(ip))
+	}
+}
+
+func TestZonesParseName(t *testing.T) {
+	t.Parallel()
+
+	zones := ipasn.ZonesUnder("asn.example.internal.")
+
+	tests := []struct {
+		name  string
+		query ipasn.Query
+		err   string
+	}{
+		{"31.108.90.216.origin.asn.example.internal.", ipasn.Query{Zone: "origin", IP: net.IPv4(216, 90, 108, 31).To4()}, ""},
+		{"108.90.216.ORIGIN.ASN.EXAMPLE.INTERNAL", ipasn.Query{Zone: "origin", IP: net.IPv4(216, 90, 108, 0).To4()}, ""},
+		{"0.6.8.4.1.0.0.2.origin6.asn.example.internal.", ipasn.Query{Zone: "origin6", IP: net.ParseIP("2001:4860::")}, ""},
+		{"31.108.90.216.peer.asn.example.internal.", ipasn.Query{Zone: "peer", IP: net.IPv4(216, 90, 108, 31).To4()}, ""},
+		{"0.6.8.4.1.0.0.2.peer.asn.example.internal.", ipasn.Query{Zone: "peer", IP: net.ParseIP("2001:4860::")}, ""},
+		{"AS23028.asn.example.internal.", ipasn.Query{Zone: "asn", ASN: 23028}, ""},
+		{"AS23028.asn.cymru.com.", ipasn.Query{}, ipasn.ErrUnknownZone.Error()},
+		{"asn.example.internal.", ipasn.Query{}, ipasn.ErrUnknownZone.Error()},
+		{"1.2.asn.example.internal.", ipasn.Query{}, ipasn.ErrUnknownZone.Error()},
+		{"1.2.3.4.5.origin.asn.example.internal.", ipasn.Query{}, "expected up to 4 octets, got 5"},
+		{"x.origin6.asn.example.internal.", ipasn.Query{}, `strconv.ParseUint: parsing "x": invalid syntax`},
+	}
+
+	for _, test := range tests {
+		q, err := zones.ParseName(test.name)
+		if test.err != "" {
+			require.EqualError(t, err, test.err, test.name)
+			continue
+		}
+
+		require.NoError(t, err, test.name)
+		require.Equal(t, test.query, q, test.name)
+	}
+
+	for _, name := range []string{
+		zones.OriginName(net.ParseIP("216.90.108.31")),
+		zones.OriginName(net.ParseIP("2001:4860:b002::68")),
+		zones.PeerName(net.ParseIP("2001:4860:b002::68")),
+		zones.ASNName(15169),
+	} {
+		q, err := zones.ParseName(name)
+		require.NoError(t, err)
+		require.Equal(t, name, zones.Name(q))
+	}
+}
+
+func TestClientZones(t *testing.T) {
+	t.Parallel()
+
+	var names []string
+
+	c := ipasn.NewClient(
+		ipasn.WithZones(ipasn.ZonesUnder("asn.example.internal")),
+		ipasn.WithResolver(mockResolver(func(ctx context.Context, name string) ([]string, error) {
+			names = append(names, name)
+			return nil, nil
+		})),
+	)
+
+	_, _ = c.Origin(context.TODO(), net.ParseIP("216.90.108.31"))
+	_, _ = c.Peer(context.TODO(), net.ParseIP("216.90.108.31"))
+	_, _ = c.ASN(context.TODO(), 23028)
+
+	require.Equal(t, []string{
+		"31.108.90.216.origin.asn.example.internal.",
+		"31.108.90.216.peer.asn.example.internal.",
+		"AS23028.asn.example.internal.",
+	}, names)
+}